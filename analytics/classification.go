@@ -0,0 +1,124 @@
+package analytics
+
+import (
+	"math"
+	"sort"
+)
+
+// ABCThresholds - границы классов ABC в процентах накопленной доли
+type ABCThresholds struct {
+	A float64
+	B float64
+}
+
+// XYZThresholds - границы классов XYZ по коэффициенту вариации спроса
+type XYZThresholds struct {
+	X float64
+	Y float64
+}
+
+var (
+	DefaultABCThresholds = ABCThresholds{A: 80, B: 95}
+	DefaultXYZThresholds = XYZThresholds{X: 0.1, Y: 0.25}
+)
+
+// ValueItem - значение показателя (стоимость запаса, выручка) для товара
+type ValueItem struct {
+	ID    uint
+	Value float64
+}
+
+// ClassifyABC распределяет товары по классам A, B и C по накопленной доле показателя.
+// Товар попадает в класс, в который входила накопленная доля до его добавления,
+// поэтому самый ценный товар всегда относится к классу A.
+func ClassifyABC(items []ValueItem, t ABCThresholds) map[uint]string {
+	sorted := make([]ValueItem, len(items))
+	copy(sorted, items)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Value > sorted[j].Value
+	})
+
+	total := 0.0
+	for _, item := range sorted {
+		if item.Value > 0 {
+			total += item.Value
+		}
+	}
+
+	classes := make(map[uint]string, len(sorted))
+	cumulative := 0.0
+	for _, item := range sorted {
+		if total <= 0 || item.Value <= 0 {
+			classes[item.ID] = "C"
+			continue
+		}
+
+		share := cumulative / total * 100
+		switch {
+		case share < t.A:
+			classes[item.ID] = "A"
+		case share < t.B:
+			classes[item.ID] = "B"
+		default:
+			classes[item.ID] = "C"
+		}
+		cumulative += item.Value
+	}
+	return classes
+}
+
+// Aggregate суммирует ряд по периодам длиной period (например, дни в недели).
+// Неполный первый период отбрасывается, чтобы не искажать вариацию.
+func Aggregate(series []float64, period int) []float64 {
+	if period <= 1 {
+		return series
+	}
+
+	offset := len(series) % period
+	result := make([]float64, 0, len(series)/period)
+	for i := offset; i+period <= len(series); i += period {
+		sum := 0.0
+		for _, v := range series[i : i+period] {
+			sum += v
+		}
+		result = append(result, sum)
+	}
+	return result
+}
+
+// CoefficientOfVariation возвращает отношение стандартного отклонения к среднему.
+// Для ряда без спроса возвращает +Inf.
+func CoefficientOfVariation(series []float64) float64 {
+	if len(series) == 0 {
+		return math.Inf(1)
+	}
+
+	mean := 0.0
+	for _, v := range series {
+		mean += v
+	}
+	mean /= float64(len(series))
+	if mean <= 0 {
+		return math.Inf(1)
+	}
+
+	variance := 0.0
+	for _, v := range series {
+		variance += (v - mean) * (v - mean)
+	}
+	variance /= float64(len(series))
+
+	return math.Sqrt(variance) / mean
+}
+
+// ClassifyXYZ определяет класс X, Y или Z по коэффициенту вариации спроса
+func ClassifyXYZ(cv float64, t XYZThresholds) string {
+	switch {
+	case cv <= t.X:
+		return "X"
+	case cv <= t.Y:
+		return "Y"
+	default:
+		return "Z"
+	}
+}
@@ -0,0 +1,133 @@
+package analytics
+
+import (
+	"math"
+	"testing"
+)
+
+func TestClassifyABC(t *testing.T) {
+	tests := []struct {
+		name  string
+		items []ValueItem
+		want  map[uint]string
+	}{
+		{
+			// Доля до добавления товара: 0, 50, 80 и 95% - границы A и B не входят в класс
+			name:  "границы классов",
+			items: []ValueItem{{1, 15}, {2, 50}, {3, 5}, {4, 30}},
+			want:  map[uint]string{2: "A", 4: "A", 1: "B", 3: "C"},
+		},
+		{
+			name:  "самый ценный товар всегда в классе A",
+			items: []ValueItem{{1, 99}, {2, 1}},
+			want:  map[uint]string{1: "A", 2: "C"},
+		},
+		{
+			name:  "нулевые и отрицательные значения - класс C",
+			items: []ValueItem{{1, 10}, {2, 0}, {3, -5}},
+			want:  map[uint]string{1: "A", 2: "C", 3: "C"},
+		},
+		{
+			name:  "нет показателя ни у одного товара",
+			items: []ValueItem{{1, 0}, {2, 0}},
+			want:  map[uint]string{1: "C", 2: "C"},
+		},
+		{
+			name:  "пустой список",
+			items: nil,
+			want:  map[uint]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ClassifyABC(tt.items, DefaultABCThresholds)
+			if len(got) != len(tt.want) {
+				t.Fatalf("ClassifyABC = %v, want %v", got, tt.want)
+			}
+			for id, class := range tt.want {
+				if got[id] != class {
+					t.Errorf("ClassifyABC = %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestClassifyABCThresholds(t *testing.T) {
+	items := []ValueItem{{1, 40}, {2, 30}, {3, 20}, {4, 10}}
+	got := ClassifyABC(items, ABCThresholds{A: 50, B: 90})
+	want := map[uint]string{1: "A", 2: "A", 3: "B", 4: "C"}
+	for id, class := range want {
+		if got[id] != class {
+			t.Fatalf("ClassifyABC = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	tests := []struct {
+		name   string
+		series []float64
+		period int
+		want   []float64
+	}{
+		{"недели", []float64{1, 1, 1, 1, 1, 1, 1, 2, 2, 2, 2, 2, 2, 2}, 7, []float64{7, 14}},
+		{"неполный первый период отбрасывается", []float64{9, 1, 2, 3, 4}, 2, []float64{3, 7}},
+		{"ряд короче периода", []float64{1, 2}, 3, []float64{}},
+		{"период 1 - без изменений", []float64{1, 2, 3}, 1, []float64{1, 2, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Aggregate(tt.series, tt.period)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Aggregate(%v, %d) = %v, want %v", tt.series, tt.period, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("Aggregate(%v, %d) = %v, want %v", tt.series, tt.period, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestCoefficientOfVariation(t *testing.T) {
+	tests := []struct {
+		name   string
+		series []float64
+		want   float64
+	}{
+		{"постоянный спрос", []float64{5, 5, 5}, 0},
+		{"переменный спрос", []float64{2, 4, 4, 4, 5, 5, 7, 9}, 0.4},
+		{"нет спроса", []float64{0, 0, 0}, math.Inf(1)},
+		{"пустой ряд", nil, math.Inf(1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CoefficientOfVariation(tt.series)
+			if got != tt.want && !almostEqual(got, tt.want) {
+				t.Errorf("CoefficientOfVariation(%v) = %v, want %v", tt.series, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClassifyXYZ(t *testing.T) {
+	tests := []struct {
+		cv   float64
+		want string
+	}{
+		{0, "X"},
+		{0.1, "X"},
+		{0.1000001, "Y"},
+		{0.25, "Y"},
+		{0.26, "Z"},
+		{math.Inf(1), "Z"},
+	}
+	for _, tt := range tests {
+		if got := ClassifyXYZ(tt.cv, DefaultXYZThresholds); got != tt.want {
+			t.Errorf("ClassifyXYZ(%v) = %s, want %s", tt.cv, got, tt.want)
+		}
+	}
+}
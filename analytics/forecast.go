@@ -0,0 +1,190 @@
+// Package analytics содержит расчеты для аналитических отчетов склада.
+// Функции пакета не обращаются к БД и работают только с переданными данными.
+package analytics
+
+import (
+	"math"
+	"time"
+)
+
+// Shipment - отгрузка товара в определенный момент времени
+type Shipment struct {
+	Date     time.Time
+	Quantity int
+}
+
+// Method - метод прогнозирования спроса
+type Method string
+
+const (
+	MethodMovingAverage Method = "moving_average"
+	MethodExponential   Method = "exponential_smoothing"
+)
+
+// ForecastParams - параметры прогнозирования
+type ForecastParams struct {
+	Method Method
+	// Window - окно скользящего среднего в днях
+	Window int
+	// Alpha - коэффициент экспоненциального сглаживания (0 < Alpha <= 1)
+	Alpha float64
+}
+
+// DefaultForecastParams - параметры по умолчанию
+var DefaultForecastParams = ForecastParams{
+	Method: MethodMovingAverage,
+	Window: 30,
+	Alpha:  0.3,
+}
+
+// Forecast - прогноз спроса по товару
+type Forecast struct {
+	DailyDemand float64
+	// DaysOfCover - на сколько дней хватит запаса, +Inf если спроса нет
+	DaysOfCover  float64
+	StockOutDate time.Time
+	// HasStockOut - ожидается ли исчерпание запаса
+	HasStockOut bool
+}
+
+// Accuracy - сравнение прогноза с фактическими продажами
+type Accuracy struct {
+	Forecast float64
+	Actual   float64
+	// Error - абсолютная ошибка прогноза
+	Error float64
+	// ErrorPercent - ошибка в процентах от факта, NaN если факт нулевой
+	ErrorPercent float64
+}
+
+// DailySeries раскладывает отгрузки по дням в интервале [from, to].
+// Отгрузки вне интервала игнорируются.
+func DailySeries(shipments []Shipment, from, to time.Time) []float64 {
+	from = truncateDay(from)
+	to = truncateDay(to)
+	if to.Before(from) {
+		return nil
+	}
+
+	days := daysBetween(from, to) + 1
+	series := make([]float64, days)
+	for _, s := range shipments {
+		day := daysBetween(from, truncateDay(s.Date.In(from.Location())))
+		if day < 0 || day >= days {
+			continue
+		}
+		series[day] += float64(s.Quantity)
+	}
+	return series
+}
+
+// MovingAverage возвращает среднее значение последних window элементов ряда
+func MovingAverage(series []float64, window int) float64 {
+	if len(series) == 0 {
+		return 0
+	}
+	if window <= 0 || window > len(series) {
+		window = len(series)
+	}
+
+	sum := 0.0
+	for _, v := range series[len(series)-window:] {
+		sum += v
+	}
+	return sum / float64(window)
+}
+
+// ExponentialSmoothing возвращает сглаженный уровень ряда (простое экспоненциальное сглаживание)
+func ExponentialSmoothing(series []float64, alpha float64) float64 {
+	if len(series) == 0 {
+		return 0
+	}
+	if alpha <= 0 || alpha > 1 {
+		alpha = DefaultForecastParams.Alpha
+	}
+
+	level := series[0]
+	for _, v := range series[1:] {
+		level = alpha*v + (1-alpha)*level
+	}
+	return level
+}
+
+// DailyDemand рассчитывает прогноз спроса в день выбранным методом
+func DailyDemand(series []float64, params ForecastParams) float64 {
+	switch params.Method {
+	case MethodExponential:
+		return ExponentialSmoothing(series, params.Alpha)
+	default:
+		return MovingAverage(series, params.Window)
+	}
+}
+
+// DaysOfCover возвращает, на сколько дней хватит запаса при заданном спросе
+func DaysOfCover(stock int, dailyDemand float64) float64 {
+	if stock <= 0 {
+		return 0
+	}
+	if dailyDemand <= 0 {
+		return math.Inf(1)
+	}
+	return float64(stock) / dailyDemand
+}
+
+// StockOutDate возвращает ожидаемую дату исчерпания запаса.
+// Второе значение false, если при нулевом спросе запас не закончится.
+func StockOutDate(now time.Time, stock int, dailyDemand float64) (time.Time, bool) {
+	cover := DaysOfCover(stock, dailyDemand)
+	if math.IsInf(cover, 1) {
+		return time.Time{}, false
+	}
+	return now.Add(time.Duration(cover * 24 * float64(time.Hour))), true
+}
+
+// ForecastProduct строит прогноз спроса и запаса по дневному ряду продаж
+func ForecastProduct(series []float64, stock int, now time.Time, params ForecastParams) Forecast {
+	demand := DailyDemand(series, params)
+	f := Forecast{
+		DailyDemand: demand,
+		DaysOfCover: DaysOfCover(stock, demand),
+	}
+	f.StockOutDate, f.HasStockOut = StockOutDate(now, stock, demand)
+	return f
+}
+
+// Backtest сравнивает прогноз с фактом: модель строится по ряду без последних
+// holdout дней, а прогноз на эти дни сравнивается с фактическими продажами.
+func Backtest(series []float64, holdout int, params ForecastParams) Accuracy {
+	if holdout <= 0 || holdout >= len(series) {
+		return Accuracy{ErrorPercent: math.NaN()}
+	}
+
+	train := series[:len(series)-holdout]
+	forecast := DailyDemand(train, params) * float64(holdout)
+
+	actual := 0.0
+	for _, v := range series[len(series)-holdout:] {
+		actual += v
+	}
+
+	a := Accuracy{
+		Forecast:     forecast,
+		Actual:       actual,
+		Error:        math.Abs(forecast - actual),
+		ErrorPercent: math.NaN(),
+	}
+	if actual > 0 {
+		a.ErrorPercent = a.Error / actual * 100
+	}
+	return a
+}
+
+func truncateDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// daysBetween возвращает число календарных дней между датами (с учетом перехода на летнее время)
+func daysBetween(from, to time.Time) int {
+	return int(math.Round(to.Sub(from).Hours() / 24))
+}
@@ -0,0 +1,277 @@
+package analytics
+
+import (
+	"math"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+// almostEqual сравнивает дробные числа с учетом погрешности вычислений
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestMovingAverage(t *testing.T) {
+	tests := []struct {
+		name   string
+		series []float64
+		window int
+		want   float64
+	}{
+		{"пустой ряд", nil, 7, 0},
+		{"последние элементы", []float64{10, 0, 2, 4, 6}, 3, 4},
+		{"окно длиннее ряда", []float64{1, 2, 3}, 10, 2},
+		{"нулевое окно - весь ряд", []float64{1, 2, 3, 6}, 0, 3},
+		{"отрицательное окно - весь ряд", []float64{4, 8}, -1, 6},
+		{"окно из одного дня", []float64{5, 7}, 1, 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MovingAverage(tt.series, tt.window); !almostEqual(got, tt.want) {
+				t.Errorf("MovingAverage(%v, %d) = %v, want %v", tt.series, tt.window, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExponentialSmoothing(t *testing.T) {
+	tests := []struct {
+		name   string
+		series []float64
+		alpha  float64
+		want   float64
+	}{
+		{"пустой ряд", nil, 0.5, 0},
+		{"один элемент", []float64{4}, 0.5, 4},
+		{"alpha 0.5", []float64{10, 20, 30}, 0.5, 22.5},
+		{"alpha 1 - последнее значение", []float64{10, 20, 30}, 1, 30},
+		{"постоянный ряд", []float64{3, 3, 3, 3}, 0.2, 3},
+		// Некорректный коэффициент заменяется коэффициентом по умолчанию 0.3
+		{"alpha 0", []float64{10, 20}, 0, 13},
+		{"alpha больше 1", []float64{10, 20}, 1.5, 13},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExponentialSmoothing(tt.series, tt.alpha); !almostEqual(got, tt.want) {
+				t.Errorf("ExponentialSmoothing(%v, %v) = %v, want %v", tt.series, tt.alpha, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDailyDemand(t *testing.T) {
+	series := []float64{10, 20, 30}
+	if got := DailyDemand(series, ForecastParams{Method: MethodMovingAverage, Window: 2}); !almostEqual(got, 25) {
+		t.Errorf("скользящее среднее: %v, want 25", got)
+	}
+	if got := DailyDemand(series, ForecastParams{Method: MethodExponential, Alpha: 0.5}); !almostEqual(got, 22.5) {
+		t.Errorf("экспоненциальное сглаживание: %v, want 22.5", got)
+	}
+}
+
+func TestDaysOfCover(t *testing.T) {
+	tests := []struct {
+		name   string
+		stock  int
+		demand float64
+		want   float64
+	}{
+		{"обычный расход", 30, 2, 15},
+		{"дробный спрос", 10, 4, 2.5},
+		{"нулевой спрос", 30, 0, math.Inf(1)},
+		{"отрицательный спрос", 30, -1, math.Inf(1)},
+		{"нет запаса", 0, 5, 0},
+		{"нет запаса и спроса", 0, 0, 0},
+		{"отрицательный запас", -3, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DaysOfCover(tt.stock, tt.demand); got != tt.want {
+				t.Errorf("DaysOfCover(%d, %v) = %v, want %v", tt.stock, tt.demand, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStockOutDate(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		stock  int
+		demand float64
+		want   time.Time
+		ok     bool
+	}{
+		{"через 15 дней", 30, 2, now.AddDate(0, 0, 15), true},
+		{"через полдня", 1, 2, now.Add(12 * time.Hour), true},
+		{"запаса нет", 0, 2, now, true},
+		{"нет спроса", 30, 0, time.Time{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := StockOutDate(now, tt.stock, tt.demand)
+			if ok != tt.ok || !got.Equal(tt.want) {
+				t.Errorf("StockOutDate(%d, %v) = %v, %v, want %v, %v", tt.stock, tt.demand, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestForecastProduct(t *testing.T) {
+	now := time.Date(2024, 5, 10, 0, 0, 0, 0, time.UTC)
+	f := ForecastProduct([]float64{0, 4, 2, 2}, 10, now, ForecastParams{Method: MethodMovingAverage, Window: 2})
+	if f.DailyDemand != 2 || f.DaysOfCover != 5 || !f.HasStockOut || !f.StockOutDate.Equal(now.AddDate(0, 0, 5)) {
+		t.Errorf("ForecastProduct = %+v", f)
+	}
+
+	f = ForecastProduct(make([]float64, 30), 10, now, DefaultForecastParams)
+	if f.DailyDemand != 0 || !math.IsInf(f.DaysOfCover, 1) || f.HasStockOut {
+		t.Errorf("прогноз без продаж: %+v", f)
+	}
+}
+
+func TestBacktest(t *testing.T) {
+	params := ForecastParams{Method: MethodMovingAverage, Window: 3}
+	tests := []struct {
+		name    string
+		series  []float64
+		holdout int
+		want    Accuracy
+	}{
+		{
+			name:    "прогноз выше факта",
+			series:  []float64{2, 2, 2, 1, 1},
+			holdout: 2,
+			want:    Accuracy{Forecast: 4, Actual: 2, Error: 2, ErrorPercent: 100},
+		},
+		{
+			name:    "точный прогноз",
+			series:  []float64{3, 3, 3, 3, 3, 3},
+			holdout: 3,
+			want:    Accuracy{Forecast: 9, Actual: 9, Error: 0, ErrorPercent: 0},
+		},
+		{
+			name:    "нулевой факт",
+			series:  []float64{1, 1, 1, 0},
+			holdout: 1,
+			want:    Accuracy{Forecast: 1, Actual: 0, Error: 1, ErrorPercent: math.NaN()},
+		},
+		{
+			name:    "контрольный период не меньше ряда",
+			series:  []float64{1, 2},
+			holdout: 2,
+			want:    Accuracy{ErrorPercent: math.NaN()},
+		},
+		{
+			name:    "нулевой контрольный период",
+			series:  []float64{1, 2},
+			holdout: 0,
+			want:    Accuracy{ErrorPercent: math.NaN()},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Backtest(tt.series, tt.holdout, params)
+			samePercent := almostEqual(got.ErrorPercent, tt.want.ErrorPercent) ||
+				math.IsNaN(got.ErrorPercent) && math.IsNaN(tt.want.ErrorPercent)
+			if !almostEqual(got.Forecast, tt.want.Forecast) || !almostEqual(got.Actual, tt.want.Actual) ||
+				!almostEqual(got.Error, tt.want.Error) || !samePercent {
+				t.Errorf("Backtest(%v, %d) = %+v, want %+v", tt.series, tt.holdout, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDailySeries(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(loc *time.Location, month time.Month, day, hour int) time.Time {
+		return time.Date(2024, month, day, hour, 0, 0, 0, loc)
+	}
+
+	tests := []struct {
+		name      string
+		shipments []Shipment
+		from, to  time.Time
+		want      []float64
+	}{
+		{
+			name: "отгрузки складываются по дням",
+			shipments: []Shipment{
+				{Date: at(time.UTC, 5, 1, 9), Quantity: 2},
+				{Date: at(time.UTC, 5, 1, 18), Quantity: 3},
+				{Date: at(time.UTC, 5, 3, 0), Quantity: 1},
+			},
+			from: at(time.UTC, 5, 1, 15),
+			to:   at(time.UTC, 5, 3, 8),
+			want: []float64{5, 0, 1},
+		},
+		{
+			name: "отгрузки вне интервала не учитываются",
+			shipments: []Shipment{
+				{Date: at(time.UTC, 4, 30, 23), Quantity: 7},
+				{Date: at(time.UTC, 5, 2, 12), Quantity: 1},
+				{Date: at(time.UTC, 5, 3, 0), Quantity: 7},
+			},
+			from: at(time.UTC, 5, 1, 0),
+			to:   at(time.UTC, 5, 2, 23),
+			want: []float64{0, 1},
+		},
+		{
+			name:      "конец раньше начала",
+			shipments: []Shipment{{Date: at(time.UTC, 5, 1, 0), Quantity: 1}},
+			from:      at(time.UTC, 5, 2, 0),
+			to:        at(time.UTC, 5, 1, 0),
+			want:      nil,
+		},
+		{
+			// 31 марта 2024 в Берлине длится 23 часа
+			name: "переход на летнее время",
+			shipments: []Shipment{
+				{Date: at(berlin, 3, 30, 23), Quantity: 1},
+				{Date: at(berlin, 3, 31, 1), Quantity: 2},
+				{Date: at(berlin, 3, 31, 23), Quantity: 3},
+				{Date: at(berlin, 4, 1, 0), Quantity: 4},
+			},
+			from: at(berlin, 3, 30, 0),
+			to:   at(berlin, 4, 1, 12),
+			want: []float64{1, 5, 4},
+		},
+		{
+			// 27 октября 2024 в Берлине длится 25 часов
+			name: "переход на зимнее время",
+			shipments: []Shipment{
+				{Date: at(berlin, 10, 27, 0), Quantity: 1},
+				{Date: at(berlin, 10, 27, 23), Quantity: 2},
+				{Date: at(berlin, 10, 28, 0), Quantity: 3},
+			},
+			from: at(berlin, 10, 26, 12),
+			to:   at(berlin, 10, 28, 12),
+			want: []float64{0, 3, 3},
+		},
+		{
+			// Отгрузка в UTC относится к дню в часовом поясе начала интервала
+			name:      "отгрузка в другом часовом поясе",
+			shipments: []Shipment{{Date: time.Date(2024, 3, 30, 23, 30, 0, 0, time.UTC), Quantity: 5}},
+			from:      at(berlin, 3, 30, 0),
+			to:        at(berlin, 3, 31, 0),
+			want:      []float64{0, 5},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DailySeries(tt.shipments, tt.from, tt.to)
+			if len(got) != len(tt.want) {
+				t.Fatalf("DailySeries = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("DailySeries = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package analytics
+
+import (
+	"time"
+)
+
+// Movement - изменение остатка товара: приход положительный, расход отрицательный
+type Movement struct {
+	Date     time.Time
+	Quantity int
+}
+
+// StockHistory восстанавливает остаток на конец каждого дня интервала [from, to].
+// Расчет идет от текущего остатка назад: из него вычитаются все движения,
+// совершенные после соответствующего дня.
+func StockHistory(current int, movements []Movement, from, to time.Time) []float64 {
+	from = truncateDay(from)
+	to = truncateDay(to)
+	if to.Before(from) {
+		return nil
+	}
+
+	days := daysBetween(from, to) + 1
+	deltas := make([]float64, days)
+	// Движения после конца интервала уже учтены в текущем остатке
+	after := 0.0
+	for _, m := range movements {
+		day := daysBetween(from, truncateDay(m.Date.In(from.Location())))
+		switch {
+		case day >= days:
+			after += float64(m.Quantity)
+		case day >= 0:
+			deltas[day] += float64(m.Quantity)
+		}
+	}
+
+	levels := make([]float64, days)
+	level := float64(current) - after
+	for i := days - 1; i >= 0; i-- {
+		levels[i] = level
+		level -= deltas[i]
+	}
+	return levels
+}
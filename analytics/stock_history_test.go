@@ -0,0 +1,90 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStockHistory(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	day := func(loc *time.Location, month time.Month, d, hour int) time.Time {
+		return time.Date(2024, month, d, hour, 0, 0, 0, loc)
+	}
+
+	tests := []struct {
+		name      string
+		current   int
+		movements []Movement
+		from, to  time.Time
+		want      []float64
+	}{
+		{
+			name:    "без движений остаток не меняется",
+			current: 5,
+			from:    day(time.UTC, 5, 1, 0),
+			to:      day(time.UTC, 5, 3, 0),
+			want:    []float64{5, 5, 5},
+		},
+		{
+			name:    "приход и расход внутри интервала",
+			current: 8,
+			movements: []Movement{
+				{Date: day(time.UTC, 5, 2, 10), Quantity: 10},
+				{Date: day(time.UTC, 5, 3, 15), Quantity: -4},
+				{Date: day(time.UTC, 5, 3, 16), Quantity: -1},
+			},
+			from: day(time.UTC, 5, 1, 0),
+			to:   day(time.UTC, 5, 3, 0),
+			want: []float64{3, 13, 8},
+		},
+		{
+			// Движения после интервала уже вошли в текущий остаток, до интервала - в начальный
+			name:    "движения вне интервала",
+			current: 20,
+			movements: []Movement{
+				{Date: day(time.UTC, 4, 30, 12), Quantity: 7},
+				{Date: day(time.UTC, 5, 2, 12), Quantity: -2},
+				{Date: day(time.UTC, 5, 5, 9), Quantity: 10},
+			},
+			from: day(time.UTC, 5, 1, 0),
+			to:   day(time.UTC, 5, 2, 23),
+			want: []float64{12, 10},
+		},
+		{
+			name:      "конец раньше начала",
+			current:   1,
+			movements: []Movement{{Date: day(time.UTC, 5, 1, 0), Quantity: 1}},
+			from:      day(time.UTC, 5, 2, 0),
+			to:        day(time.UTC, 5, 1, 0),
+			want:      nil,
+		},
+		{
+			// 31 марта 2024 в Берлине длится 23 часа: движение в 23:30 относится к этому дню
+			name:    "переход на летнее время",
+			current: 0,
+			movements: []Movement{
+				{Date: time.Date(2024, 3, 31, 23, 30, 0, 0, berlin), Quantity: -3},
+				{Date: day(berlin, 4, 1, 8), Quantity: -2},
+			},
+			from: day(berlin, 3, 30, 0),
+			to:   day(berlin, 4, 1, 0),
+			want: []float64{5, 2, 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := StockHistory(tt.current, tt.movements, tt.from, tt.to)
+			if len(got) != len(tt.want) {
+				t.Fatalf("StockHistory = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("StockHistory = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"SanWarehouse/config"
+	db "SanWarehouse/database"
+	"SanWarehouse/models"
+)
+
+// runCommand выполняет подкоманду командной строки. Возвращает false,
+// если аргументы не содержат известной подкоманды и нужно запустить GUI.
+func runCommand(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+
+	switch args[0] {
+	case "generate":
+		runGenerate(args[1:])
+	case "backup":
+		runBackup(args[1:])
+	case "export":
+		runExport(args[1:])
+	case "import":
+		runImport(args[1:])
+	case "rates":
+		runRates(args[1:])
+	default:
+		return false
+	}
+	return true
+}
+
+// runGenerate создает синтетический каталог и замеряет время типовых запросов
+func runGenerate(args []string) {
+	fs := flag.NewFlagSet("generate", flag.ExitOnError)
+	count := fs.Int("n", 100000, "количество товаров")
+	seed := fs.Int64("seed", time.Now().UnixNano(), "начальное значение генератора случайных чисел")
+	fs.Parse(args)
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal("Ошибка чтения настроек:", err)
+	}
+	if err := db.InitDB(cfg.Database); err != nil {
+		log.Fatal("Ошибка инициализации БД:", err)
+	}
+	defer db.CloseDB()
+
+	started := time.Now()
+	err = db.GenerateCatalog(*count, *seed, func(done int) {
+		if done%10000 == 0 || done == *count {
+			fmt.Printf("\rСоздано товаров: %d из %d", done, *count)
+		}
+	})
+	fmt.Println()
+	if err != nil {
+		log.Fatal("Ошибка генерации каталога:", err)
+	}
+	fmt.Printf("Генерация заняла %s\n", time.Since(started).Round(time.Millisecond))
+
+	// Замеры запросов, которые выполняет GUI
+	measure := func(title string, fn func()) {
+		started := time.Now()
+		fn()
+		fmt.Printf("%-40s %s\n", title, time.Since(started).Round(time.Microsecond))
+	}
+
+	var total int64
+	measure("Количество товаров", func() {
+		db.DB.Model(&models.Product{}).Count(&total)
+	})
+
+	var page db.ProductPage
+	measure("Первая страница по названию", func() {
+		page, _ = db.ProductsPage(db.DB.Model(&models.Product{}), "name", false, nil, 200)
+	})
+	for i := 0; i < 50 && page.Next != nil; i++ {
+		page, _ = db.ProductsPage(db.DB.Model(&models.Product{}), "name", false, page.Next, 200)
+	}
+	measure("Страница после 10 000 строк", func() {
+		if page.Next != nil {
+			db.ProductsPage(db.DB.Model(&models.Product{}), "name", false, page.Next, 200)
+		}
+	})
+
+	measure("Поиск \"смеситель grohe\"", func() {
+		db.SearchProducts(db.DB.Model(&models.Product{}), "смеситель grohe").Count(&total)
+	})
+
+	var values []struct {
+		Category string
+		Value    models.Money
+	}
+	measure("Стоимость запаса по категориям", func() {
+		db.DB.Model(&models.Product{}).
+			Select("category, sum(quantity * " + db.PurchaseCostSQL(db.CurrentExchangeRates()) + ") AS value").
+			Group("category").
+			Scan(&values)
+	})
+}
+
+// runBackup создает резервную копию базы, а также выводит список копий,
+// проверяет и восстанавливает копию
+func runBackup(args []string) {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal("Ошибка чтения настроек:", err)
+	}
+
+	fs := flag.NewFlagSet("backup", flag.ExitOnError)
+	dir := fs.String("dir", cfg.Backup.Dir, "каталог резервных копий")
+	keep := fs.Int("keep", cfg.Backup.Keep, "сколько автоматических копий хранить (0 - не удалять)")
+	list := fs.Bool("list", false, "показать копии в каталоге")
+	check := fs.String("check", "", "проверить целостность файла копии")
+	restore := fs.String("restore", "", "восстановить базу из файла копии")
+	manual := fs.Bool("manual", false, "не удалять копию при ротации (по умолчанию копия считается созданной по расписанию, например из cron)")
+	fs.Parse(args)
+
+	switch {
+	case *list:
+		backups, err := db.ListBackups(*dir)
+		if err != nil {
+			log.Fatal("Ошибка чтения каталога копий:", err)
+		}
+		for _, b := range backups {
+			fmt.Printf("%s  %-22s %8d КБ  %s\n", b.CreatedAt.Format("02.01.2006 15:04:05"), b.Kind.Title(), b.Size/1024, b.Path)
+		}
+		fmt.Printf("Копий: %d\n", len(backups))
+		return
+	case *check != "":
+		if err := db.CheckBackup(*check); err != nil {
+			log.Fatal("Копия не прошла проверку: ", err)
+		}
+		fmt.Println("Копия исправна:", *check)
+		return
+	}
+
+	if err := db.InitDB(cfg.Database); err != nil {
+		log.Fatal("Ошибка инициализации БД:", err)
+	}
+	defer db.CloseDB()
+
+	if *restore != "" {
+		current, err := db.RestoreBackup(*restore, *dir)
+		if err != nil {
+			log.Fatal("Ошибка восстановления:", err)
+		}
+		fmt.Println("База восстановлена из", *restore)
+		fmt.Println("Прежняя база сохранена в", current.Path)
+		return
+	}
+
+	kind := db.BackupScheduled
+	if *manual {
+		kind = db.BackupManual
+	}
+	backup, err := db.CreateBackup(*dir, kind)
+	if err != nil {
+		log.Fatal("Ошибка резервного копирования:", err)
+	}
+	fmt.Printf("Копия создана: %s (%d КБ)\n", backup.Path, backup.Size/1024)
+	if _, err := db.RotateBackups(*dir, *keep); err != nil {
+		log.Fatal("Ошибка удаления старых копий:", err)
+	}
+}
+
+// runExport выгружает все данные базы в файл снимка JSON Lines
+func runExport(args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Использование: export FILE.jsonl")
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal("Ошибка чтения настроек:", err)
+	}
+	if err := db.InitDB(cfg.Database); err != nil {
+		log.Fatal("Ошибка инициализации БД:", err)
+	}
+	defer db.CloseDB()
+
+	file, err := os.Create(fs.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	counts, err := db.ExportSnapshot(file)
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(fs.Arg(0))
+		log.Fatal("Ошибка выгрузки:", err)
+	}
+	fmt.Printf("Выгружено записей: %d (%s)\n", counts.Total(), counts)
+}
+
+// runImport загружает снимок JSON Lines в базу
+func runImport(args []string) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	replace := fs.Bool("replace", false, "удалить данные, которые уже есть в базе")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Использование: import [-replace] FILE.jsonl")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	file, err := os.Open(fs.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal("Ошибка чтения настроек:", err)
+	}
+	if err := db.InitDB(cfg.Database); err != nil {
+		log.Fatal("Ошибка инициализации БД:", err)
+	}
+	defer db.CloseDB()
+
+	counts, err := db.ImportSnapshot(file, *replace)
+	if err != nil {
+		log.Fatal("Ошибка загрузки:", err)
+	}
+	fmt.Printf("Загружено записей: %d (%s)\n", counts.Total(), counts)
+}
+
+// runRates загружает курсы валют из CSV-файла и выводит курсы, действующие на дату
+func runRates(args []string) {
+	fs := flag.NewFlagSet("rates", flag.ExitOnError)
+	importFile := fs.String("import", "", "загрузить курсы из CSV-файла: дата;валюта;курс[;номинал]")
+	date := fs.String("date", "", "показать курсы на дату ГГГГ-ММ-ДД (по умолчанию - на сегодня)")
+	fs.Parse(args)
+
+	day := time.Now()
+	if *date != "" {
+		parsed, err := time.Parse("2006-01-02", *date)
+		if err != nil {
+			log.Fatal("Некорректная дата: ", *date)
+		}
+		day = parsed
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal("Ошибка чтения настроек:", err)
+	}
+	if err := db.InitDB(cfg.Database); err != nil {
+		log.Fatal("Ошибка инициализации БД:", err)
+	}
+	defer db.CloseDB()
+
+	if *importFile != "" {
+		file, err := os.Open(*importFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		count, err := db.ImportExchangeRates(file)
+		file.Close()
+		if err != nil {
+			log.Fatal("Ошибка загрузки курсов: ", err)
+		}
+		fmt.Printf("Загружено курсов: %d\n", count)
+	}
+
+	rates := db.ExchangeRatesOn(day)
+	currencies := make([]string, 0, len(rates))
+	for c := range rates {
+		currencies = append(currencies, string(c))
+	}
+	sort.Strings(currencies)
+	fmt.Printf("Курсы к %s на %s:\n", models.BaseCurrency, day.Format("02.01.2006"))
+	for _, c := range currencies {
+		fmt.Printf("  %s  %s\n", c, strconv.FormatFloat(rates[models.Currency(c)], 'f', -1, 64))
+	}
+	if len(currencies) == 0 {
+		fmt.Println("  курсы не заданы")
+	}
+}
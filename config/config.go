@@ -0,0 +1,102 @@
+// Package config - настройки программы, общие для графического интерфейса и командной строки.
+// Настройки хранятся в data/config.json рядом с базой; отсутствующий файл означает
+// настройки по умолчанию.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// Path - файл настроек
+var Path = filepath.Join("data", "config.json")
+
+// Config - настройки программы
+type Config struct {
+	// Language - язык интерфейса: LanguageRussian, LanguageEnglish или "" - язык системы
+	Language string   `json:"language"`
+	Database Database `json:"database"`
+	Backup   Backup   `json:"backup"`
+}
+
+const (
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
+)
+
+const (
+	LanguageRussian = "ru"
+	LanguageEnglish = "en"
+)
+
+// Database - подключение к базе данных
+type Database struct {
+	// Driver - DriverSQLite (по умолчанию) или DriverPostgres
+	Driver string `json:"driver"`
+	// DSN - путь к файлу SQLite или строка подключения PostgreSQL,
+	// например "host=localhost user=warehouse password=secret dbname=warehouse sslmode=disable"
+	DSN string `json:"dsn"`
+}
+
+// Backup - настройки резервного копирования базы
+type Backup struct {
+	// Dir - каталог резервных копий
+	Dir string `json:"dir"`
+	// IntervalHours - период автоматического копирования в часах; 0 - не копировать по расписанию
+	IntervalHours int `json:"interval_hours"`
+	// Keep - сколько автоматических копий хранить; копии, созданные вручную, не удаляются
+	Keep int `json:"keep"`
+	// OnExit - создавать копию при выходе из программы
+	OnExit bool `json:"on_exit"`
+}
+
+// Default возвращает настройки по умолчанию
+func Default() *Config {
+	return &Config{
+		Database: Database{
+			Driver: DriverSQLite,
+			DSN:    filepath.Join("data", "warehouse.db"),
+		},
+		Backup: Backup{
+			Dir:           filepath.Join("data", "backups"),
+			IntervalHours: 24,
+			Keep:          10,
+			OnExit:        true,
+		},
+	}
+}
+
+// Load читает настройки из файла. Параметры, которых нет в файле, берутся по умолчанию.
+func Load() (*Config, error) {
+	cfg := Default()
+	data, err := os.ReadFile(Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Save записывает настройки в файл. Файл заменяется целиком, чтобы при сбое
+// не остался наполовину записанный.
+func (c *Config) Save() error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(Path), os.ModePerm); err != nil {
+		return err
+	}
+	tmp := Path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, Path)
+}
@@ -0,0 +1,240 @@
+package database
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"SanWarehouse/models"
+
+	"gorm.io/gorm"
+)
+
+// AttachmentsDir - каталог файлов товаров: <ID товара>/<ID вложения>.<расширение>
+var AttachmentsDir = filepath.Join("data", "attachments")
+
+// maxAttachmentSize ограничивает размер загружаемого файла
+const maxAttachmentSize = 50 << 20
+
+// AttachmentPath возвращает путь к файлу вложения
+func AttachmentPath(a *models.Attachment) string {
+	return filepath.Join(AttachmentsDir, filepath.FromSlash(a.StoredName))
+}
+
+// ThumbnailPath возвращает путь к миниатюре изображения или "" для документов
+func ThumbnailPath(a *models.Attachment) string {
+	if a.ThumbnailName == "" {
+		return ""
+	}
+	return filepath.Join(AttachmentsDir, filepath.FromSlash(a.ThumbnailName))
+}
+
+// attachmentFileExists сообщает, что в каталоге файлов товаров уже есть файл с именем name
+func attachmentFileExists(name string) bool {
+	_, err := os.Stat(filepath.Join(AttachmentsDir, filepath.FromSlash(name)))
+	return err == nil
+}
+
+// AddAttachment сохраняет файл товара в каталоге данных и записывает сведения о нем в БД.
+// Для изображений строится миниатюра; первое изображение товара становится основным.
+func AddAttachment(productID uint, kind models.AttachmentKind, fileName string, r io.Reader) (*models.Attachment, error) {
+	if err := requirePermission(models.PermEditProducts); err != nil {
+		return nil, err
+	}
+
+	attachment := &models.Attachment{
+		ProductID: productID,
+		Kind:      kind,
+		FileName:  filepath.Base(fileName),
+	}
+
+	var files []string
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		var product models.Product
+		if err := tx.First(&product, productID).Error; err != nil {
+			return err
+		}
+
+		var count, images int64
+		tx.Model(&models.Attachment{}).Where("product_id = ?", productID).Count(&count)
+		tx.Model(&models.Attachment{}).Where("product_id = ? AND kind = ?", productID, models.AttachmentImage).Count(&images)
+		attachment.SortOrder = int(count)
+		attachment.IsPrimary = kind == models.AttachmentImage && images == 0
+
+		// ID вложения нужен для имени файла, поэтому запись создается до копирования
+		if err := tx.Create(attachment).Error; err != nil {
+			return err
+		}
+
+		ext := strings.ToLower(filepath.Ext(attachment.FileName))
+		stem := fmt.Sprintf("%d/%d", productID, attachment.ID)
+		// После загрузки снимка (ImportSnapshot) в каталоге остаются файлы с прежними ID,
+		// поэтому занятое имя не перезаписывается
+		for n := 1; attachmentFileExists(stem+ext) || attachmentFileExists(stem+"_thumb.png"); n++ {
+			stem = fmt.Sprintf("%d/%d_%d", productID, attachment.ID, n)
+		}
+		attachment.StoredName = stem + ext
+		path := AttachmentPath(attachment)
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			return err
+		}
+		files = append(files, path)
+
+		size, err := copyAttachment(path, r)
+		if err != nil {
+			return err
+		}
+		attachment.Size = size
+		attachment.MimeType = detectMimeType(path, ext)
+
+		if kind == models.AttachmentImage {
+			attachment.ThumbnailName = stem + "_thumb.png"
+			files = append(files, ThumbnailPath(attachment))
+			if err := makeThumbnail(path, ThumbnailPath(attachment), thumbnailSize); err != nil {
+				return models.Errorf("не удалось прочитать изображение %s: %w", attachment.FileName, err)
+			}
+		}
+
+		return tx.Save(attachment).Error
+	})
+	if err != nil {
+		for _, f := range files {
+			os.Remove(f)
+		}
+		return nil, err
+	}
+	return attachment, nil
+}
+
+// copyAttachment копирует содержимое r в файл path с проверкой размера
+func copyAttachment(path string, r io.Reader) (int64, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+	size, err := io.Copy(f, io.LimitReader(r, maxAttachmentSize+1))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return 0, err
+	}
+	if size > maxAttachmentSize {
+		return 0, models.Errorf("файл больше %d МБ", maxAttachmentSize>>20)
+	}
+	return size, nil
+}
+
+// detectMimeType определяет тип файла по содержимому, а если он не распознан - по расширению
+func detectMimeType(path, ext string) string {
+	buf := make([]byte, 512)
+	if f, err := os.Open(path); err == nil {
+		n, _ := f.Read(buf)
+		f.Close()
+		if t := http.DetectContentType(buf[:n]); t != "application/octet-stream" {
+			return t
+		}
+	}
+	if t := mime.TypeByExtension(ext); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+// ProductAttachments возвращает вложения товара: сначала основное изображение, затем в порядке добавления
+func ProductAttachments(productID uint) []models.Attachment {
+	var attachments []models.Attachment
+	DB.Where("product_id = ?", productID).
+		Order("is_primary DESC, sort_order, id").
+		Find(&attachments)
+	return attachments
+}
+
+// SetPrimaryAttachment делает изображение основным для товара
+func SetPrimaryAttachment(id uint) error {
+	if err := requirePermission(models.PermEditProducts); err != nil {
+		return err
+	}
+
+	return DB.Transaction(func(tx *gorm.DB) error {
+		var attachment models.Attachment
+		if err := tx.First(&attachment, id).Error; err != nil {
+			return err
+		}
+		if !attachment.IsImage() {
+			return models.Errorf("основным может быть только изображение")
+		}
+		if err := tx.Model(&models.Attachment{}).
+			Where("product_id = ? AND id <> ?", attachment.ProductID, id).
+			Update("is_primary", false).Error; err != nil {
+			return err
+		}
+		return tx.Model(&attachment).Update("is_primary", true).Error
+	})
+}
+
+// DeleteAttachment удаляет вложение и его файлы. Если удалено основное изображение,
+// основным становится следующее изображение товара.
+func DeleteAttachment(id uint) error {
+	if err := requirePermission(models.PermEditProducts); err != nil {
+		return err
+	}
+
+	var attachment models.Attachment
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&attachment, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&attachment).Error; err != nil {
+			return err
+		}
+		if !attachment.IsPrimary {
+			return nil
+		}
+
+		var next models.Attachment
+		err := tx.Where("product_id = ? AND kind = ?", attachment.ProductID, models.AttachmentImage).
+			Order("sort_order, id").
+			First(&next).Error
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return tx.Model(&next).Update("is_primary", true).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	// Файлы удаляем после фиксации транзакции: потерянный файл безопаснее потерянной записи
+	os.Remove(AttachmentPath(&attachment))
+	if thumb := ThumbnailPath(&attachment); thumb != "" {
+		os.Remove(thumb)
+	}
+	return nil
+}
+
+// PrimaryThumbnails возвращает пути к миниатюрам основных изображений товаров
+func PrimaryThumbnails(productIDs []uint) map[uint]string {
+	result := make(map[uint]string, len(productIDs))
+	if len(productIDs) == 0 {
+		return result
+	}
+
+	var attachments []models.Attachment
+	DB.Select("product_id", "thumbnail_name").
+		Where("product_id IN ? AND is_primary = ?", productIDs, true).
+		Find(&attachments)
+	for i := range attachments {
+		if path := ThumbnailPath(&attachments[i]); path != "" {
+			result[attachments[i].ProductID] = path
+		}
+	}
+	return result
+}
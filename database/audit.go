@@ -0,0 +1,454 @@
+package database
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"SanWarehouse/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	// auditSkipKey - настройка сессии, отключающая журнал для служебных операций
+	auditSkipKey = "audit:skip"
+	// auditBeforeKey - состояние записей до изменения или удаления
+	auditBeforeKey = "audit:before"
+	// auditBatchSize - сколько записей читается одним запросом по списку ID
+	auditBatchSize = 500
+)
+
+var auditableType = reflect.TypeOf((*models.Auditable)(nil)).Elem()
+
+// withoutAudit возвращает сессию, изменения в которой не записываются в журнал аудита
+// (миграции, генерация синтетического каталога). Сессию можно использовать повторно.
+func withoutAudit(db *gorm.DB) *gorm.DB {
+	return db.Set(auditSkipKey, true).Session(&gorm.Session{})
+}
+
+// registerAuditCallbacks подключает запись журнала аудита к созданию, изменению и удалению
+// сущностей, реализующих models.Auditable. Записи журнала создаются в той же транзакции.
+func registerAuditCallbacks(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Create().After("gorm:after_create").Before("gorm:commit_or_rollback_transaction").
+		Register("audit:after_create", auditAfterCreate); err != nil {
+		return err
+	}
+	if err := cb.Update().After("gorm:before_update").Before("gorm:update").
+		Register("audit:before_update", auditSnapshot); err != nil {
+		return err
+	}
+	if err := cb.Update().After("gorm:after_update").Before("gorm:commit_or_rollback_transaction").
+		Register("audit:after_update", auditAfterUpdate); err != nil {
+		return err
+	}
+	if err := cb.Delete().After("gorm:before_delete").Before("gorm:delete").
+		Register("audit:before_delete", auditSnapshot); err != nil {
+		return err
+	}
+	return cb.Delete().After("gorm:after_delete").Before("gorm:commit_or_rollback_transaction").
+		Register("audit:after_delete", auditAfterDelete)
+}
+
+// auditEnabled сообщает, нужно ли записывать операцию в журнал
+func auditEnabled(db *gorm.DB) bool {
+	stmt := db.Statement
+	if db.Error != nil || db.DryRun || stmt.Schema == nil || stmt.Schema.PrioritizedPrimaryField == nil {
+		return false
+	}
+	if skip, ok := db.Get(auditSkipKey); ok && skip == true {
+		return false
+	}
+	return reflect.PointerTo(stmt.Schema.ModelType).Implements(auditableType)
+}
+
+// auditFields возвращает поля, изменения которых записываются в журнал
+func auditFields(s *schema.Schema) []*schema.Field {
+	deletedAtType := reflect.TypeOf(gorm.DeletedAt{})
+	fields := make([]*schema.Field, 0, len(s.Fields))
+	for _, f := range s.Fields {
+		if f.DBName == "" || f.PrimaryKey || f.AutoCreateTime > 0 || f.AutoUpdateTime > 0 ||
+			f.FieldType == deletedAtType || f.Tag.Get("audit") == "-" {
+			continue
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// touchesAuditedFields отсеивает обновления по карте колонок, не затрагивающие журналируемые
+// поля (например, запись классов ABC/XYZ), чтобы не читать записи до и после впустую
+func touchesAuditedFields(stmt *gorm.Statement) bool {
+	columns, ok := stmt.Dest.(map[string]interface{})
+	if !ok {
+		return true
+	}
+	for column := range columns {
+		if f := stmt.Schema.LookUpField(column); f != nil && f.Tag.Get("audit") != "-" {
+			return true
+		}
+	}
+	return false
+}
+
+// auditPrimaryKeys возвращает ID записей, переданных в операцию (структура или срез)
+func auditPrimaryKeys(stmt *gorm.Statement) []uint {
+	pk := stmt.Schema.PrioritizedPrimaryField
+	var ids []uint
+	add := func(rv reflect.Value) {
+		if v, zero := pk.ValueOf(stmt.Context, rv); !zero {
+			if id, ok := v.(uint); ok {
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	switch rv := stmt.ReflectValue; rv.Kind() {
+	case reflect.Struct:
+		add(rv)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			if item := reflect.Indirect(rv.Index(i)); item.Kind() == reflect.Struct {
+				add(item)
+			}
+		}
+	}
+	return ids
+}
+
+// auditQuery - запрос к таблице операции на том же соединении (в той же транзакции)
+func auditQuery(db *gorm.DB, unscoped bool) *gorm.DB {
+	query := db.Session(&gorm.Session{NewDB: true, SkipHooks: true}).Table(db.Statement.Table)
+	if unscoped {
+		query = query.Unscoped()
+	}
+	return query
+}
+
+// auditLoad читает записи по ID пачками, чтобы не упереться в ограничение числа параметров
+func auditLoad(query *gorm.DB, s *schema.Schema, ids []uint) ([]reflect.Value, error) {
+	var rows []reflect.Value
+	column := clause.Column{Table: clause.CurrentTable, Name: s.PrioritizedPrimaryField.DBName}
+	for start := 0; start < len(ids); start += auditBatchSize {
+		end := min(start+auditBatchSize, len(ids))
+		values := make([]interface{}, 0, end-start)
+		for _, id := range ids[start:end] {
+			values = append(values, id)
+		}
+
+		batch := reflect.New(reflect.SliceOf(s.ModelType))
+		if err := query.Session(&gorm.Session{}).Where(clause.IN{Column: column, Values: values}).Find(batch.Interface()).Error; err != nil {
+			return nil, err
+		}
+		for i := 0; i < batch.Elem().Len(); i++ {
+			rows = append(rows, batch.Elem().Index(i).Addr())
+		}
+	}
+	return rows, nil
+}
+
+// auditSnapshot запоминает записи, которые будут изменены или удалены: по ID переданной
+// структуры или по условиям запроса
+func auditSnapshot(db *gorm.DB) {
+	if !auditEnabled(db) || !touchesAuditedFields(db.Statement) {
+		return
+	}
+	stmt := db.Statement
+
+	ids := auditPrimaryKeys(stmt)
+	where, hasWhere := stmt.Clauses["WHERE"].Expression.(clause.Where)
+	if len(ids) == 0 && !hasWhere {
+		return
+	}
+
+	query := auditQuery(db, stmt.Unscoped)
+	if hasWhere {
+		// Условия копируются, чтобы не изменить условия самой операции
+		query = query.Clauses(clause.Where{Exprs: append([]clause.Expression(nil), where.Exprs...)})
+	}
+
+	var rows []reflect.Value
+	var err error
+	if len(ids) > 0 {
+		rows, err = auditLoad(query, stmt.Schema, ids)
+	} else {
+		batch := reflect.New(reflect.SliceOf(stmt.Schema.ModelType))
+		err = query.Find(batch.Interface()).Error
+		for i := 0; i < batch.Elem().Len(); i++ {
+			rows = append(rows, batch.Elem().Index(i).Addr())
+		}
+	}
+	if err != nil {
+		db.AddError(models.Errorf("журнал аудита: %w", err))
+		return
+	}
+	db.InstanceSet(auditBeforeKey, rows)
+}
+
+// auditBefore возвращает записи, сохраненные auditSnapshot
+func auditBefore(db *gorm.DB) []reflect.Value {
+	if db.Error != nil {
+		return nil
+	}
+	rows, _ := db.InstanceGet(auditBeforeKey)
+	before, _ := rows.([]reflect.Value)
+	return before
+}
+
+func auditAfterCreate(db *gorm.DB) {
+	if !auditEnabled(db) {
+		return
+	}
+	ids := auditPrimaryKeys(db.Statement)
+	if len(ids) == 0 {
+		return
+	}
+	rows, err := auditLoad(auditQuery(db, true), db.Statement.Schema, ids)
+	if err != nil {
+		db.AddError(models.Errorf("журнал аудита: %w", err))
+		return
+	}
+
+	fields := auditFields(db.Statement.Schema)
+	entries := make([]models.AuditEntry, 0, len(rows))
+	for _, row := range rows {
+		entries = append(entries, newAuditEntry(db, models.AuditCreate, row, auditDiff(db, fields, reflect.Value{}, row)))
+	}
+	writeAuditEntries(db, entries)
+}
+
+func auditAfterUpdate(db *gorm.DB) {
+	before := auditBefore(db)
+	if len(before) == 0 {
+		return
+	}
+	s := db.Statement.Schema
+
+	ids := make([]uint, 0, len(before))
+	for _, row := range before {
+		ids = append(ids, auditID(db, row))
+	}
+	rows, err := auditLoad(auditQuery(db, true), s, ids)
+	if err != nil {
+		db.AddError(models.Errorf("журнал аудита: %w", err))
+		return
+	}
+	current := make(map[uint]reflect.Value, len(rows))
+	for _, row := range rows {
+		current[auditID(db, row)] = row
+	}
+
+	fields := auditFields(s)
+	var entries []models.AuditEntry
+	for _, old := range before {
+		row, ok := current[auditID(db, old)]
+		if !ok {
+			continue
+		}
+		changes := auditDiff(db, fields, old, row)
+		if auditDeleted(db, old) && !auditDeleted(db, row) {
+			entries = append(entries, newAuditEntry(db, models.AuditRestore, row, changes))
+			continue
+		}
+		// Сохранение без изменений в журнал не попадает
+		if len(changes) > 0 {
+			entries = append(entries, newAuditEntry(db, models.AuditUpdate, row, changes))
+		}
+	}
+	writeAuditEntries(db, entries)
+}
+
+func auditAfterDelete(db *gorm.DB) {
+	before := auditBefore(db)
+	if len(before) == 0 || db.RowsAffected == 0 {
+		return
+	}
+
+	fields := auditFields(db.Statement.Schema)
+	entries := make([]models.AuditEntry, 0, len(before))
+	for _, row := range before {
+		entries = append(entries, newAuditEntry(db, models.AuditDelete, row, auditDiff(db, fields, row, reflect.Value{})))
+	}
+	writeAuditEntries(db, entries)
+}
+
+// auditDeleted сообщает, помечена ли запись удаленной
+func auditDeleted(db *gorm.DB, row reflect.Value) bool {
+	f := db.Statement.Schema.LookUpField("DeletedAt")
+	if f == nil {
+		return false
+	}
+	v, _ := f.ValueOf(db.Statement.Context, row.Elem())
+	deletedAt, ok := v.(gorm.DeletedAt)
+	return ok && deletedAt.Valid
+}
+
+// auditID возвращает ID записи
+func auditID(db *gorm.DB, row reflect.Value) uint {
+	v, _ := db.Statement.Schema.PrioritizedPrimaryField.ValueOf(db.Statement.Context, row.Elem())
+	id, _ := v.(uint)
+	return id
+}
+
+// auditDiff сравнивает поля записи до и после операции. Для создания old не задан,
+// для удаления не задан current; пустые значения в этих случаях не записываются.
+func auditDiff(db *gorm.DB, fields []*schema.Field, old, current reflect.Value) []models.AuditChange {
+	value := func(f *schema.Field, row reflect.Value) string {
+		if !row.IsValid() {
+			return ""
+		}
+		v, zero := f.ValueOf(db.Statement.Context, row.Elem())
+		if zero && (!old.IsValid() || !current.IsValid()) {
+			return ""
+		}
+		return formatAuditValue(v)
+	}
+
+	var changes []models.AuditChange
+	for _, f := range fields {
+		oldValue, newValue := value(f, old), value(f, current)
+		if oldValue == newValue {
+			continue
+		}
+		if f.Tag.Get("audit") == "secret" {
+			oldValue, newValue = maskAuditValue(oldValue), maskAuditValue(newValue)
+		}
+		changes = append(changes, models.AuditChange{Field: f.DBName, OldValue: oldValue, NewValue: newValue})
+	}
+	return changes
+}
+
+func maskAuditValue(v string) string {
+	if v == "" {
+		return ""
+	}
+	return models.AuditSecretMask
+}
+
+// formatAuditValue приводит значение поля к строке журнала
+func formatAuditValue(v interface{}) string {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return ""
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return ""
+	}
+
+	if t, ok := rv.Interface().(time.Time); ok {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format("2006-01-02 15:04:05")
+	}
+	switch rv.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(rv.Float(), 'f', -1, 64)
+	case reflect.Bool:
+		return strconv.FormatBool(rv.Bool())
+	}
+	return fmt.Sprint(rv.Interface())
+}
+
+// newAuditEntry создает запись журнала от имени текущего пользователя
+func newAuditEntry(db *gorm.DB, action models.AuditAction, row reflect.Value, changes []models.AuditChange) models.AuditEntry {
+	entry := models.AuditEntry{
+		Action:   action,
+		Entity:   db.Statement.Schema.Table,
+		EntityID: auditID(db, row),
+		Changes:  changes,
+	}
+	if a, ok := row.Interface().(models.Auditable); ok {
+		entry.EntityLabel = a.AuditLabel()
+	}
+	if u := currentUser; u != nil {
+		id := u.ID
+		entry.UserID = &id
+		entry.Username = u.Username
+	}
+	return entry
+}
+
+// writeAuditEntries сохраняет записи журнала; ошибка откатывает всю операцию
+func writeAuditEntries(db *gorm.DB, entries []models.AuditEntry) {
+	if len(entries) == 0 {
+		return
+	}
+	err := db.Session(&gorm.Session{NewDB: true, SkipHooks: true}).CreateInBatches(&entries, 100).Error
+	if err != nil {
+		db.AddError(models.Errorf("журнал аудита: %w", err))
+	}
+}
+
+// AuditFilter - условия отбора записей журнала аудита; пустые поля не ограничивают выборку
+type AuditFilter struct {
+	Entity   string
+	EntityID uint
+	Action   models.AuditAction
+	Username string
+	// Fields - записи, в которых менялось хотя бы одно из полей
+	Fields []string
+	// Search - подстрока обозначения записи, имени пользователя или значения поля
+	Search string
+	Since  time.Time
+}
+
+// Apply ограничивает запрос к журналу условиями фильтра
+func (f AuditFilter) Apply(query *gorm.DB) *gorm.DB {
+	if f.Entity != "" {
+		query = query.Where("entity = ?", f.Entity)
+	}
+	if f.EntityID != 0 {
+		query = query.Where("entity_id = ?", f.EntityID)
+	}
+	if f.Action != "" {
+		query = query.Where("action = ?", f.Action)
+	}
+	if f.Username != "" {
+		query = query.Where("username = ?", f.Username)
+	}
+	if len(f.Fields) > 0 {
+		query = query.Where("id IN (?)", DB.Model(&models.AuditChange{}).Select("audit_entry_id").Where("field IN ?", f.Fields))
+	}
+	if f.Search != "" {
+		// Поиск без учета регистра одинаково в SQLite и PostgreSQL
+		like := "%" + escapeLike(strings.ToLower(f.Search)) + "%"
+		lower := func(column string) string { return lowerExpr(query, column) + " LIKE ?" + likeEscape }
+		query = query.Where("("+lower("entity_label")+" OR "+lower("username")+" OR id IN (?))", like, like,
+			DB.Model(&models.AuditChange{}).Select("audit_entry_id").Where(lower("old_value")+" OR "+lower("new_value"), like, like))
+	}
+	if !f.Since.IsZero() {
+		query = query.Where("created_at >= ?", f.Since)
+	}
+	return query
+}
+
+// AuditEntries возвращает записи журнала по фильтру, новые первыми, не больше limit
+// (0 - без ограничения), и общее число подходящих записей
+func AuditEntries(f AuditFilter, limit int) ([]models.AuditEntry, int64) {
+	var total int64
+	f.Apply(DB.Model(&models.AuditEntry{})).Count(&total)
+
+	query := f.Apply(DB.Preload("Changes")).Order("created_at DESC, id DESC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	var entries []models.AuditEntry
+	query.Find(&entries)
+	return entries, total
+}
+
+// AuditUsernames возвращает имена пользователей, встречающиеся в журнале
+func AuditUsernames() []string {
+	var names []string
+	DB.Model(&models.AuditEntry{}).Where("username <> ''").Distinct("username").Order("username").Pluck("username", &names)
+	return names
+}
@@ -0,0 +1,419 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"SanWarehouse/models"
+)
+
+// BackupKind - повод создания резервной копии
+type BackupKind string
+
+const (
+	BackupManual    BackupKind = "manual"
+	BackupScheduled BackupKind = "scheduled"
+	BackupOnExit    BackupKind = "exit"
+	// BackupBeforeRestore - копия текущей базы, которая создается перед восстановлением
+	BackupBeforeRestore BackupKind = "restore"
+)
+
+// Title возвращает название вида копии для интерфейса
+func (k BackupKind) Title() string {
+	switch k {
+	case BackupManual:
+		return "Вручную"
+	case BackupScheduled:
+		return "По расписанию"
+	case BackupOnExit:
+		return "При выходе"
+	case BackupBeforeRestore:
+		return "Перед восстановлением"
+	}
+	return string(k)
+}
+
+const (
+	// backupPrefix и backupTimeLayout задают имя файла копии: warehouse-20240131-154500-manual.db
+	backupPrefix     = "warehouse-"
+	backupTimeLayout = "20060102-150405"
+	backupExt        = ".db"
+	// backupFilesExt - каталог файлов товаров рядом с копией базы: warehouse-20240131-154500-manual.files
+	backupFilesExt = ".files"
+)
+
+// Backup - файл резервной копии базы
+type Backup struct {
+	Path      string
+	Kind      BackupKind
+	CreatedAt time.Time
+	Size      int64
+}
+
+// parseBackupName разбирает имя файла копии; ok = false для посторонних файлов
+func parseBackupName(name string) (kind BackupKind, createdAt time.Time, ok bool) {
+	if !strings.HasPrefix(name, backupPrefix) || !strings.HasSuffix(name, backupExt) {
+		return "", time.Time{}, false
+	}
+	stem := strings.TrimSuffix(strings.TrimPrefix(name, backupPrefix), backupExt)
+	if len(stem) <= len(backupTimeLayout)+1 {
+		return "", time.Time{}, false
+	}
+	createdAt, err := time.ParseInLocation(backupTimeLayout, stem[:len(backupTimeLayout)], time.Local)
+	if err != nil {
+		return "", time.Time{}, false
+	}
+	return BackupKind(stem[len(backupTimeLayout)+1:]), createdAt, true
+}
+
+// BackupSupported сообщает, доступно ли встроенное резервное копирование: только для SQLite,
+// базу PostgreSQL копируют средствами сервера (pg_dump)
+func BackupSupported() bool {
+	dbMu.Lock()
+	defer dbMu.Unlock()
+	return isSQLite(DB)
+}
+
+// errBackupUnsupported - ошибка копирования и восстановления базы PostgreSQL
+var errBackupUnsupported = models.Errorf("резервное копирование доступно только для базы SQLite; базу PostgreSQL копируйте с помощью pg_dump")
+
+// CreateBackup сохраняет копию базы в каталог dir командой VACUUM INTO, а рядом с ней - копию
+// каталога файлов товаров (AttachmentsDir). Копия согласована и создается без остановки работы;
+// файл появляется в каталоге только полностью записанным.
+// Функцию можно вызывать из фоновой горутины: закрытие и замена базы дожидаются копирования.
+func CreateBackup(dir string, kind BackupKind) (*Backup, error) {
+	dbMu.Lock()
+	defer dbMu.Unlock()
+	return createBackup(dir, kind)
+}
+
+// createBackup создает копию базы; вызывается под dbMu
+func createBackup(dir string, kind BackupKind) (*Backup, error) {
+	if !isSQLite(DB) {
+		return nil, errBackupUnsupported
+	}
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	name := fmt.Sprintf("%s%s-%s%s", backupPrefix, now.Format(backupTimeLayout), kind, backupExt)
+	path := filepath.Join(dir, name)
+	if _, err := os.Stat(path); err == nil {
+		return nil, models.Errorf("копия %s уже существует", name)
+	}
+
+	// VACUUM INTO не перезаписывает существующий файл, поэтому временный удаляется заранее
+	tmp := path + ".tmp"
+	os.Remove(tmp)
+	if err := DB.Exec("VACUUM INTO ?", tmp).Error; err != nil {
+		os.Remove(tmp)
+		return nil, models.Errorf("резервное копирование: %w", err)
+	}
+	files := backupFilesDir(path)
+	os.RemoveAll(files + ".tmp")
+	if err := copyAttachments(AttachmentsDir, files+".tmp"); err != nil {
+		os.Remove(tmp)
+		os.RemoveAll(files + ".tmp")
+		return nil, models.Errorf("копирование файлов товаров: %w", err)
+	}
+	if err := os.Rename(files+".tmp", files); err != nil {
+		os.Remove(tmp)
+		os.RemoveAll(files + ".tmp")
+		return nil, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		os.RemoveAll(files)
+		return nil, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Backup{Path: path, Kind: kind, CreatedAt: now.Truncate(time.Second), Size: info.Size()}, nil
+}
+
+// ListBackups возвращает копии из каталога dir, новые первыми
+func ListBackups(dir string) ([]Backup, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []Backup
+	for _, e := range entries {
+		kind, createdAt, ok := parseBackupName(e.Name())
+		if !ok || e.IsDir() {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		backups = append(backups, Backup{
+			Path:      filepath.Join(dir, e.Name()),
+			Kind:      kind,
+			CreatedAt: createdAt,
+			Size:      info.Size(),
+		})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].CreatedAt.After(backups[j].CreatedAt)
+	})
+	return backups, nil
+}
+
+// RotateBackups оставляет keep последних автоматических копий и удаляет более старые.
+// Копии, созданные вручную, не удаляются. keep <= 0 отключает удаление.
+func RotateBackups(dir string, keep int) ([]Backup, error) {
+	if keep <= 0 {
+		return nil, nil
+	}
+	backups, err := ListBackups(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []Backup
+	kept := 0
+	for _, b := range backups {
+		if b.Kind == BackupManual {
+			continue
+		}
+		kept++
+		if kept <= keep {
+			continue
+		}
+		if err := os.Remove(b.Path); err != nil {
+			return removed, err
+		}
+		if err := os.RemoveAll(backupFilesDir(b.Path)); err != nil {
+			return removed, err
+		}
+		removed = append(removed, b)
+	}
+	return removed, nil
+}
+
+// BackupDue сообщает, пора ли создать копию по расписанию: с последней копии
+// любого вида прошло не меньше interval. interval <= 0 - расписание отключено.
+func BackupDue(dir string, interval time.Duration) bool {
+	if interval <= 0 {
+		return false
+	}
+	backups, err := ListBackups(dir)
+	if err != nil {
+		return false
+	}
+	if len(backups) == 0 {
+		return true
+	}
+	return time.Since(backups[0].CreatedAt) >= interval
+}
+
+// CheckBackup проверяет целостность файла копии (PRAGMA integrity_check)
+// и то, что это база склада
+func CheckBackup(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+	conn, err := sql.Open(sqliteDriverName, "file:"+filepath.ToSlash(path)+"?mode=ro")
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	rows, err := conn.Query("PRAGMA integrity_check")
+	if err != nil {
+		return models.Errorf("файл не является базой SQLite: %w", err)
+	}
+	defer rows.Close()
+	var problems []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return err
+		}
+		if line != "ok" {
+			problems = append(problems, line)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return models.Errorf("копия повреждена: %w", err)
+	}
+	if len(problems) > 0 {
+		return models.Errorf("копия повреждена: %s", strings.Join(problems, "; "))
+	}
+
+	var products int64
+	if err := conn.QueryRow("SELECT count(*) FROM products").Scan(&products); err != nil {
+		return models.Errorf("файл не является базой склада: %w", err)
+	}
+	return nil
+}
+
+// RestoreBackup заменяет базу копией из файла path, а файлы товаров - сохраненными вместе
+// с ней; если файлов в копии нет (BackupHasFiles), текущие файлы остаются. Перед заменой
+// копия проверяется, а текущая база с файлами сохраняется в каталог dir, чтобы восстановление
+// можно было отменить. После восстановления соединение с базой открывается заново.
+func RestoreBackup(path, dir string) (*Backup, error) {
+	if err := requirePermission(models.PermManageBackups); err != nil {
+		return nil, err
+	}
+	dbMu.Lock()
+	defer dbMu.Unlock()
+	if !isSQLite(DB) {
+		return nil, errBackupUnsupported
+	}
+	if err := CheckBackup(path); err != nil {
+		return nil, err
+	}
+	dbPath := dbConfig.DSN
+	if absPath(path) == absPath(dbPath) {
+		return nil, models.Errorf("нельзя восстановить базу из нее самой")
+	}
+
+	current, err := createBackup(dir, BackupBeforeRestore)
+	if err != nil {
+		return nil, models.Errorf("не удалось сохранить текущую базу: %w", err)
+	}
+
+	// Копируем во временный файл и каталог рядом с базой, чтобы замена была атомарной
+	tmp := dbPath + ".restore"
+	if err := copyFile(path, tmp); err != nil {
+		os.Remove(tmp)
+		return nil, err
+	}
+	files := ""
+	if BackupHasFiles(path) {
+		files = AttachmentsDir + ".restore"
+		os.RemoveAll(files)
+		if err := copyAttachments(backupFilesDir(path), files); err != nil {
+			os.Remove(tmp)
+			os.RemoveAll(files)
+			return nil, models.Errorf("копирование файлов товаров: %w", err)
+		}
+	}
+
+	if err := closeDB(); err != nil {
+		os.Remove(tmp)
+		os.RemoveAll(files)
+		return nil, err
+	}
+	for _, suffix := range []string{"-wal", "-shm", "-journal"} {
+		os.Remove(dbPath + suffix)
+	}
+	if err := os.Rename(tmp, dbPath); err != nil {
+		os.Remove(tmp)
+		os.RemoveAll(files)
+		return nil, errors.Join(err, InitDB(dbConfig))
+	}
+	if files != "" {
+		if err := replaceDir(files, AttachmentsDir); err != nil {
+			os.RemoveAll(files)
+			return nil, errors.Join(models.Errorf("база восстановлена, но файлы товаров не заменены: %w", err), InitDB(dbConfig))
+		}
+	}
+	if err := InitDB(dbConfig); err != nil {
+		return nil, err
+	}
+	return current, nil
+}
+
+// backupFilesDir возвращает каталог файлов товаров, сохраненный вместе с копией path
+func backupFilesDir(path string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + backupFilesExt
+}
+
+// BackupHasFiles сообщает, сохранены ли вместе с копией файлы товаров. Копии прежних
+// версий программы и отдельные файлы базы содержат только записи о файлах.
+func BackupHasFiles(path string) bool {
+	info, err := os.Stat(backupFilesDir(path))
+	return err == nil && info.IsDir()
+}
+
+// copyAttachments копирует каталог файлов товаров src в dst; отсутствующий src дает пустой dst.
+// Файлы товаров не изменяются после записи, поэтому вместо копий по возможности
+// создаются жесткие ссылки.
+func copyAttachments(src, dst string) error {
+	if err := os.MkdirAll(dst, os.ModePerm); err != nil {
+		return err
+	}
+	if _, err := os.Stat(src); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, os.ModePerm)
+		}
+		if os.Link(path, target) == nil {
+			return nil
+		}
+		return copyFile(path, target)
+	})
+}
+
+// replaceDir заменяет каталог dst каталогом src
+func replaceDir(src, dst string) error {
+	old := dst + ".old"
+	os.RemoveAll(old)
+	if err := os.Rename(dst, old); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := os.Rename(src, dst); err != nil {
+		os.Rename(old, dst)
+		return err
+	}
+	return os.RemoveAll(old)
+}
+
+func absPath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	return abs
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
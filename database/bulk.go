@@ -0,0 +1,97 @@
+package database
+
+import (
+	"sort"
+
+	"SanWarehouse/models"
+
+	"gorm.io/gorm"
+)
+
+// BulkUpdateProducts применяет изменения ко всем товарам из ids в одной транзакции
+// и возвращает ревизии измененных товаров для отмены.
+// При ошибке хотя бы у одного товара не изменяется ни один.
+func BulkUpdateProducts(ids []uint, changes []models.BulkChange) ([]ProductRevision, error) {
+	if len(ids) == 0 || len(changes) == 0 {
+		return nil, nil
+	}
+	if err := requirePermission(models.PermEditProducts); err != nil {
+		return nil, err
+	}
+	for _, c := range changes {
+		if c.Field == models.BulkPurchasePrice || c.Field == models.BulkSellingPrice {
+			if err := requirePermission(models.PermEditPrices); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var revisions []ProductRevision
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		products, err := findProducts(tx, ids)
+		if err != nil {
+			return err
+		}
+
+		catalog := newCatalogResolver(tx)
+		for i := range products {
+			p := &products[i]
+			before := *p
+			if err := models.ApplyBulkChanges(p, changes); err != nil {
+				return models.Errorf("%s: %w", p.SKU, err)
+			}
+			// Новые категория и бренд связываются со справочниками
+			if err := catalog.resolve(p); err != nil {
+				return models.Errorf("%s: %w", p.SKU, err)
+			}
+			// Save вызывает хуки, поэтому статус товара пересчитывается
+			if err := tx.Save(p).Error; err != nil {
+				return models.Errorf("%s: %w", p.SKU, err)
+			}
+			revisions = append(revisions, ProductRevision{Before: before, After: *p})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+// idBatchSize - сколько ID передается в одном условии IN: SQLite принимает не больше
+// 32766 параметров в запросе, а список товаров по фильтру может быть длиннее
+const idBatchSize = 500
+
+// inBatches вызывает fn для ids пачками не длиннее idBatchSize
+func inBatches(ids []uint, fn func(batch []uint) error) error {
+	for start := 0; start < len(ids); start += idBatchSize {
+		if err := fn(ids[start:min(start+idBatchSize, len(ids))]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findProducts читает товары по списку ID любой длины
+func findProducts(tx *gorm.DB, ids []uint) ([]models.Product, error) {
+	var products []models.Product
+	err := inBatches(ids, func(batch []uint) error {
+		var part []models.Product
+		if err := tx.Where("id IN ?", batch).Find(&part).Error; err != nil {
+			return err
+		}
+		products = append(products, part...)
+		return nil
+	})
+	return products, err
+}
+
+// ProductsByIDs возвращает товары из ids, упорядоченные по артикулу
+func ProductsByIDs(ids []uint) ([]models.Product, error) {
+	products, err := findProducts(DB, ids)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(products, func(i, j int) bool { return products[i].SKU < products[j].SKU })
+	return products, nil
+}
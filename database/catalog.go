@@ -0,0 +1,460 @@
+package database
+
+import (
+	"strings"
+
+	"SanWarehouse/models"
+
+	"gorm.io/gorm"
+)
+
+// catalogResolver находит или создает категории и бренды по названиям.
+// Найденные записи кэшируются, поэтому один резолвер удобно использовать для пачки товаров.
+type catalogResolver struct {
+	tx         *gorm.DB
+	categories map[string]*models.Category
+	brands     map[string]*models.Brand
+}
+
+func newCatalogResolver(tx *gorm.DB) *catalogResolver {
+	return &catalogResolver{
+		tx:         tx,
+		categories: map[string]*models.Category{},
+		brands:     map[string]*models.Brand{},
+	}
+}
+
+// category возвращает категорию по пути, создавая недостающие уровни. Для пустого пути - nil.
+func (r *catalogResolver) category(path string) (*models.Category, error) {
+	parts := models.SplitCategoryPath(path)
+	var parent *models.Category
+	for i := range parts {
+		key := models.LookupKey(models.JoinCategoryPath(parts[:i+1]))
+		if c, ok := r.categories[key]; ok {
+			parent = c
+			continue
+		}
+
+		var c models.Category
+		err := r.tx.Where("lookup_key = ?", key).Take(&c).Error
+		if err == gorm.ErrRecordNotFound {
+			// Путь строим от сохраненного родителя, чтобы сохранить его написание
+			c = models.Category{Name: parts[i], Path: parts[i], LookupKey: key}
+			if parent != nil {
+				c.ParentID = &parent.ID
+				c.Path = parent.Path + models.CategoryPathSeparator + parts[i]
+			}
+			err = r.tx.Create(&c).Error
+		}
+		if err != nil {
+			return nil, err
+		}
+		r.categories[key] = &c
+		parent = &c
+	}
+	return parent, nil
+}
+
+// brand возвращает бренд по названию, создавая его при необходимости. Для пустого названия - nil.
+func (r *catalogResolver) brand(name string) (*models.Brand, error) {
+	key := models.LookupKey(name)
+	if key == "" {
+		return nil, nil
+	}
+	if b, ok := r.brands[key]; ok {
+		return b, nil
+	}
+
+	b := models.Brand{Name: models.NormalizeName(name), LookupKey: key}
+	if err := r.tx.Where(models.Brand{LookupKey: key}).FirstOrCreate(&b).Error; err != nil {
+		return nil, err
+	}
+	r.brands[key] = &b
+	return &b, nil
+}
+
+// resolve связывает товар со справочниками по введенным названиям категории и бренда
+// и приводит названия к написанию из справочника
+func (r *catalogResolver) resolve(p *models.Product) error {
+	category, err := r.category(p.Category)
+	if err != nil {
+		return err
+	}
+	p.CategoryID, p.Category = nil, ""
+	if category != nil {
+		p.CategoryID, p.Category = &category.ID, category.Path
+	}
+
+	brand, err := r.brand(p.Brand)
+	if err != nil {
+		return err
+	}
+	p.BrandID, p.Brand = nil, ""
+	if brand != nil {
+		p.BrandID, p.Brand = &brand.ID, brand.Name
+	}
+	return nil
+}
+
+// ResolveCatalog находит или создает категорию и бренд товара по названиям
+func ResolveCatalog(p *models.Product) error {
+	return newCatalogResolver(DB).resolve(p)
+}
+
+// migrateCatalog переносит в справочники категории и бренды товаров, сохраненных
+// до их появления. Названия, отличающиеся только регистром и пробелами, объединяются;
+// в справочник попадает самое частое написание.
+func migrateCatalog(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		// Связывание со справочниками не меняет данные по существу и в журнал не пишется
+		tx = withoutAudit(tx)
+		r := newCatalogResolver(tx)
+		products := tx.Unscoped().Model(&models.Product{})
+
+		var categories []string
+		products.Session(&gorm.Session{}).
+			Where("category_id IS NULL AND category <> ''").
+			Group("category").Order("count(*) DESC, category").
+			Pluck("category", &categories)
+		for _, name := range categories {
+			c, err := r.category(name)
+			if err != nil {
+				return err
+			}
+			err = products.Session(&gorm.Session{}).
+				Where("category_id IS NULL AND category = ?", name).
+				UpdateColumns(map[string]interface{}{"category_id": c.ID, "category": c.Path}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		var brands []string
+		products.Session(&gorm.Session{}).
+			Where("brand_id IS NULL AND brand <> ''").
+			Group("brand").Order("count(*) DESC, brand").
+			Pluck("brand", &brands)
+		for _, name := range brands {
+			b, err := r.brand(name)
+			if err != nil {
+				return err
+			}
+			err = products.Session(&gorm.Session{}).
+				Where("brand_id IS NULL AND brand = ?", name).
+				UpdateColumns(map[string]interface{}{"brand_id": b.ID, "brand": b.Name}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// CategoryPaths возвращает полные пути всех категорий по алфавиту
+func CategoryPaths() []string {
+	var paths []string
+	DB.Model(&models.Category{}).Order("path").Pluck("path", &paths)
+	return paths
+}
+
+// BrandNames возвращает названия всех брендов по алфавиту
+func BrandNames() []string {
+	var names []string
+	DB.Model(&models.Brand{}).Order("name").Pluck("name", &names)
+	return names
+}
+
+// InCategory ограничивает запрос товарами категории path и ее подкатегорий
+func InCategory(query *gorm.DB, path string) *gorm.DB {
+	return query.Where("(category = ? OR category LIKE ?"+likeEscape+")", path, escapeLike(path+models.CategoryPathSeparator)+"%")
+}
+
+// CategoryGroup - строка отчета по категориям
+type CategoryGroup struct {
+	Path string
+	// Subtree - в группу входят и товары подкатегорий
+	Subtree bool
+}
+
+// Apply ограничивает запрос товарами группы
+func (g CategoryGroup) Apply(query *gorm.DB) *gorm.DB {
+	if g.Subtree {
+		return InCategory(query, g.Path)
+	}
+	return query.Where("category = ?", g.Path)
+}
+
+// CategoryGroups возвращает группы отчета по категориям, свернутым до уровня level.
+// При level = 0 каждая категория - отдельная группа. Категории выше уровня level
+// образуют группы без подкатегорий: их подкатегории попадают в свои группы.
+func CategoryGroups(level int) []CategoryGroup {
+	var paths []string
+	DB.Model(&models.Product{}).Distinct().Order("category").Pluck("category", &paths)
+
+	var groups []CategoryGroup
+	seen := map[string]bool{}
+	for _, path := range paths {
+		group := CategoryGroup{Path: path}
+		if level > 0 && len(models.SplitCategoryPath(path)) >= level {
+			group = CategoryGroup{Path: models.CategoryAncestor(path, level), Subtree: true}
+		}
+		if !seen[group.Path] {
+			seen[group.Path] = true
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
+
+// CategoryProductCounts возвращает число товаров, непосредственно входящих в каждую категорию
+func CategoryProductCounts() map[uint]int64 {
+	return productCounts("category_id")
+}
+
+// BrandProductCounts возвращает число товаров каждого бренда
+func BrandProductCounts() map[uint]int64 {
+	return productCounts("brand_id")
+}
+
+func productCounts(column string) map[uint]int64 {
+	var rows []struct {
+		ID    uint
+		Count int64
+	}
+	DB.Model(&models.Product{}).
+		Select(column + " AS id, count(*) AS count").
+		Where(column + " IS NOT NULL").
+		Group(column).
+		Scan(&rows)
+
+	counts := make(map[uint]int64, len(rows))
+	for _, r := range rows {
+		counts[r.ID] = r.Count
+	}
+	return counts
+}
+
+// updateCategoryPath записывает категории новый путь и обновляет пути подкатегорий
+// и названия категорий у товаров
+func updateCategoryPath(tx *gorm.DB, c *models.Category, path string) error {
+	c.Path = path
+	c.LookupKey = models.LookupKey(path)
+	if err := tx.Save(c).Error; err != nil {
+		return err
+	}
+	err := tx.Unscoped().Model(&models.Product{}).
+		Where("category_id = ?", c.ID).
+		UpdateColumn("category", path).Error
+	if err != nil {
+		return err
+	}
+
+	var children []models.Category
+	if err := tx.Where("parent_id = ?", c.ID).Find(&children).Error; err != nil {
+		return err
+	}
+	for i := range children {
+		child := &children[i]
+		if err := updateCategoryPath(tx, child, path+models.CategoryPathSeparator+child.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RenameCategory задает категории новый путь. Последний уровень пути - новое название,
+// предыдущие - новый родитель (недостающие уровни создаются), так что категорию
+// можно и переименовать, и перенести в другую ветку.
+func RenameCategory(id uint, path string) error {
+	if err := requirePermission(models.PermManageCatalog); err != nil {
+		return err
+	}
+
+	parts := models.SplitCategoryPath(path)
+	if len(parts) == 0 {
+		return models.Errorf("укажите название категории")
+	}
+	path = models.JoinCategoryPath(parts)
+
+	return DB.Transaction(func(tx *gorm.DB) error {
+		var c models.Category
+		if err := tx.First(&c, id).Error; err != nil {
+			return err
+		}
+
+		key := models.LookupKey(path)
+		if key == c.LookupKey && path == c.Path {
+			return nil
+		}
+		// Смена только регистра не создает конфликта с самой собой
+		if key != c.LookupKey {
+			var count int64
+			tx.Model(&models.Category{}).Where("lookup_key = ?", key).Count(&count)
+			if count > 0 {
+				return models.Errorf("категория %s уже существует, используйте объединение", path)
+			}
+		}
+		if strings.HasPrefix(key, c.LookupKey+models.CategoryPathSeparator) {
+			return models.Errorf("нельзя перенести категорию в ее собственную подкатегорию")
+		}
+
+		c.Name = parts[len(parts)-1]
+		c.ParentID = nil
+		if len(parts) > 1 {
+			parent, err := newCatalogResolver(tx).category(models.JoinCategoryPath(parts[:len(parts)-1]))
+			if err != nil {
+				return err
+			}
+			c.ParentID = &parent.ID
+			path = parent.Path + models.CategoryPathSeparator + c.Name
+		}
+		return updateCategoryPath(tx, &c, path)
+	})
+}
+
+// MergeCategories переносит товары и подкатегории категории sourceID в targetID
+// и удаляет sourceID. Одноименные подкатегории объединяются.
+func MergeCategories(sourceID, targetID uint) error {
+	if err := requirePermission(models.PermManageCatalog); err != nil {
+		return err
+	}
+
+	return DB.Transaction(func(tx *gorm.DB) error {
+		var source, target models.Category
+		if err := tx.First(&source, sourceID).Error; err != nil {
+			return err
+		}
+		if err := tx.First(&target, targetID).Error; err != nil {
+			return err
+		}
+		if source.ID == target.ID || strings.HasPrefix(target.LookupKey, source.LookupKey+models.CategoryPathSeparator) {
+			return models.Errorf("нельзя объединить категорию с ней самой или с ее подкатегорией")
+		}
+		return mergeCategory(tx, &source, &target)
+	})
+}
+
+func mergeCategory(tx *gorm.DB, source, target *models.Category) error {
+	err := tx.Unscoped().Model(&models.Product{}).
+		Where("category_id = ?", source.ID).
+		UpdateColumns(map[string]interface{}{"category_id": target.ID, "category": target.Path}).Error
+	if err != nil {
+		return err
+	}
+
+	var children []models.Category
+	if err := tx.Where("parent_id = ?", source.ID).Find(&children).Error; err != nil {
+		return err
+	}
+	for i := range children {
+		child := &children[i]
+		path := target.Path + models.CategoryPathSeparator + child.Name
+
+		var existing models.Category
+		err := tx.Where("lookup_key = ?", models.LookupKey(path)).Take(&existing).Error
+		switch {
+		case err == nil:
+			err = mergeCategory(tx, child, &existing)
+		case err == gorm.ErrRecordNotFound:
+			child.ParentID = &target.ID
+			err = updateCategoryPath(tx, child, path)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Delete(source).Error
+}
+
+// DeleteCategory удаляет категорию без товаров и подкатегорий
+func DeleteCategory(id uint) error {
+	if err := requirePermission(models.PermManageCatalog); err != nil {
+		return err
+	}
+
+	var products, children int64
+	DB.Unscoped().Model(&models.Product{}).Where("category_id = ?", id).Count(&products)
+	DB.Model(&models.Category{}).Where("parent_id = ?", id).Count(&children)
+	if products > 0 || children > 0 {
+		return models.Errorf("в категории есть товары или подкатегории, используйте объединение")
+	}
+	return DB.Delete(&models.Category{}, id).Error
+}
+
+// RenameBrand переименовывает бренд и обновляет название у товаров
+func RenameBrand(id uint, name string) error {
+	if err := requirePermission(models.PermManageCatalog); err != nil {
+		return err
+	}
+
+	name = models.NormalizeName(name)
+	if name == "" {
+		return models.Errorf("укажите название бренда")
+	}
+
+	return DB.Transaction(func(tx *gorm.DB) error {
+		var b models.Brand
+		if err := tx.First(&b, id).Error; err != nil {
+			return err
+		}
+		key := models.LookupKey(name)
+		if key != b.LookupKey {
+			var count int64
+			tx.Model(&models.Brand{}).Where("lookup_key = ?", key).Count(&count)
+			if count > 0 {
+				return models.Errorf("бренд %s уже существует, используйте объединение", name)
+			}
+		}
+
+		b.Name, b.LookupKey = name, key
+		if err := tx.Save(&b).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Model(&models.Product{}).
+			Where("brand_id = ?", id).
+			UpdateColumn("brand", name).Error
+	})
+}
+
+// MergeBrands переносит товары бренда sourceID в targetID и удаляет sourceID
+func MergeBrands(sourceID, targetID uint) error {
+	if err := requirePermission(models.PermManageCatalog); err != nil {
+		return err
+	}
+
+	if sourceID == targetID {
+		return models.Errorf("нельзя объединить бренд с самим собой")
+	}
+
+	return DB.Transaction(func(tx *gorm.DB) error {
+		var source, target models.Brand
+		if err := tx.First(&source, sourceID).Error; err != nil {
+			return err
+		}
+		if err := tx.First(&target, targetID).Error; err != nil {
+			return err
+		}
+		err := tx.Unscoped().Model(&models.Product{}).
+			Where("brand_id = ?", source.ID).
+			UpdateColumns(map[string]interface{}{"brand_id": target.ID, "brand": target.Name}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Delete(&source).Error
+	})
+}
+
+// DeleteBrand удаляет бренд без товаров
+func DeleteBrand(id uint) error {
+	if err := requirePermission(models.PermManageCatalog); err != nil {
+		return err
+	}
+
+	var products int64
+	DB.Unscoped().Model(&models.Product{}).Where("brand_id = ?", id).Count(&products)
+	if products > 0 {
+		return models.Errorf("у бренда есть товары, используйте объединение")
+	}
+	return DB.Delete(&models.Brand{}, id).Error
+}
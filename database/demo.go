@@ -0,0 +1,262 @@
+package database
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"SanWarehouse/models"
+
+	"gorm.io/gorm"
+)
+
+// demoFixtures - демонстрационные поставщики, товары и курсы валют
+//
+//go:embed fixtures/*.json
+var demoFixtures embed.FS
+
+const (
+	// DemoProducts - сколько товаров генерируется для демонстрации по умолчанию
+	DemoProducts = 300
+	// demoHistoryDays - за сколько дней создается история приходов и отгрузок
+	demoHistoryDays = 180
+	// demoBatchSize - сколько товаров создается одним запросом
+	demoBatchSize = 100
+)
+
+// warehouseTables - данные склада, без учетных записей и журнала
+var warehouseTables = []interface{}{
+	&models.Product{},
+	&models.Supplier{},
+	&models.Category{},
+	&models.Brand{},
+	&models.PurchaseOrder{},
+	&models.StockMovement{},
+	&models.ExchangeRate{},
+}
+
+// demoSupplier - поставщик из fixtures и бренды, которые он поставляет
+type demoSupplier struct {
+	models.Supplier
+	Brands []string `json:"brands"`
+}
+
+// WarehouseEmpty сообщает, что на складе еще нет данных: ни товаров, в том числе удаленных,
+// ни справочников, ни движений. Так определяется первый запуск.
+func WarehouseEmpty() bool {
+	empty, err := tablesEmpty(DB, warehouseTables)
+	return err == nil && empty
+}
+
+// tablesEmpty сообщает, что в таблицах моделей нет ни одной записи
+func tablesEmpty(tx *gorm.DB, tables []interface{}) (bool, error) {
+	for _, table := range tables {
+		var count int64
+		if err := tx.Unscoped().Model(table).Count(&count).Error; err != nil {
+			return false, err
+		}
+		if count > 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// LoadDemoData заполняет пустой склад демонстрационными данными: поставщиками, товарами
+// и курсами валют из встроенных fixtures и count сгенерированными товарами с историей движений за полгода,
+// чтобы в отчетах (ABC/XYZ, прогноз, пополнение) было что показать. Возвращает количество
+// созданных товаров. Демонстрационные данные в журнал аудита не записываются.
+func LoadDemoData(count int, seed int64) (int, error) {
+	if err := requirePermission(models.PermEditProducts); err != nil {
+		return 0, err
+	}
+	if !WarehouseEmpty() {
+		return 0, models.Errorf("демонстрационные данные загружаются только в пустую базу")
+	}
+
+	var suppliers []demoSupplier
+	var fixtures []models.Product
+	var rates []models.ExchangeRate
+	if err := readFixture("fixtures/suppliers.json", &suppliers); err != nil {
+		return 0, err
+	}
+	if err := readFixture("fixtures/products.json", &fixtures); err != nil {
+		return 0, err
+	}
+	if err := readFixture("fixtures/exchange_rates.json", &rates); err != nil {
+		return 0, err
+	}
+
+	created := 0
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		tx = withoutAudit(tx)
+		catalog := newCatalogResolver(tx)
+
+		// Курсы в fixtures без даты: они действуют с начала истории движений
+		now := time.Now()
+		for i := range rates {
+			if rates[i].Date.IsZero() {
+				rates[i].Date = now.AddDate(0, 0, -demoHistoryDays)
+			}
+			if err := validateExchangeRate(&rates[i]); err != nil {
+				return err
+			}
+		}
+		if err := tx.Create(&rates).Error; err != nil {
+			return err
+		}
+
+		// Поставщик товара определяется по бренду
+		brandSuppliers := map[string]*uint{}
+		for i := range suppliers {
+			s := &suppliers[i]
+			if err := tx.Create(&s.Supplier).Error; err != nil {
+				return err
+			}
+			for _, brand := range s.Brands {
+				brandSuppliers[models.LookupKey(brand)] = &s.ID
+			}
+		}
+
+		// Остаток товаров из fixtures оприходуется одним движением
+		for i := range fixtures {
+			p := &fixtures[i]
+			p.SupplierID = brandSuppliers[models.LookupKey(p.Brand)]
+			if err := catalog.resolve(p); err != nil {
+				return err
+			}
+			if err := tx.Create(p).Error; err != nil {
+				return models.Errorf("товар %s: %w", p.SKU, err)
+			}
+			if p.Quantity > 0 {
+				movement := models.StockMovement{CreatedAt: now, ProductID: p.ID, Type: models.MovementReceipt,
+					Quantity: p.Quantity, Location: p.Location, Comment: "Начальный остаток"}
+				if err := tx.Create(&movement).Error; err != nil {
+					return err
+				}
+			}
+		}
+		created = len(fixtures)
+
+		rng := rand.New(rand.NewSource(seed))
+		for start := 0; start < count; start += demoBatchSize {
+			n := min(demoBatchSize, count-start)
+			products := make([]models.Product, n)
+			histories := make([][]models.StockMovement, n)
+			for i := range products {
+				products[i] = generateProduct(rng, "", start+i+1)
+				products[i].SupplierID = brandSuppliers[models.LookupKey(products[i].Brand)]
+				histories[i] = simulateDemoHistory(rng, &products[i], now)
+				if err := catalog.resolve(&products[i]); err != nil {
+					return err
+				}
+			}
+			if err := tx.Create(&products).Error; err != nil {
+				return err
+			}
+
+			var movements []models.StockMovement
+			for i, history := range histories {
+				for j := range history {
+					history[j].ProductID = products[i].ID
+				}
+				movements = append(movements, history...)
+			}
+			if len(movements) > 0 {
+				if err := tx.CreateInBatches(&movements, 500).Error; err != nil {
+					return err
+				}
+			}
+			created += n
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return created, nil
+}
+
+// readFixture читает встроенный файл fixtures
+func readFixture(name string, v interface{}) error {
+	data, err := demoFixtures.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return models.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// simulateDemoHistory моделирует продажи товара за demoHistoryDays дней: ежедневные отгрузки
+// со случайным спросом и приходы заказанных партий через срок поставки. Задает политику
+// пополнения по среднему спросу и итоговый остаток товара; возвращает движения.
+func simulateDemoHistory(rng *rand.Rand, p *models.Product, now time.Time) []models.StockMovement {
+	// Спрос распределен неравномерно: у немногих товаров продажи ежедневные, часть не продается
+	demand := rng.ExpFloat64() * 0.3
+	if rng.Float64() < 0.15 {
+		demand = 0
+	}
+	// Нерегулярный спрос (класс Z): редкие крупные отгрузки вместо ежедневных
+	irregular := rng.Float64() < 0.25
+
+	p.LeadTimeDays = 5 + rng.Intn(26)
+	p.MinStockLevel = max(1, int(math.Ceil(demand*7)))
+	p.ReorderPoint = int(math.Ceil(demand * float64(p.LeadTimeDays+7)))
+	p.ReorderQuantity = max(2, int(math.Ceil(demand*30)))
+	p.MaxStockLevel = p.ReorderPoint + p.ReorderQuantity
+
+	start := now.AddDate(0, 0, -demoHistoryDays)
+	stock := p.ReorderQuantity + rng.Intn(p.ReorderQuantity+1)
+	movements := []models.StockMovement{{CreatedAt: start, Type: models.MovementReceipt, Quantity: stock,
+		Location: p.Location, Comment: "Начальный остаток"}}
+
+	arrival, order := -1, 0
+	for day := 1; day <= demoHistoryDays; day++ {
+		at := start.AddDate(0, 0, day).Add(time.Duration(9+rng.Intn(9)) * time.Hour)
+		if day == arrival {
+			stock += order
+			movements = append(movements, models.StockMovement{CreatedAt: at, Type: models.MovementReceipt,
+				Quantity: order, Location: p.Location, Reference: fmt.Sprintf("PO-DEMO-%s-%d", p.SKU, day)})
+			arrival = -1
+		}
+
+		sold := demoPoisson(rng, demand)
+		if irregular {
+			sold = 0
+			if rng.Float64() < demand/4 {
+				sold = 1 + rng.Intn(8)
+			}
+		}
+		if sold = min(sold, stock); sold > 0 {
+			stock -= sold
+			movements = append(movements, models.StockMovement{CreatedAt: at, Type: models.MovementShipment,
+				Quantity: -sold, Location: p.Location})
+		}
+
+		// Заказ поставщику, когда остаток опустился до точки заказа
+		if arrival < 0 && demand > 0 && stock <= p.ReorderPoint {
+			arrival, order = day+p.LeadTimeDays, p.ReorderQuantity
+		}
+	}
+	p.Quantity = stock
+	p.ReservedQuantity = 0
+	return movements
+}
+
+// demoPoisson возвращает случайное число продаж за день при среднем lambda
+func demoPoisson(rng *rand.Rand, lambda float64) int {
+	if lambda <= 0 {
+		return 0
+	}
+	limit, k, prod := math.Exp(-lambda), 0, rng.Float64()
+	for prod > limit {
+		k++
+		prod *= rng.Float64()
+	}
+	return k
+}
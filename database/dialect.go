@@ -0,0 +1,62 @@
+package database
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"SanWarehouse/config"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// openDialector выбирает драйвер базы по настройкам; по умолчанию - файл SQLite
+func openDialector(cfg config.Database) (gorm.Dialector, error) {
+	switch cfg.Driver {
+	case "", config.DriverSQLite:
+		// Создаем папку для файла базы, если её нет
+		if err := os.MkdirAll(filepath.Dir(cfg.DSN), os.ModePerm); err != nil {
+			return nil, err
+		}
+		return sqlite.New(sqlite.Config{DriverName: sqliteDriverName, DSN: cfg.DSN}), nil
+	case config.DriverPostgres:
+		return postgres.Open(cfg.DSN), nil
+	}
+	return nil, fmt.Errorf("неизвестный драйвер базы данных %q (допустимы %s и %s)",
+		cfg.Driver, config.DriverSQLite, config.DriverPostgres)
+}
+
+// Dialect возвращает имя драйвера открытой базы: config.DriverSQLite или config.DriverPostgres
+func Dialect() string {
+	return DB.Dialector.Name()
+}
+
+// isSQLite сообщает, что база - файл SQLite. Полнотекстовый индекс FTS5, PRAGMA
+// и резервное копирование через VACUUM INTO есть только у SQLite.
+func isSQLite(db *gorm.DB) bool {
+	return db.Dialector.Name() == config.DriverSQLite
+}
+
+// likeEscape - признак экранирования в шаблонах LIKE, одинаковый в SQLite и PostgreSQL;
+// добавляется после "LIKE ?", если шаблон построен из текста пользователя через escapeLike
+const likeEscape = ` ESCAPE '\'`
+
+// likeEscaper экранирует символы шаблона LIKE
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// escapeLike экранирует в тексте символы шаблона LIKE, чтобы "50%" и "A_1" искались буквально
+func escapeLike(text string) string {
+	return likeEscaper.Replace(text)
+}
+
+// lowerExpr переводит выражение в нижний регистр с учетом кириллицы: встроенная lower()
+// в SQLite работает только с ASCII, поэтому для нее зарегистрирована utf8_lower
+func lowerExpr(db *gorm.DB, expr string) string {
+	if isSQLite(db) {
+		return "utf8_lower(" + expr + ")"
+	}
+	return "lower(" + expr + ")"
+}
@@ -0,0 +1,262 @@
+package database
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"SanWarehouse/models"
+
+	"gorm.io/gorm"
+)
+
+// ExchangeRateList возвращает все курсы, новые первыми
+func ExchangeRateList() []models.ExchangeRate {
+	var rates []models.ExchangeRate
+	DB.Order("date DESC, currency").Find(&rates)
+	return rates
+}
+
+// ExchangeRatesOn возвращает курсы, действующие в день day: для каждой валюты
+// последний курс, заданный не позже этого дня
+func ExchangeRatesOn(day time.Time) models.ExchangeRates {
+	var list []models.ExchangeRate
+	DB.Where("date <= ?", models.RateDay(day)).Order("date").Find(&list)
+	rates := models.ExchangeRates{}
+	for _, r := range list {
+		// Некорректный курс, сохраненный до проверки, считается отсутствующим
+		if math.IsNaN(r.Rate) || math.IsInf(r.Rate, 0) || r.Rate <= 0 {
+			delete(rates, r.Currency)
+			continue
+		}
+		rates[r.Currency] = r.Rate
+	}
+	return rates
+}
+
+// CurrentExchangeRates возвращает курсы, действующие сегодня
+func CurrentExchangeRates() models.ExchangeRates {
+	return ExchangeRatesOn(time.Now())
+}
+
+// validateExchangeRate проверяет курс и приводит дату к дню
+func validateExchangeRate(rate *models.ExchangeRate) error {
+	currency, err := models.ParseCurrency(string(rate.Currency))
+	if err != nil {
+		return err
+	}
+	if currency == models.BaseCurrency {
+		return models.Errorf("курс валюты учета %s не задается", currency)
+	}
+	if math.IsNaN(rate.Rate) || math.IsInf(rate.Rate, 0) || rate.Rate <= 0 {
+		return models.Errorf("курс %s должен быть положительным числом", currency)
+	}
+	if rate.Date.IsZero() {
+		return models.Errorf("укажите дату курса %s", currency)
+	}
+	rate.Currency, rate.Date = currency, models.RateDay(rate.Date)
+	return nil
+}
+
+// findExchangeRate возвращает ID курса валюты на день или 0, если курса нет
+func findExchangeRate(tx *gorm.DB, currency models.Currency, day time.Time) (uint, error) {
+	var ids []uint
+	err := tx.Model(&models.ExchangeRate{}).
+		Where("currency = ? AND date = ?", currency, day).
+		Limit(1).Pluck("id", &ids).Error
+	if err != nil || len(ids) == 0 {
+		return 0, err
+	}
+	return ids[0], nil
+}
+
+// SaveExchangeRate добавляет или изменяет курс валюты. На один день у валюты один курс.
+func SaveExchangeRate(rate *models.ExchangeRate) error {
+	if err := requirePermission(models.PermEditPrices); err != nil {
+		return err
+	}
+	if err := validateExchangeRate(rate); err != nil {
+		return err
+	}
+
+	return DB.Transaction(func(tx *gorm.DB) error {
+		id, err := findExchangeRate(tx, rate.Currency, rate.Date)
+		if err != nil {
+			return err
+		}
+		if id != 0 && id != rate.ID {
+			return models.Errorf("курс %s на %s уже задан", rate.Currency, rate.Date.Format("02.01.2006"))
+		}
+		return tx.Save(rate).Error
+	})
+}
+
+// DeleteExchangeRate удаляет курс
+func DeleteExchangeRate(id uint) error {
+	if err := requirePermission(models.PermEditPrices); err != nil {
+		return err
+	}
+	return DB.Delete(&models.ExchangeRate{}, id).Error
+}
+
+// rateDateLayouts - форматы дат в файле курсов
+var rateDateLayouts = []string{"2006-01-02", "02.01.2006"}
+
+// ImportExchangeRates загружает курсы из CSV: дата, код валюты, курс и необязательный номинал
+// (курс 45,12 за 100 единиц - "45,12;100"). Разделитель колонок - точка с запятой, запятая
+// или табуляция, первая строка может быть заголовком. Курсы на уже заданные дни заменяются.
+// Файл загружается целиком или не загружается вовсе; возвращает число загруженных курсов.
+func ImportExchangeRates(r io.Reader) (int, error) {
+	if err := requirePermission(models.PermEditPrices); err != nil {
+		return 0, err
+	}
+
+	reader := bufio.NewReader(r)
+	first, _ := reader.Peek(4096)
+	csvReader := csv.NewReader(reader)
+	csvReader.Comma = rateSeparator(string(first))
+	csvReader.FieldsPerRecord = -1
+	csvReader.TrimLeadingSpace = true
+	records, err := csvReader.ReadAll()
+	if err != nil {
+		return 0, err
+	}
+
+	var rates []models.ExchangeRate
+	for i, record := range records {
+		rate, err := parseRateRecord(record)
+		if err != nil {
+			// Заголовок - первая строка, в которой не читается дата
+			if i == 0 && len(record) > 0 && parseRateDate(record[0]).IsZero() {
+				continue
+			}
+			return 0, models.Errorf("строка %d: %w", i+1, err)
+		}
+		if rate != nil {
+			rates = append(rates, *rate)
+		}
+	}
+	if len(rates) == 0 {
+		return 0, models.Errorf("в файле нет курсов")
+	}
+
+	err = DB.Transaction(func(tx *gorm.DB) error {
+		for i := range rates {
+			id, err := findExchangeRate(tx, rates[i].Currency, rates[i].Date)
+			if err != nil {
+				return err
+			}
+			if id != 0 {
+				err = tx.Model(&models.ExchangeRate{ID: id}).Update("rate", rates[i].Rate).Error
+			} else {
+				err = tx.Create(&rates[i]).Error
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(rates), nil
+}
+
+// rateSeparator определяет разделитель колонок по началу файла
+func rateSeparator(head string) rune {
+	line, _, _ := strings.Cut(head, "\n")
+	switch {
+	case strings.Contains(line, ";"):
+		return ';'
+	case strings.Contains(line, "\t"):
+		return '\t'
+	}
+	return ','
+}
+
+// parseRateDate разбирает дату курса; для нераспознанной строки возвращает нулевое время
+func parseRateDate(s string) time.Time {
+	for _, layout := range rateDateLayouts {
+		if t, err := time.Parse(layout, strings.TrimSpace(s)); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// parseRateRecord разбирает строку файла курсов. Для пустой строки возвращает nil.
+func parseRateRecord(record []string) (*models.ExchangeRate, error) {
+	if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+		return nil, nil
+	}
+	if len(record) < 3 || len(record) > 4 {
+		return nil, models.Errorf("ожидается дата, валюта, курс и необязательный номинал")
+	}
+
+	date := parseRateDate(record[0])
+	if date.IsZero() {
+		return nil, models.Errorf("некорректная дата: %s", record[0])
+	}
+	value, err := models.ParseDecimal(record[2])
+	if err != nil {
+		return nil, err
+	}
+	if len(record) == 4 && strings.TrimSpace(record[3]) != "" {
+		nominal, err := models.ParseInteger(record[3])
+		if err != nil || nominal <= 0 {
+			return nil, models.Errorf("некорректный номинал: %s", record[3])
+		}
+		value /= float64(nominal)
+	}
+
+	rate := &models.ExchangeRate{Currency: models.Currency(record[1]), Date: date, Rate: value}
+	if err := validateExchangeRate(rate); err != nil {
+		return nil, err
+	}
+	return rate, nil
+}
+
+// PurchaseCostSQL возвращает SQL-выражение закупочной цены товара в валюте учета по курсам
+// rates. Для валют без курса выражение равно NULL, и sum() такие товары пропускает;
+// эти валюты возвращает CurrenciesWithoutRate.
+func PurchaseCostSQL(rates models.ExchangeRates) string {
+	currencies := make([]string, 0, len(rates))
+	for c := range rates {
+		// Код валюты и курс подставляются в запрос, поэтому берутся только коды ISO
+		// и конечные числа: +Inf или NaN сделали бы запрос некорректным
+		if rate := rates[c]; c.Valid() && c != models.BaseCurrency && !math.IsNaN(rate) && !math.IsInf(rate, 0) {
+			currencies = append(currencies, string(c))
+		}
+	}
+	sort.Strings(currencies)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "(purchase_price * CASE purchase_currency WHEN '%s' THEN 1", models.BaseCurrency)
+	for _, c := range currencies {
+		fmt.Fprintf(&b, " WHEN '%s' THEN %s", c, strconv.FormatFloat(rates[models.Currency(c)], 'f', -1, 64))
+	}
+	b.WriteString(" END)")
+	return b.String()
+}
+
+// CurrenciesWithoutRate возвращает валюты закупочных цен товаров query, для которых
+// в rates нет курса: стоимость таких товаров не пересчитать в валюту учета
+func CurrenciesWithoutRate(query *gorm.DB, rates models.ExchangeRates) []models.Currency {
+	var used []models.Currency
+	query.Distinct("purchase_currency").Pluck("purchase_currency", &used)
+	var missing []models.Currency
+	for _, c := range used {
+		if _, ok := rates.Convert(0, c); !ok {
+			missing = append(missing, c)
+		}
+	}
+	sort.Slice(missing, func(i, j int) bool { return missing[i] < missing[j] })
+	return missing
+}
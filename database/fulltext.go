@@ -0,0 +1,188 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"unicode"
+
+	"github.com/mattn/go-sqlite3"
+	"gorm.io/gorm"
+)
+
+// Имя драйвера SQLite с дополнительными функциями склада
+const sqliteDriverName = "sqlite3_warehouse"
+
+func init() {
+	// utf8_lower переводит строку в нижний регистр с учетом кириллицы:
+	// встроенная lower() в SQLite работает только с ASCII
+	sql.Register(sqliteDriverName, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			return conn.RegisterFunc("utf8_lower", strings.ToLower, true)
+		},
+	})
+}
+
+// Колонки полнотекстового индекса в порядке объявления и их веса для bm25
+var fullTextColumns = []struct {
+	name   string
+	weight float64
+}{
+	{"sku", 10},
+	{"name", 5},
+	{"brand", 3},
+	{"category", 2},
+	{"material", 1.5},
+	{"description", 1},
+	{"location", 1},
+	{"marketplace_id", 1},
+}
+
+// fullTextEnabled - доступен ли индекс FTS5 (go-sqlite3 собран с тегом sqlite_fts5)
+var fullTextEnabled bool
+
+// FullTextEnabled сообщает, используется ли для поиска полнотекстовый индекс
+func FullTextEnabled() bool {
+	return fullTextEnabled
+}
+
+// setupFullTextSearch создает индекс FTS5 по товарам и триггеры его синхронизации.
+// Без модуля FTS5 и в PostgreSQL поиск работает через LIKE без учета регистра, см. lowerExpr.
+func setupFullTextSearch(db *gorm.DB) error {
+	fullTextEnabled = false
+	if !isSQLite(db) {
+		return nil
+	}
+
+	columns := make([]string, len(fullTextColumns))
+	newValues := make([]string, len(fullTextColumns))
+	oldValues := make([]string, len(fullTextColumns))
+	for i, c := range fullTextColumns {
+		columns[i] = c.name
+		newValues[i] = "new." + c.name
+		oldValues[i] = "old." + c.name
+	}
+	columnList := strings.Join(columns, ", ")
+
+	exists := db.Migrator().HasTable("products_fts")
+
+	var err error
+	if exists {
+		// Индекс мог создать экземпляр, собранный с FTS5: без модуля таблица не читается
+		err = db.Exec("SELECT 1 FROM products_fts LIMIT 0").Error
+	} else {
+		// unicode61 приводит к нижнему регистру и кириллицу, remove_diacritics убирает ударения и "ё"
+		err = db.Exec(fmt.Sprintf(
+			"CREATE VIRTUAL TABLE products_fts USING fts5(%s, content='products', content_rowid='id', tokenize='unicode61 remove_diacritics 2')",
+			columnList)).Error
+	}
+	if err != nil {
+		if !strings.Contains(err.Error(), "no such module") {
+			return err
+		}
+		log.Println("FTS5 недоступен, поиск будет выполняться через LIKE (соберите с -tags sqlite_fts5)")
+		return dropFullTextTriggers(db)
+	}
+
+	// Триггеры могли быть удалены экземпляром без FTS5, тогда индекс отстал от товаров
+	var synced int64
+	db.Raw("SELECT count(*) FROM sqlite_master WHERE type = 'trigger' AND name = 'products_fts_ai'").Scan(&synced)
+
+	triggers := []string{
+		fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS products_fts_ai AFTER INSERT ON products BEGIN
+			INSERT INTO products_fts(rowid, %[1]s) VALUES (new.id, %[2]s);
+		END`, columnList, strings.Join(newValues, ", ")),
+		fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS products_fts_ad AFTER DELETE ON products BEGIN
+			INSERT INTO products_fts(products_fts, rowid, %[1]s) VALUES ('delete', old.id, %[2]s);
+		END`, columnList, strings.Join(oldValues, ", ")),
+		fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS products_fts_au AFTER UPDATE ON products BEGIN
+			INSERT INTO products_fts(products_fts, rowid, %[1]s) VALUES ('delete', old.id, %[2]s);
+			INSERT INTO products_fts(rowid, %[1]s) VALUES (new.id, %[3]s);
+		END`, columnList, strings.Join(oldValues, ", "), strings.Join(newValues, ", ")),
+	}
+	for _, t := range triggers {
+		if err := db.Exec(t).Error; err != nil {
+			return err
+		}
+	}
+
+	if !exists || synced == 0 {
+		// Индексируем уже существующие товары
+		if err := db.Exec("INSERT INTO products_fts(products_fts) VALUES ('rebuild')").Error; err != nil {
+			return err
+		}
+	}
+
+	fullTextEnabled = true
+	return nil
+}
+
+// dropFullTextTriggers удаляет триггеры синхронизации индекса: без модуля FTS5 они
+// не дали бы изменять товары. Сам индекс остается и пригодится, когда модуль будет доступен.
+func dropFullTextTriggers(db *gorm.DB) error {
+	for _, name := range []string{"products_fts_ai", "products_fts_ad", "products_fts_au"} {
+		if err := db.Exec("DROP TRIGGER IF EXISTS " + name).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fullTextMatch строит выражение MATCH для FTS5: каждое слово ищется по префиксу основы,
+// фраза в кавычках - целиком
+func fullTextMatch(terms []string) string {
+	var parts []string
+	for _, term := range terms {
+		words := strings.FieldsFunc(term, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		if len(words) == 0 {
+			continue
+		}
+		if len(words) > 1 && strings.ContainsRune(term, ' ') {
+			parts = append(parts, `"`+strings.Join(words, " ")+`"`)
+			continue
+		}
+		for _, w := range words {
+			parts = append(parts, `"`+stemRussian(strings.ToLower(w))+`"*`)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// Окончания, отбрасываемые перед поиском по префиксу, от длинных к коротким
+var russianEndings = []string{
+	"иями", "ями", "ами", "ией", "ого", "его", "ому", "ему", "ыми", "ими",
+	"ая", "яя", "ое", "ее", "ые", "ие", "ый", "ий", "ой", "ей", "ых", "их", "ом", "ем", "ам", "ям", "ах", "ях", "ов", "ев", "ью",
+	"а", "я", "о", "е", "ы", "и", "у", "ю", "ь", "й",
+}
+
+// stemRussian грубо отбрасывает окончание слова, чтобы "смесители" находили "смеситель".
+// Короткие слова и слова не на кириллице не изменяются.
+func stemRussian(word string) string {
+	runes := []rune(word)
+	if len(runes) < 5 || !unicode.Is(unicode.Cyrillic, runes[0]) {
+		return word
+	}
+	for _, ending := range russianEndings {
+		if !strings.HasSuffix(word, ending) {
+			continue
+		}
+		stem := strings.TrimSuffix(word, ending)
+		if len([]rune(stem)) < 3 {
+			return word
+		}
+		return stem
+	}
+	return word
+}
+
+// fullTextRank возвращает выражение ранжирования bm25 с весами колонок
+func fullTextRank() string {
+	weights := make([]string, len(fullTextColumns))
+	for i, c := range fullTextColumns {
+		weights[i] = fmt.Sprintf("%g", c.weight)
+	}
+	return "bm25(products_fts, " + strings.Join(weights, ", ") + ")"
+}
@@ -0,0 +1,159 @@
+package database
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"SanWarehouse/models"
+
+	"gorm.io/gorm"
+)
+
+// generatorKind - вид товара и подкатегория, в которую он попадает
+type generatorKind struct {
+	name        string
+	subcategory string
+}
+
+// Справочники для синтетического каталога
+var (
+	generatorCategories = []struct {
+		name     string
+		prefix   string
+		kinds    []generatorKind
+		minPrice float64
+		maxPrice float64
+	}{
+		{"Смесители", "MIX", []generatorKind{{"Смеситель для раковины", "Для раковины"}, {"Смеситель для ванны", "Для ванны"}, {"Смеситель для кухни", "Для кухни"}, {"Термостат для душа", "Термостаты"}}, 2500, 45000},
+		{"Унитазы", "TOI", []generatorKind{{"Унитаз-компакт", "Напольные"}, {"Унитаз приставной", "Напольные"}, {"Унитаз подвесной", "Подвесные"}}, 6000, 60000},
+		{"Раковины", "SINK", []generatorKind{{"Раковина накладная", "Накладные"}, {"Раковина встраиваемая", "Встраиваемые"}, {"Раковина с пьедесталом", "С пьедесталом"}}, 3000, 40000},
+		{"Ванны", "BATH", []generatorKind{{"Ванна акриловая", "Акриловые"}, {"Ванна чугунная", "Чугунные"}, {"Ванна стальная", "Стальные"}}, 12000, 150000},
+		{"Душевые", "SHW", []generatorKind{{"Душевая кабина", "Кабины и уголки"}, {"Душевой уголок", "Кабины и уголки"}, {"Душевая система", "Душевые системы"}, {"Лейка для душа", "Лейки и шланги"}}, 1500, 90000},
+		{"Биде", "BID", []generatorKind{{"Биде напольное", ""}, {"Биде подвесное", ""}}, 5000, 35000},
+		{"Полотенцесушители", "TWR", []generatorKind{{"Полотенцесушитель водяной", "Водяные"}, {"Полотенцесушитель электрический", "Электрические"}}, 3500, 30000},
+		{"Аксессуары", "ACC", []generatorKind{{"Набор аксессуаров", "Наборы"}, {"Держатель для бумаги", "Держатели"}, {"Дозатор для мыла", "Дозаторы и мыльницы"}, {"Крючок настенный", "Держатели"}, {"Зеркало", "Зеркала"}}, 300, 12000},
+		{"Инсталляции", "INS", []generatorKind{{"Инсталляция для унитаза", "Системы инсталляции"}, {"Кнопка смыва", "Кнопки смыва"}}, 2000, 40000},
+		{"Сифоны", "SIF", []generatorKind{{"Сифон для раковины", "Сифоны"}, {"Сифон для ванны", "Сифоны"}, {"Трап душевой", "Трапы"}}, 400, 9000},
+	}
+	// generatorBrands - бренды и их серии: товар получает серию своего бренда
+	generatorBrands = []struct {
+		name   string
+		series []string
+	}{
+		{"Grohe", []string{"Eurosmart", "BauEdge", "Essence", "Euphoria"}},
+		{"Hansgrohe", []string{"Logis", "Focus", "Talis", "Croma"}},
+		{"Cersanit", []string{"Nature", "Carina", "Parva", "City"}},
+		{"Roca", []string{"Gap", "Victoria", "Meridian", "Debba"}},
+		{"Jacob Delafon", []string{"Patio", "Odeon Up", "Struktura", "Elevation"}},
+		{"IDDIS", []string{"Alborg", "Ray", "Slide", "Vane"}},
+		{"Laparet", []string{"Classic", "Ares", "Nova"}},
+		{"Vitra", []string{"S50", "Integra", "Metropole"}},
+		{"Ideal Standard", []string{"Connect", "Tesi", "Ceraline"}},
+		{"Am.Pm", []string{"Like", "Spirit", "Gem", "X-Joy"}},
+		{"Santek", []string{"Нео", "Бриз", "Анимо"}},
+		{"Lemark", []string{"Plus", "Status", "Comfort", "Prime"}},
+	}
+	// generatorImportBrands - бренды, которые закупаются в евро
+	generatorImportBrands = map[string]bool{"Grohe": true, "Jacob Delafon": true}
+	generatorMaterials    = []string{"Латунь", "Керамика", "Фаянс", "Акрил", "Чугун", "Сталь", "Нержавеющая сталь", "Стекло", "Пластик"}
+	generatorColors       = []string{"белый", "хром", "черный матовый", "бронза", "золото", "графит"}
+)
+
+// generatorEURRate - курс евро, по которому пересчитываются закупочные цены импортных
+// брендов; совпадает с курсом в fixtures/exchange_rates.json
+const generatorEURRate = 98.5
+
+// GenerateCatalog добавляет count синтетических товаров для проверки производительности
+// на больших каталогах. Артикулы имеют вид GEN-<категория>-<номер> и не пересекаются
+// с ранее сгенерированными. progress вызывается после каждой пачки (может быть nil).
+func GenerateCatalog(count int, seed int64, progress func(done int)) error {
+	const batchSize = 500
+	rng := rand.New(rand.NewSource(seed))
+
+	var start int64
+	DB.Unscoped().Model(&models.Product{}).Where("sku LIKE ?", "GEN-%").Count(&start)
+
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		// Синтетический каталог в журнал аудита не пишется
+		tx = withoutAudit(tx)
+		catalog := newCatalogResolver(tx)
+		batch := make([]models.Product, 0, batchSize)
+		for i := 0; i < count; i++ {
+			p := generateProduct(rng, "GEN-", int(start)+i+1)
+			if err := catalog.resolve(&p); err != nil {
+				return err
+			}
+			batch = append(batch, p)
+			if len(batch) == batchSize || i == count-1 {
+				if err := tx.Create(&batch).Error; err != nil {
+					return err
+				}
+				batch = batch[:0]
+				if progress != nil {
+					progress(i + 1)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Обновляем статистику, иначе планировщик SQLite выбирает индекс по deleted_at
+	// вместо индексов колонок сортировки
+	return DB.Exec("ANALYZE").Error
+}
+
+// generateProduct создает товар случайной категории и бренда с артикулом <skuPrefix><категория>-<n>
+func generateProduct(rng *rand.Rand, skuPrefix string, n int) models.Product {
+	category := generatorCategories[rng.Intn(len(generatorCategories))]
+	brand := generatorBrands[rng.Intn(len(generatorBrands))]
+	series := brand.series[rng.Intn(len(brand.series))]
+	color := generatorColors[rng.Intn(len(generatorColors))]
+	kind := category.kinds[rng.Intn(len(category.kinds))]
+
+	path := category.name
+	if kind.subcategory != "" {
+		path += models.CategoryPathSeparator + kind.subcategory
+	}
+
+	// Цены распределены логнормально: дешевых позиций больше, чем дорогих
+	logMin, logMax := math.Log(category.minPrice), math.Log(category.maxPrice)
+	selling := math.Exp(logMin + rng.Float64()*(logMax-logMin))
+	selling = math.Round(selling/10)*10 - 10
+	purchase := selling * (0.55 + rng.Float64()*0.2)
+	currency := models.BaseCurrency
+	if generatorImportBrands[brand.name] {
+		purchase, currency = purchase/generatorEURRate, models.CurrencyEUR
+	}
+
+	quantity := rng.Intn(60)
+	if rng.Float64() < 0.1 {
+		quantity = 0
+	}
+	reserved := 0
+	if quantity > 0 && rng.Float64() < 0.2 {
+		reserved = rng.Intn(quantity/3 + 1)
+	}
+
+	return models.Product{
+		SKU:              fmt.Sprintf("%s%s-%06d", skuPrefix, category.prefix, n),
+		Name:             fmt.Sprintf("%s %s %s, %s", kind.name, brand.name, series, color),
+		Category:         path,
+		Brand:            brand.name,
+		Description:      fmt.Sprintf("%s серии %s от %s. Цвет: %s.", kind.name, series, brand.name, color),
+		Quantity:         quantity,
+		ReservedQuantity: reserved,
+		PurchasePrice:    models.NewMoney(purchase),
+		PurchaseCurrency: currency,
+		SellingPrice:     models.NewMoney(selling),
+		MinStockLevel:    2 + rng.Intn(8),
+		Location:         fmt.Sprintf("%c-%02d-%02d", 'A'+rune(rng.Intn(8)), 1+rng.Intn(30), 1+rng.Intn(10)),
+		Weight:           math.Round((0.2+rng.Float64()*40)*10) / 10,
+		Material:         generatorMaterials[rng.Intn(len(generatorMaterials))],
+		MarketplaceID:    fmt.Sprintf("OZ%09d", 100000000+n),
+		IsActive:         true,
+	}
+}
@@ -0,0 +1,434 @@
+package database_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"SanWarehouse/config"
+	db "SanWarehouse/database"
+	"SanWarehouse/models"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// pgDSNEnv - переменная окружения со строкой подключения к пустой базе PostgreSQL,
+// например в контейнере:
+//
+//	docker run -d --name warehouse-pg -p 5432:5432 -e POSTGRES_USER=warehouse -e POSTGRES_PASSWORD=secret postgres:16
+//	WAREHOUSE_TEST_PG_DSN="host=localhost user=warehouse password=secret dbname=warehouse sslmode=disable" go test ./database
+const pgDSNEnv = "WAREHOUSE_TEST_PG_DSN"
+
+// TestDatabase проверяет программу на базе каждого драйвера: схему, сохранение и поиск
+// товаров, постраничную загрузку, блокировку версий, журнал изменений и запросы отчетов.
+// SQLite проверяется во временном файле, PostgreSQL - если задана переменная pgDSNEnv.
+func TestDatabase(t *testing.T) {
+	t.Run(config.DriverSQLite, func(t *testing.T) {
+		runDatabaseTests(t, config.Database{
+			Driver: config.DriverSQLite,
+			DSN:    filepath.Join(t.TempDir(), "warehouse.db"),
+		})
+	})
+	t.Run(config.DriverPostgres, func(t *testing.T) {
+		dsn := os.Getenv(pgDSNEnv)
+		if dsn == "" {
+			t.Skipf("не задана строка подключения %s", pgDSNEnv)
+		}
+		runDatabaseTests(t, config.Database{Driver: config.DriverPostgres, DSN: dsn})
+	})
+}
+
+// runDatabaseTests выполняет проверки по порядку: каждая использует записи предыдущих.
+// Артикулы товаров начинаются с отметки времени, чтобы повторный запуск на той же базе
+// PostgreSQL не конфликтовал с прежними записями.
+func runDatabaseTests(t *testing.T, cfg config.Database) {
+	if err := db.InitDB(cfg); err != nil {
+		t.Fatal("Ошибка инициализации БД:", err)
+	}
+	t.Cleanup(func() { db.CloseDB() })
+	t.Logf("База: %s, полнотекстовый поиск: %v", db.Dialect(), db.FullTextEnabled())
+
+	prefix := fmt.Sprintf("DBTEST-%d-", time.Now().UnixNano())
+	var products []models.Product
+	// checked - товары проверки без учета остальных товаров базы
+	checked := func() *gorm.DB {
+		return db.DB.Model(&models.Product{}).Where("sku LIKE ?", prefix+"%")
+	}
+
+	created := t.Run("Создание товаров", func(t *testing.T) {
+		for i, name := range []string{"Смеситель для кухни", "Смеситель для ванны", "Унитаз подвесной", "Раковина накладная", "Ёршик настенный"} {
+			p := models.Product{
+				SKU:           fmt.Sprintf("%s%d", prefix, i+1),
+				Name:          name + " Проверочный",
+				Category:      "Проверка" + models.CategoryPathSeparator + "Сантехника",
+				Brand:         "Проверка",
+				Quantity:      10 * i,
+				PurchasePrice: models.NewMoney(100),
+				SellingPrice:  models.NewMoney(150),
+				MinStockLevel: 5,
+				IsActive:      true,
+			}
+			if err := db.ResolveCatalog(&p); err != nil {
+				t.Fatal(err)
+			}
+			if err := db.DB.Create(&p).Error; err != nil {
+				t.Fatal(err)
+			}
+			products = append(products, p)
+		}
+		if products[0].Status != models.StatusOutOfStock {
+			t.Errorf("статус товара без остатка: %q", products[0].Status)
+		}
+	})
+	if !created {
+		t.FailNow()
+	}
+
+	t.Run("Поиск без учета регистра", func(t *testing.T) {
+		var count int64
+		if err := db.SearchProducts(checked(), "СМЕСИТЕЛЬ").Count(&count).Error; err != nil {
+			t.Fatal(err)
+		}
+		if count != 2 {
+			t.Errorf("найдено %d товаров вместо 2", count)
+		}
+		if err := db.SearchProducts(checked(), "name:ёршик").Count(&count).Error; err != nil {
+			t.Fatal(err)
+		}
+		if count != 1 {
+			t.Errorf("по названию найдено %d товаров вместо 1", count)
+		}
+		// Символы шаблона LIKE в тексте запроса ищутся буквально
+		for _, input := range []string{"с_еситель", "name:с_еситель", "name:%"} {
+			if err := db.SearchProducts(checked(), input).Count(&count).Error; err != nil {
+				t.Fatal(err)
+			}
+			if count != 0 {
+				t.Errorf("по запросу %q найдено %d товаров вместо 0", input, count)
+			}
+		}
+		if err := db.InCategory(checked(), "Провер_а").Count(&count).Error; err != nil {
+			t.Fatal(err)
+		}
+		if count != 0 {
+			t.Errorf("в категории с символом шаблона найдено %d товаров вместо 0", count)
+		}
+		// Цены хранятся в копейках, а в запросе пишутся в рублях
+		if err := db.SearchProducts(checked(), "price>=150 cost<100.01").Count(&count).Error; err != nil {
+			t.Fatal(err)
+		}
+		if count != 5 {
+			t.Errorf("по цене найдено %d товаров вместо 5", count)
+		}
+	})
+
+	t.Run("Поиск по закупочной цене без права просмотра", func(t *testing.T) {
+		db.SetCurrentUser(&models.User{Role: models.RoleStorekeeper})
+		defer db.SetCurrentUser(nil)
+
+		var count int64
+		err := db.SearchProducts(checked(), "cost<100.01").Count(&count).Error
+		var userErr *models.UserError
+		if !errors.As(err, &userErr) {
+			t.Errorf("поиск по закупочной цене: %v", err)
+		}
+		if err := db.SearchProducts(checked(), "price>=150").Count(&count).Error; err != nil {
+			t.Fatal(err)
+		}
+		if count != 5 {
+			t.Errorf("по цене найдено %d товаров вместо 5", count)
+		}
+	})
+
+	t.Run("Постраничная загрузка", func(t *testing.T) {
+		var names []string
+		var cursor *db.PageCursor
+		for {
+			page, err := db.ProductsPage(checked(), "quantity - reserved_quantity", true, cursor, 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, p := range page.Products {
+				names = append(names, p.SKU)
+			}
+			if page.Next == nil {
+				break
+			}
+			cursor = page.Next
+		}
+		if len(names) != len(products) || names[0] != products[len(products)-1].SKU {
+			t.Errorf("неверный порядок товаров: %v", names)
+		}
+	})
+
+	t.Run("Блокировка версий", func(t *testing.T) {
+		var mine, theirs models.Product
+		db.DB.First(&mine, products[1].ID)
+		db.DB.First(&theirs, products[1].ID)
+		theirs.Quantity++
+		if err := db.DB.Save(&theirs).Error; err != nil {
+			t.Fatal(err)
+		}
+		mine.SellingPrice = models.NewMoney(200)
+		if err := db.DB.Save(&mine).Error; !errors.Is(err, db.ErrVersionConflict) {
+			t.Errorf("сохранение устаревшей версии: %v", err)
+		}
+	})
+
+	t.Run("Журнал изменений", func(t *testing.T) {
+		entries, total := db.AuditEntries(db.AuditFilter{Entity: "products", EntityID: products[1].ID}, 10)
+		if total != 2 || len(entries) != 2 {
+			t.Errorf("записей журнала %d вместо 2", total)
+		}
+		_, total = db.AuditEntries(db.AuditFilter{Entity: "products", Search: "смеситель для ВАННЫ"}, 10)
+		if total != 1 {
+			t.Errorf("поиском по журналу найдено %d записей вместо 1", total)
+		}
+		_, total = db.AuditEntries(db.AuditFilter{Entity: "products", Search: "смеситель для _анны"}, 10)
+		if total != 0 {
+			t.Errorf("поиском с символом шаблона найдено %d записей вместо 0", total)
+		}
+	})
+
+	t.Run("Приход по заказу поставщику", func(t *testing.T) {
+		order := models.PurchaseOrder{
+			Number: prefix + "PO",
+			Status: models.OrderPlaced,
+			Lines:  []models.PurchaseOrderLine{{ProductID: products[0].ID, Quantity: 7}},
+		}
+		if err := db.DB.Create(&order).Error; err != nil {
+			t.Fatal(err)
+		}
+		stale := order
+		if err := db.ReceivePurchaseOrder(&order); err != nil {
+			t.Fatal(err)
+		}
+		// Повторное оприходование из окна, открытого до прихода, остатки не меняет
+		if err := db.ReceivePurchaseOrder(&stale); err == nil {
+			t.Error("заказ оприходован повторно")
+		}
+		var p models.Product
+		db.DB.First(&p, products[0].ID)
+		if p.Quantity != 7 || p.Status != models.StatusInStock {
+			t.Errorf("после прихода остаток %d, статус %q", p.Quantity, p.Status)
+		}
+		if stock := db.StockByLocation(&p); len(stock) != 1 || stock[0].Quantity != 7 {
+			t.Errorf("остатки по местам хранения: %+v", stock)
+		}
+	})
+
+	t.Run("Номер заказа поставщику", func(t *testing.T) {
+		order := models.PurchaseOrder{Number: db.NextOrderNumber(db.DB), Status: models.OrderDraft}
+		if err := db.DB.Create(&order).Error; err != nil {
+			t.Fatal(err)
+		}
+		if err := db.DB.Delete(&order).Error; err != nil {
+			t.Fatal(err)
+		}
+		// Номер удаленного заказа не выдается повторно
+		if next := db.NextOrderNumber(db.DB); next == order.Number {
+			t.Errorf("номер %s выдан повторно", next)
+		}
+	})
+
+	t.Run("Запросы отчетов", func(t *testing.T) {
+		var value models.Money
+		var items int64
+		cost := db.PurchaseCostSQL(db.CurrentExchangeRates())
+		if err := checked().Select("coalesce(sum(quantity * " + cost + "), 0)").Scan(&value).Error; err != nil {
+			t.Fatal(err)
+		}
+		if err := checked().Select("coalesce(sum(quantity), 0)").Scan(&items).Error; err != nil {
+			t.Fatal(err)
+		}
+		if items != 108 || value != models.NewMoney(10800) {
+			t.Errorf("остаток %d шт. на %s вместо 108 шт. на 10800.00", items, value)
+		}
+
+		var brands []struct {
+			Brand       string
+			PurchaseSum models.Money
+			SellingSum  models.Money
+		}
+		err := checked().
+			Select("brand, sum(quantity * " + cost + ") as purchase_sum, sum(quantity * selling_price) as selling_sum").
+			Group("brand").
+			Scan(&brands).Error
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(brands) != 1 || brands[0].SellingSum != models.NewMoney(16200) {
+			t.Errorf("итоги по брендам: %+v", brands)
+		}
+
+		var stats struct {
+			Count       int64
+			TotalItems  int64
+			AvgPrice    models.Money
+			BrandsCount int64
+		}
+		err = db.InCategory(checked(), "Проверка").
+			Select("count(*) as count, coalesce(sum(quantity), 0) as total_items, coalesce(avg(selling_price), 0) as avg_price, count(distinct brand) as brands_count").
+			Scan(&stats).Error
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stats.Count != 5 || stats.TotalItems != 108 || stats.AvgPrice != models.NewMoney(150) || stats.BrandsCount != 1 {
+			t.Errorf("итоги по категории: %+v", stats)
+		}
+	})
+
+	t.Run("Удаление и восстановление товаров", func(t *testing.T) {
+		ids := []uint{products[4].ID}
+		if err := db.DeleteProducts(ids); err != nil {
+			t.Fatal(err)
+		}
+		var count int64
+		checked().Count(&count)
+		if count != 4 {
+			t.Errorf("после удаления осталось %d товаров вместо 4", count)
+		}
+		if err := db.RestoreProducts(ids); err != nil {
+			t.Fatal(err)
+		}
+		checked().Count(&count)
+		if count != 5 {
+			t.Errorf("после восстановления %d товаров вместо 5", count)
+		}
+	})
+
+	t.Run("Курсы валют и пересчет цен", func(t *testing.T) {
+		// Курсы на давнюю дату, чтобы не зависеть от курсов, заданных в базе
+		count, err := db.ImportExchangeRates(strings.NewReader("Дата;Валюта;Курс;Номинал\n" +
+			"03.01.2000;eur;98,50\n" +
+			"2000-01-03;KZT;17,95;100\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rates := db.ExchangeRatesOn(time.Date(2000, 1, 5, 0, 0, 0, 0, time.UTC))
+		if count != 2 || rates[models.CurrencyEUR] != 98.5 || fmt.Sprintf("%.4f", rates["KZT"]) != "0.1795" {
+			t.Errorf("загружено курсов %d, курсы на 05.01.2000: %v", count, rates)
+		}
+
+		p := models.Product{
+			SKU:              prefix + "EUR",
+			Name:             "Смеситель импортный Проверочный",
+			Quantity:         3,
+			PurchasePrice:    models.NewMoney(45.5),
+			PurchaseCurrency: models.CurrencyEUR,
+			SellingPrice:     models.NewMoney(6990),
+			IsActive:         true,
+		}
+		if err := db.DB.Create(&p).Error; err != nil {
+			t.Fatal(err)
+		}
+		var stored models.Product
+		db.DB.First(&stored, p.ID)
+		if stored.PurchasePrice != p.PurchasePrice || stored.PurchaseCurrency != models.CurrencyEUR {
+			t.Errorf("закупочная цена сохранена как %s %s", stored.PurchasePrice, stored.PurchaseCurrency)
+		}
+
+		var cost models.Money
+		err = db.DB.Model(&models.Product{}).Where("id = ?", p.ID).
+			Select("sum(quantity * " + db.PurchaseCostSQL(rates) + ")").
+			Scan(&cost).Error
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cost != models.NewMoney(13445.25) {
+			t.Errorf("себестоимость %s вместо 13445.25", cost)
+		}
+		missing := db.CurrenciesWithoutRate(db.DB.Model(&models.Product{}).Where("id = ?", p.ID), models.ExchangeRates{})
+		if len(missing) != 1 || missing[0] != models.CurrencyEUR {
+			t.Errorf("валюты без курса: %v", missing)
+		}
+	})
+
+	t.Run("Резервное копирование", func(t *testing.T) {
+		if !db.BackupSupported() {
+			t.Skip("не поддерживается драйвером")
+		}
+		attachmentsDir := db.AttachmentsDir
+		db.AttachmentsDir = filepath.Join(t.TempDir(), "attachments")
+		defer func() { db.AttachmentsDir = attachmentsDir }()
+		writeFile := func(name string) {
+			path := filepath.Join(db.AttachmentsDir, name)
+			if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte(name), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		exists := func(name string) bool {
+			_, err := os.Stat(filepath.Join(db.AttachmentsDir, name))
+			return err == nil
+		}
+
+		dir := t.TempDir()
+		writeFile("1/1.png")
+		backup, err := db.CreateBackup(dir, db.BackupManual)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := db.CheckBackup(backup.Path); err != nil {
+			t.Error(err)
+		}
+		if !db.BackupHasFiles(backup.Path) {
+			t.Fatal("файлы товаров не сохранены вместе с копией")
+		}
+
+		// Восстановление возвращает и файлы товаров на момент копии
+		writeFile("1/2.png")
+		os.Remove(filepath.Join(db.AttachmentsDir, "1", "1.png"))
+		if _, err := db.RestoreBackup(backup.Path, dir); err != nil {
+			t.Fatal(err)
+		}
+		if !exists("1/1.png") || exists("1/2.png") {
+			t.Error("файлы товаров не восстановлены из копии")
+		}
+	})
+}
+
+// TestMoneyMigration проверяет перевод цен, которые прежние версии хранили дробными
+// числами, в копейки: один раз, при первом открытии базы
+func TestMoneyMigration(t *testing.T) {
+	cfg := config.Database{Driver: config.DriverSQLite, DSN: filepath.Join(t.TempDir(), "warehouse.db")}
+	legacy, err := gorm.Open(sqlite.Open(cfg.DSN), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = legacy.Exec("CREATE TABLE products (id integer PRIMARY KEY AUTOINCREMENT, sku text, name text, " +
+		"quantity integer, purchase_price real, selling_price real)").Error
+	if err == nil {
+		err = legacy.Exec("INSERT INTO products (sku, name, quantity, purchase_price, selling_price) " +
+			"VALUES ('OLD-1', 'Смеситель', 2, 100.5, 150.25)").Error
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sqlDB, err := legacy.DB(); err == nil {
+		sqlDB.Close()
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := db.InitDB(cfg); err != nil {
+			t.Fatal("Ошибка инициализации БД:", err)
+		}
+		var p models.Product
+		if err := db.DB.Where("sku = ?", "OLD-1").First(&p).Error; err != nil {
+			t.Fatal(err)
+		}
+		var value models.Money
+		db.DB.Model(&models.Product{}).Select("sum(quantity * selling_price)").Scan(&value)
+		db.CloseDB()
+		if p.PurchasePrice != models.NewMoney(100.5) || p.SellingPrice != models.NewMoney(150.25) || value != models.NewMoney(300.5) {
+			t.Fatalf("открытие %d: цены %s и %s, стоимость %s", i+1, p.PurchasePrice, p.SellingPrice, value)
+		}
+	}
+}
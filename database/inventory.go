@@ -0,0 +1,298 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"SanWarehouse/models"
+
+	"gorm.io/gorm"
+)
+
+// OpenOrderQuantities возвращает количество каждого товара в открытых заказах поставщикам
+func OpenOrderQuantities() map[uint]int {
+	type row struct {
+		ProductID uint
+		Quantity  int
+	}
+	var rows []row
+
+	DB.Table("purchase_order_lines AS l").
+		Select("l.product_id, sum(l.quantity) AS quantity").
+		Joins("JOIN purchase_orders o ON o.id = l.purchase_order_id").
+		Where("o.status IN ? AND o.deleted_at IS NULL", models.OpenOrderStatuses).
+		Group("l.product_id").
+		Scan(&rows)
+
+	result := make(map[uint]int, len(rows))
+	for _, r := range rows {
+		result[r.ProductID] = r.Quantity
+	}
+	return result
+}
+
+// ShipmentTotals возвращает количество отгруженных единиц каждого товара начиная с since
+func ShipmentTotals(since time.Time) map[uint]int {
+	type row struct {
+		ProductID uint
+		Quantity  int
+	}
+	var rows []row
+
+	DB.Model(&models.StockMovement{}).
+		Select("product_id, -sum(quantity) AS quantity").
+		Where("type = ? AND created_at >= ?", models.MovementShipment, since).
+		Group("product_id").
+		Scan(&rows)
+
+	result := make(map[uint]int, len(rows))
+	for _, r := range rows {
+		result[r.ProductID] = r.Quantity
+	}
+	return result
+}
+
+// NextOrderNumber генерирует номер нового заказа поставщику по наибольшему ID заказа,
+// в том числе удаленного, поэтому номера не повторяются после удаления заказов. Если другое
+// рабочее место одновременно создаст заказ с тем же номером, сохранение одного из них
+// отклонит уникальный индекс на номере.
+func NextOrderNumber(tx *gorm.DB) string {
+	var last uint
+	tx.Unscoped().Model(&models.PurchaseOrder{}).Select("coalesce(max(id), 0)").Scan(&last)
+	return fmt.Sprintf("PO-%s-%04d", time.Now().Format("20060102"), last+1)
+}
+
+// ReceivePurchaseOrder оприходует заказ: увеличивает остатки и записывает движения товара.
+// Заказ закрывается условным изменением статуса до изменения остатков, поэтому заказ,
+// который уже оприходовали или отменили в другом окне, повторно не оприходуется.
+func ReceivePurchaseOrder(order *models.PurchaseOrder) error {
+	if err := requirePermission(models.PermAdjustStock); err != nil {
+		return err
+	}
+
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.PurchaseOrder{}).
+			Where("id = ? AND status IN ? AND version = ?", order.ID, models.OpenOrderStatuses, order.Version).
+			Update("status", models.OrderReceived)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			var current models.PurchaseOrder
+			if err := tx.First(&current, order.ID).Error; err != nil {
+				return err
+			}
+			if !current.IsOpen() {
+				return models.Errorf("заказ %s уже закрыт", order.Number)
+			}
+			return models.Errorf("%s: %w", order.AuditLabel(), ErrVersionConflict)
+		}
+
+		var lines []models.PurchaseOrderLine
+		if err := tx.Where("purchase_order_id = ?", order.ID).Find(&lines).Error; err != nil {
+			return err
+		}
+		for _, line := range lines {
+			var product models.Product
+			if err := tx.First(&product, line.ProductID).Error; err != nil {
+				return err
+			}
+			product.Quantity += line.Quantity
+			if err := tx.Save(&product).Error; err != nil {
+				return err
+			}
+
+			movement := models.StockMovement{
+				ProductID: product.ID,
+				Type:      models.MovementReceipt,
+				Quantity:  line.Quantity,
+				Location:  product.Location,
+				Reference: order.Number,
+			}
+			if err := tx.Create(&movement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	order.Status = models.OrderReceived
+	order.Version++
+	return nil
+}
+
+// FindOrCreateSupplier возвращает ID поставщика с указанным названием, создавая его при необходимости.
+// Для пустого названия возвращает nil.
+func FindOrCreateSupplier(name string) (*uint, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, nil
+	}
+
+	supplier := models.Supplier{Name: name}
+	if err := DB.Where(models.Supplier{Name: name}).FirstOrCreate(&supplier).Error; err != nil {
+		return nil, err
+	}
+	return &supplier.ID, nil
+}
+
+// SupplierNames возвращает названия всех поставщиков
+func SupplierNames() []string {
+	var names []string
+	DB.Model(&models.Supplier{}).Order("name").Pluck("name", &names)
+	return names
+}
+
+// AdjustStock изменяет остаток товара на delta и записывает движение товара.
+// Отрицательный delta не может сделать остаток меньше нуля.
+func AdjustStock(productID uint, movementType models.MovementType, delta int, reference, comment string) error {
+	if err := requirePermission(models.PermAdjustStock); err != nil {
+		return err
+	}
+
+	return DB.Transaction(func(tx *gorm.DB) error {
+		var product models.Product
+		if err := tx.First(&product, productID).Error; err != nil {
+			return err
+		}
+		if product.Quantity+delta < 0 {
+			return models.Errorf("недостаточно товара %s: на складе %d шт.", product.SKU, product.Quantity)
+		}
+
+		product.Quantity += delta
+		if err := tx.Save(&product).Error; err != nil {
+			return err
+		}
+
+		movement := models.StockMovement{
+			ProductID: product.ID,
+			Type:      movementType,
+			Quantity:  delta,
+			Location:  product.Location,
+			Reference: reference,
+			Comment:   comment,
+		}
+		return tx.Create(&movement).Error
+	})
+}
+
+// ReserveStock резервирует товар под заказ клиента и увеличивает зарезервированный остаток
+func ReserveStock(reservation *models.Reservation) error {
+	if err := requirePermission(models.PermAdjustStock); err != nil {
+		return err
+	}
+
+	if reservation.Quantity <= 0 {
+		return models.Errorf("количество резерва должно быть больше нуля")
+	}
+
+	return DB.Transaction(func(tx *gorm.DB) error {
+		var product models.Product
+		if err := tx.First(&product, reservation.ProductID).Error; err != nil {
+			return err
+		}
+		if available := product.AvailableQuantity(); reservation.Quantity > available {
+			return models.Errorf("недостаточно товара %s для резерва: доступно %d шт.", product.SKU, available)
+		}
+
+		product.ReservedQuantity += reservation.Quantity
+		if err := tx.Save(&product).Error; err != nil {
+			return err
+		}
+
+		reservation.Status = models.ReservationActive
+		return tx.Create(reservation).Error
+	})
+}
+
+// ReleaseReservation снимает активный резерв и возвращает товар в доступный остаток
+func ReleaseReservation(id uint) error {
+	if err := requirePermission(models.PermAdjustStock); err != nil {
+		return err
+	}
+
+	return DB.Transaction(func(tx *gorm.DB) error {
+		var reservation models.Reservation
+		if err := tx.First(&reservation, id).Error; err != nil {
+			return err
+		}
+		if !reservation.IsActive() {
+			return models.Errorf("резерв уже снят")
+		}
+
+		var product models.Product
+		if err := tx.First(&product, reservation.ProductID).Error; err != nil {
+			return err
+		}
+		product.ReservedQuantity -= reservation.Quantity
+		if product.ReservedQuantity < 0 {
+			product.ReservedQuantity = 0
+		}
+		if err := tx.Save(&product).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&reservation).Update("status", models.ReservationReleased).Error
+	})
+}
+
+// LocationStock - остаток товара в одном месте хранения
+type LocationStock struct {
+	Location string
+	Quantity int
+}
+
+// StockByLocation распределяет остаток товара по местам хранения на основе движений.
+// Остаток, не подтвержденный движениями (например, введенный вручную), относится
+// к основному месту хранения товара.
+func StockByLocation(product *models.Product) []LocationStock {
+	var rows []LocationStock
+	DB.Model(&models.StockMovement{}).
+		Select("location, sum(quantity) AS quantity").
+		Where("product_id = ?", product.ID).
+		Group("location").
+		Order("location").
+		Scan(&rows)
+
+	tracked := 0
+	main := -1
+	for i, r := range rows {
+		tracked += r.Quantity
+		if r.Location == product.Location {
+			main = i
+		}
+	}
+	if untracked := product.Quantity - tracked; untracked != 0 {
+		if main < 0 {
+			rows = append([]LocationStock{{Location: product.Location}}, rows...)
+			main = 0
+		}
+		rows[main].Quantity += untracked
+	}
+
+	// Места, где товара не осталось, не показываем
+	result := rows[:0]
+	for _, r := range rows {
+		if r.Quantity != 0 {
+			result = append(result, r)
+		}
+	}
+	return result
+}
+
+// OpenOrdersForProduct возвращает открытые заказы поставщикам, в которых есть товар.
+// В Lines загружаются только строки этого товара.
+func OpenOrdersForProduct(productID uint) []models.PurchaseOrder {
+	var orders []models.PurchaseOrder
+	DB.Preload("Supplier").
+		Preload("Lines", "product_id = ?", productID).
+		Where("status IN ?", models.OpenOrderStatuses).
+		Where("id IN (?)", DB.Model(&models.PurchaseOrderLine{}).Select("purchase_order_id").Where("product_id = ?", productID)).
+		Order("created_at").
+		Find(&orders)
+	return orders
+}
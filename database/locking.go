@@ -0,0 +1,226 @@
+package database
+
+import (
+	"errors"
+	"reflect"
+
+	"SanWarehouse/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	// versionColumn - колонка номера версии записи для оптимистической блокировки
+	versionColumn = "version"
+	// versionLoadedKey - номер версии, с которым запись была прочитана
+	versionLoadedKey = "version:loaded"
+)
+
+// ErrVersionConflict возвращается при сохранении записи, которую после чтения изменил
+// или удалил кто-то другой: другой пользователь той же базы или другое окно программы
+var ErrVersionConflict = models.Errorf("запись изменена другим пользователем")
+
+// registerVersionCallbacks подключает оптимистическую блокировку к изменению сущностей
+// с полем Version. Каждое изменение увеличивает номер версии. Изменение конкретной
+// записи (Save, Model(&запись).Update) выполняется, только если номер версии в базе
+// совпадает с прочитанным, иначе операция завершается с ErrVersionConflict.
+func registerVersionCallbacks(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Update().After("gorm:before_update").Before("gorm:update").
+		Register("version:before_update", versionCheck); err != nil {
+		return err
+	}
+	return cb.Update().After("gorm:update").Before("gorm:after_update").
+		Register("version:after_update", versionConfirm)
+}
+
+// versionField возвращает поле номера версии, если сущность его поддерживает
+func versionField(db *gorm.DB) *schema.Field {
+	stmt := db.Statement
+	if db.Error != nil || stmt.Schema == nil {
+		return nil
+	}
+	f := stmt.Schema.LookUpField(versionColumn)
+	if f == nil || f.FieldType.Kind() != reflect.Uint {
+		return nil
+	}
+	return f
+}
+
+// versionedRecord сообщает, изменяется ли одна прочитанная ранее запись (а не набор записей по условию)
+func versionedRecord(stmt *gorm.Statement) bool {
+	if stmt.Schema.PrioritizedPrimaryField == nil || stmt.ReflectValue.Kind() != reflect.Struct || !stmt.ReflectValue.CanAddr() {
+		return false
+	}
+	_, zero := stmt.Schema.PrioritizedPrimaryField.ValueOf(stmt.Context, stmt.ReflectValue)
+	return !zero
+}
+
+// versionCheck добавляет к изменению условие на прочитанный номер версии и увеличивает его
+func versionCheck(db *gorm.DB) {
+	f := versionField(db)
+	if f == nil {
+		return
+	}
+	stmt := db.Statement
+
+	switch dest := stmt.Dest.(type) {
+	case map[string]interface{}:
+		// Update/UpdateColumns: номер версии увеличивает сама база
+		if _, ok := dest[versionColumn]; !ok {
+			dest[versionColumn] = gorm.Expr(stmt.Quote(versionColumn) + " + 1")
+		}
+	default:
+		// Save и Updates той же записи: в базу пишется номер версии из структуры
+		if stmt.Dest != stmt.Model {
+			return
+		}
+	}
+	if !versionedRecord(stmt) {
+		return
+	}
+
+	value, _ := f.ValueOf(stmt.Context, stmt.ReflectValue)
+	loaded := value.(uint)
+	db.InstanceSet(versionLoadedKey, loaded)
+	stmt.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: versionColumn}, Value: loaded},
+	}})
+	if _, ok := stmt.Dest.(map[string]interface{}); !ok {
+		db.AddError(f.Set(stmt.Context, stmt.ReflectValue, loaded+1))
+	}
+}
+
+// versionConfirm проверяет, что запись была изменена, и обновляет номер версии в структуре.
+// Если запись не найдена с прочитанным номером версии, возвращается ErrVersionConflict,
+// а структура сохраняет прежний номер, чтобы операцию можно было повторить.
+func versionConfirm(db *gorm.DB) {
+	value, ok := db.InstanceGet(versionLoadedKey)
+	if !ok {
+		return
+	}
+	loaded := value.(uint)
+	stmt := db.Statement
+	f := stmt.Schema.LookUpField(versionColumn)
+
+	if db.Error == nil && db.RowsAffected == 0 && !db.DryRun {
+		err := ErrVersionConflict
+		if record, ok := stmt.ReflectValue.Addr().Interface().(models.Auditable); ok {
+			err = models.Errorf("%s: %w", record.AuditLabel(), ErrVersionConflict)
+		}
+		db.AddError(err)
+	}
+	if db.Error != nil {
+		f.Set(stmt.Context, stmt.ReflectValue, loaded)
+		return
+	}
+	f.Set(stmt.Context, stmt.ReflectValue, loaded+1)
+}
+
+// ConflictField - поле товара, значение которого в форме отличается от сохраненного в базе
+type ConflictField struct {
+	// Field - имя колонки, как в журнале аудита
+	Field string
+	// Base - значение при открытии формы, Mine - в форме, Theirs - текущее в базе
+	Base, Mine, Theirs string
+	// Conflict - поле изменено и в форме, и другим пользователем
+	Conflict bool
+}
+
+// ProductConflict - товар, который изменили после открытия формы редактирования
+type ProductConflict struct {
+	// Base - товар при открытии формы, Mine - сохраняемый, Theirs - текущий в базе
+	Base, Mine, Theirs models.Product
+	// Fields - различающиеся поля, сначала конфликтующие
+	Fields []ConflictField
+}
+
+// LoadProductConflict читает текущую версию товара и сравнивает ее с сохраняемой
+func LoadProductConflict(base, mine models.Product) (*ProductConflict, error) {
+	fields, err := revisionFields()
+	if err != nil {
+		return nil, err
+	}
+
+	c := &ProductConflict{Base: base, Mine: mine}
+	if err := DB.First(&c.Theirs, mine.ID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.Errorf("товар %s удален другим пользователем", mine.SKU)
+		}
+		return nil, err
+	}
+
+	ctx := DB.Statement.Context
+	baseValue := reflect.ValueOf(&c.Base).Elem()
+	mineValue := reflect.ValueOf(&c.Mine).Elem()
+	theirsValue := reflect.ValueOf(&c.Theirs).Elem()
+	var conflicts, others []ConflictField
+	for _, f := range fields {
+		b, _ := f.ValueOf(ctx, baseValue)
+		m, _ := f.ValueOf(ctx, mineValue)
+		t, _ := f.ValueOf(ctx, theirsValue)
+		if reflect.DeepEqual(m, t) {
+			continue
+		}
+		field := ConflictField{
+			Field:    f.DBName,
+			Base:     formatAuditValue(b),
+			Mine:     formatAuditValue(m),
+			Theirs:   formatAuditValue(t),
+			Conflict: !reflect.DeepEqual(m, b) && !reflect.DeepEqual(t, b),
+		}
+		if field.Conflict {
+			conflicts = append(conflicts, field)
+		} else {
+			others = append(others, field)
+		}
+	}
+	c.Fields = append(conflicts, others...)
+	return c, nil
+}
+
+// Merged возвращает текущую версию товара с изменениями из формы: поля, измененные
+// только другим пользователем, сохраняются, в конфликтующих полях остается значение из формы
+func (c *ProductConflict) Merged() (*models.Product, error) {
+	return c.apply(true)
+}
+
+// Overwritten возвращает товар из формы поверх текущей версии. Поля, которые текущий
+// пользователь не может изменять, остаются такими, как в базе.
+func (c *ProductConflict) Overwritten() (*models.Product, error) {
+	return c.apply(false)
+}
+
+// apply переносит поля из формы в копию текущей версии товара; onlyChanged - только
+// поля, измененные в форме
+func (c *ProductConflict) apply(onlyChanged bool) (*models.Product, error) {
+	fields, err := revisionFields()
+	if err != nil {
+		return nil, err
+	}
+
+	result := c.Theirs
+	ctx := DB.Statement.Context
+	baseValue := reflect.ValueOf(&c.Base).Elem()
+	mineValue := reflect.ValueOf(&c.Mine).Elem()
+	target := reflect.ValueOf(&result).Elem()
+	for _, f := range fields {
+		if p, ok := revisionPermissions[f.DBName]; ok && !Can(p) {
+			continue
+		}
+		// Скрытую закупочную цену и ее валюту форма не показывает, значит, и не изменяет
+		if (f.DBName == "purchase_price" || f.DBName == "purchase_currency") && !Can(models.PermViewPurchasePrices) {
+			continue
+		}
+		m, _ := f.ValueOf(ctx, mineValue)
+		if b, _ := f.ValueOf(ctx, baseValue); onlyChanged && reflect.DeepEqual(m, b) {
+			continue
+		}
+		if err := f.Set(ctx, target, m); err != nil {
+			return nil, err
+		}
+	}
+	return &result, nil
+}
@@ -0,0 +1,67 @@
+package database
+
+import (
+	"reflect"
+	"strings"
+
+	"SanWarehouse/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// moneyType - тип полей с денежными суммами
+var moneyType = reflect.TypeOf(models.Money(0))
+
+// migrateMoney переводит денежные колонки таблиц values, созданные прежними версиями
+// дробными числами (REAL в SQLite, numeric в PostgreSQL), в целые копейки. Значения
+// пересчитываются вместе со сменой типа в одной транзакции, поэтому колонка с целым
+// типом уже переведена и повторно не пересчитывается.
+func migrateMoney(db *gorm.DB, values ...interface{}) error {
+	for _, value := range values {
+		if !db.Migrator().HasTable(value) {
+			continue
+		}
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(value); err != nil {
+			return err
+		}
+		columnTypes, err := db.Migrator().ColumnTypes(value)
+		if err != nil {
+			return err
+		}
+
+		var fields []*schema.Field
+		for _, ct := range columnTypes {
+			field := stmt.Schema.LookUpField(ct.Name())
+			if field == nil || field.FieldType != moneyType {
+				continue
+			}
+			if strings.Contains(strings.ToLower(ct.DatabaseTypeName()), "int") {
+				continue
+			}
+			fields = append(fields, field)
+		}
+		if len(fields) == 0 {
+			continue
+		}
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			for _, field := range fields {
+				column := clause.Column{Name: field.DBName}
+				if err := tx.Exec("UPDATE ? SET ? = round(? * 100)", clause.Table{Name: stmt.Table}, column, column).Error; err != nil {
+					return err
+				}
+				if err := tx.Migrator().AlterColumn(value, field.Name); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return models.Errorf("перевод сумм %s в копейки: %w", stmt.Table, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package database
+
+import (
+	"SanWarehouse/models"
+
+	"gorm.io/gorm"
+)
+
+// PageCursor - позиция в выборке при постраничной загрузке по ключу (keyset pagination):
+// значение выражения сортировки и ID последней загруженной строки
+type PageCursor struct {
+	Key interface{}
+	ID  uint
+}
+
+// ProductPage - страница товаров
+type ProductPage struct {
+	Products []models.Product
+	// Next - курсор для следующей страницы, nil если это последняя страница
+	Next *PageCursor
+}
+
+// ProductsPage загружает до limit товаров из query, отсортированных по orderExpr и ID,
+// начиная после after (nil - с начала). В отличие от OFFSET, время выборки не растет
+// с номером страницы.
+func ProductsPage(query *gorm.DB, orderExpr string, desc bool, after *PageCursor, limit int) (ProductPage, error) {
+	compare, direction := ">", ""
+	if desc {
+		compare, direction = "<", " DESC"
+	}
+
+	page := query.Session(&gorm.Session{})
+	if after != nil {
+		page = page.Where("("+orderExpr+", products.id) "+compare+" (?, ?)", after.Key, after.ID)
+	}
+
+	var result ProductPage
+	err := page.
+		Order(orderExpr + direction).
+		Order("products.id" + direction).
+		Limit(limit + 1).
+		Find(&result.Products).Error
+	if err != nil {
+		return result, err
+	}
+
+	if len(result.Products) <= limit {
+		return result, nil
+	}
+	result.Products = result.Products[:limit]
+
+	// Значение выражения сортировки у последней строки берем из БД,
+	// так как выражение может быть вычисляемым (например, доступный остаток)
+	last := result.Products[limit-1]
+	cursor := &PageCursor{ID: last.ID}
+	err = query.Session(&gorm.Session{}).
+		Select(orderExpr).
+		Where("products.id = ?", last.ID).
+		Row().
+		Scan(&cursor.Key)
+	if err != nil {
+		return result, err
+	}
+	// Текст может прийти как []byte, а в SQLite BLOB сравнивается иначе, чем TEXT
+	if b, ok := cursor.Key.([]byte); ok {
+		cursor.Key = string(b)
+	}
+	result.Next = cursor
+	return result, nil
+}
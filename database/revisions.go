@@ -0,0 +1,127 @@
+package database
+
+import (
+	"reflect"
+
+	"SanWarehouse/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// ProductRevision - состояние товара до и после действия пользователя.
+// По ревизиям действие отменяется и повторяется.
+type ProductRevision struct {
+	Before models.Product
+	After  models.Product
+}
+
+// Reversed возвращает ревизию обратного действия
+func (r ProductRevision) Reversed() ProductRevision {
+	return ProductRevision{Before: r.After, After: r.Before}
+}
+
+// revisionPermissions - права, нужные для переноса отдельных полей
+var revisionPermissions = map[string]models.Permission{
+	"purchase_price":    models.PermEditPrices,
+	"purchase_currency": models.PermEditPrices,
+	"selling_price":     models.PermEditPrices,
+	"quantity":          models.PermAdjustStock,
+	"reserved_quantity": models.PermAdjustStock,
+}
+
+// revisionFields возвращает поля товара, переносимые ревизией: те же, что пишутся в журнал
+// аудита, кроме статуса, который пересчитывается при сохранении
+func revisionFields() ([]*schema.Field, error) {
+	stmt := &gorm.Statement{DB: DB}
+	if err := stmt.Parse(&models.Product{}); err != nil {
+		return nil, err
+	}
+	var fields []*schema.Field
+	for _, f := range auditFields(stmt.Schema) {
+		if f.DBName != "status" {
+			fields = append(fields, f)
+		}
+	}
+	return fields, nil
+}
+
+// ApplyProductRevisions переводит товары из состояния Before в состояние After в одной транзакции.
+// Изменяются только поля, различающиеся в ревизии, поэтому более поздние изменения других полей
+// (например, движение остатков) сохраняются. Если такое поле после действия успели изменить,
+// возвращается ошибка и ни один товар не изменяется.
+func ApplyProductRevisions(revisions []ProductRevision) error {
+	if err := requirePermission(models.PermEditProducts); err != nil {
+		return err
+	}
+	fields, err := revisionFields()
+	if err != nil {
+		return err
+	}
+
+	return DB.Transaction(func(tx *gorm.DB) error {
+		for _, r := range revisions {
+			var current models.Product
+			if err := tx.First(&current, r.After.ID).Error; err != nil {
+				return models.Errorf("%s: %w", r.After.SKU, err)
+			}
+
+			before := reflect.ValueOf(&r.Before).Elem()
+			after := reflect.ValueOf(&r.After).Elem()
+			target := reflect.ValueOf(&current).Elem()
+			changed := false
+			for _, f := range fields {
+				from, _ := f.ValueOf(tx.Statement.Context, before)
+				to, _ := f.ValueOf(tx.Statement.Context, after)
+				if reflect.DeepEqual(from, to) {
+					continue
+				}
+				if p, ok := revisionPermissions[f.DBName]; ok {
+					if err := requirePermission(p); err != nil {
+						return err
+					}
+				}
+				if now, _ := f.ValueOf(tx.Statement.Context, target); !reflect.DeepEqual(now, from) {
+					return models.Errorf("товар %s изменен после этого действия", current.SKU)
+				}
+				if err := f.Set(tx.Statement.Context, target, to); err != nil {
+					return err
+				}
+				changed = true
+			}
+
+			if changed {
+				if err := tx.Save(&current).Error; err != nil {
+					return models.Errorf("%s: %w", current.SKU, err)
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteProducts помечает товары удаленными
+func DeleteProducts(ids []uint) error {
+	if err := requirePermission(models.PermDeleteProducts); err != nil {
+		return err
+	}
+	return DB.Transaction(func(tx *gorm.DB) error {
+		return inBatches(ids, func(batch []uint) error {
+			return tx.Delete(&models.Product{}, batch).Error
+		})
+	})
+}
+
+// RestoreProducts возвращает товары, помеченные удаленными
+func RestoreProducts(ids []uint) error {
+	if err := requirePermission(models.PermDeleteProducts); err != nil {
+		return err
+	}
+	return DB.Transaction(func(tx *gorm.DB) error {
+		return inBatches(ids, func(batch []uint) error {
+			return tx.Unscoped().Model(&models.Product{}).
+				Where("id IN ? AND deleted_at IS NOT NULL", batch).
+				UpdateColumn("deleted_at", nil).Error
+		})
+	})
+}
@@ -0,0 +1,258 @@
+package database
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"SanWarehouse/models"
+
+	"gorm.io/gorm"
+)
+
+// Текстовые колонки, по которым ищутся слова без квалификатора
+var searchColumns = []string{"sku", "name", "category", "brand", "description", "material", "location", "marketplace_id"}
+
+// Квалификаторы текстовых полей: brand:Grohe
+var searchTextFields = map[string]string{
+	"sku":         "sku",
+	"name":        "name",
+	"category":    "category",
+	"cat":         "category",
+	"brand":       "brand",
+	"desc":        "description",
+	"description": "description",
+	"material":    "material",
+	"location":    "location",
+	"loc":         "location",
+	"marketplace": "marketplace_id",
+	"mp":          "marketplace_id",
+}
+
+// Квалификаторы числовых полей: qty<5, price>=1000
+var searchNumberFields = map[string]string{
+	"qty":       "quantity",
+	"quantity":  "quantity",
+	"avail":     "quantity - reserved_quantity",
+	"available": "quantity - reserved_quantity",
+	"reserved":  "reserved_quantity",
+	"price":     "selling_price",
+	"cost":      "purchase_price",
+	"min":       "min_stock_level",
+}
+
+// searchMoneyColumns - денежные колонки: хранятся в копейках, а в запросе пишутся в рублях
+var searchMoneyColumns = map[string]bool{
+	"selling_price":  true,
+	"purchase_price": true,
+}
+
+// Сокращения статусов для status:low
+var searchStatuses = map[string]models.ProductStatus{
+	"in":      models.StatusInStock,
+	"instock": models.StatusInStock,
+	"low":     models.StatusLowStock,
+	"out":     models.StatusOutOfStock,
+	"order":   models.StatusOnOrder,
+}
+
+// Операторы сравнения; при совпадении позиции двухсимвольные важнее односимвольных
+var searchOperators = []string{"<=", ">=", "<>", "!=", "<", ">", "=", ":"}
+
+// SearchCondition - одно условие поискового запроса
+type SearchCondition struct {
+	// Field - колонка или выражение; пустое для поиска по всем текстовым колонкам
+	Field    string
+	Operator string
+	Value    string
+	Number   float64
+	Numeric  bool
+}
+
+// SearchQuery - разобранная строка поиска
+type SearchQuery struct {
+	Conditions []SearchCondition
+}
+
+// ParseSearchQuery разбирает строку поиска вида `brand:Grohe qty<5 status:low "белый унитаз"`.
+// Неизвестные квалификаторы и некорректные числа ищутся как обычный текст.
+func ParseSearchQuery(input string) SearchQuery {
+	var q SearchQuery
+	for _, token := range splitSearchTokens(input) {
+		q.Conditions = append(q.Conditions, parseSearchToken(token))
+	}
+	return q
+}
+
+// splitSearchTokens делит строку по пробелам с учетом кавычек
+func splitSearchTokens(input string) []string {
+	var tokens []string
+	var current strings.Builder
+	quoted := false
+	for _, r := range input {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case unicode.IsSpace(r) && !quoted:
+			if current.Len() > 0 {
+				tokens = append(tokens, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if current.Len() > 0 {
+		tokens = append(tokens, current.String())
+	}
+	return tokens
+}
+
+func parseSearchToken(token string) SearchCondition {
+	text := SearchCondition{Value: token}
+
+	// Ключ отделяется от значения первым по положению оператором
+	pos, op := -1, ""
+	for _, candidate := range searchOperators {
+		i := strings.Index(token, candidate)
+		if i > 0 && (pos < 0 || i < pos) {
+			pos, op = i, candidate
+		}
+	}
+	if pos < 0 {
+		return text
+	}
+
+	key := strings.ToLower(token[:pos])
+	value := token[pos+len(op):]
+	if value == "" {
+		return text
+	}
+
+	if column, ok := searchNumberFields[key]; ok {
+		number, err := strconv.ParseFloat(strings.ReplaceAll(value, ",", "."), 64)
+		if err != nil {
+			return text
+		}
+		switch op {
+		case ":":
+			op = "="
+		case "!=":
+			op = "<>"
+		}
+		return SearchCondition{Field: column, Operator: op, Value: value, Number: number, Numeric: true}
+	}
+
+	// Текстовые поля поддерживают только ":" (вхождение) и "=" (точное совпадение)
+	if op != ":" && op != "=" {
+		return text
+	}
+	switch key {
+	case "status":
+		status, ok := searchStatuses[strings.ToLower(value)]
+		if !ok {
+			status = models.ProductStatus(value)
+		}
+		return SearchCondition{Field: "status", Operator: "=", Value: string(status)}
+	case "active":
+		active, err := strconv.ParseBool(value)
+		if err != nil {
+			return text
+		}
+		return SearchCondition{Field: "is_active", Operator: "=", Value: strconv.FormatBool(active)}
+	case "class", "abc":
+		return SearchCondition{Field: "abc_class", Operator: "=", Value: strings.ToUpper(value)}
+	case "xyz":
+		return SearchCondition{Field: "xyz_class", Operator: "=", Value: strings.ToUpper(value)}
+	}
+	if column, ok := searchTextFields[key]; ok {
+		return SearchCondition{Field: column, Operator: op, Value: value}
+	}
+	return text
+}
+
+// FreeText возвращает слова и фразы запроса без квалификаторов
+func (q SearchQuery) FreeText() []string {
+	var terms []string
+	for _, c := range q.Conditions {
+		if c.Field == "" {
+			terms = append(terms, c.Value)
+		}
+	}
+	return terms
+}
+
+// Apply добавляет условия поиска к запросу товаров. Условие на закупочную цену без права
+// ее просмотра делает запрос ошибочным: иначе цену можно подобрать сужением условия.
+func (q SearchQuery) Apply(query *gorm.DB) *gorm.DB {
+	for _, c := range q.Conditions {
+		if c.Field == "purchase_price" {
+			if err := requirePermission(models.PermViewPurchasePrices); err != nil {
+				query.AddError(models.Errorf("поиск по закупочной цене: %w", err))
+				return query
+			}
+		}
+		switch {
+		case c.Field == "":
+			if fullTextEnabled {
+				continue
+			}
+			// Отдельное слово ищется по основе, как и в полнотекстовом индексе
+			value := strings.ToLower(c.Value)
+			if !strings.ContainsRune(value, ' ') {
+				value = stemRussian(value)
+			}
+			like := "%" + escapeLike(value) + "%"
+			parts := make([]string, len(searchColumns))
+			args := make([]interface{}, len(searchColumns))
+			for i, column := range searchColumns {
+				parts[i] = lowerExpr(query, column) + " LIKE ?" + likeEscape
+				args[i] = like
+			}
+			query = query.Where(strings.Join(parts, " OR "), args...)
+		case c.Numeric && searchMoneyColumns[c.Field]:
+			query = query.Where(c.Field+" "+c.Operator+" ?", models.NewMoney(c.Number))
+		case c.Numeric:
+			query = query.Where(c.Field+" "+c.Operator+" ?", c.Number)
+		case c.Field == "is_active":
+			query = query.Where("is_active = ?", c.Value == "true")
+		case c.Operator == ":":
+			query = query.Where(lowerExpr(query, c.Field)+" LIKE ?"+likeEscape, "%"+escapeLike(strings.ToLower(c.Value))+"%")
+		default:
+			query = query.Where(c.Field+" = ?", c.Value)
+		}
+	}
+
+	// Слова без квалификаторов ищутся по полнотекстовому индексу
+	if terms := q.FreeText(); fullTextEnabled && len(terms) > 0 {
+		if match := fullTextMatch(terms); match != "" {
+			query = query.Joins(
+				"JOIN (SELECT rowid, "+fullTextRank()+" AS rank FROM products_fts WHERE products_fts MATCH ?) AS fts ON fts.rowid = products.id",
+				match)
+		}
+	}
+	return query
+}
+
+// SearchProducts применяет строку поиска к запросу товаров
+func SearchProducts(query *gorm.DB, input string) *gorm.DB {
+	return ParseSearchQuery(input).Apply(query)
+}
+
+// RelevanceExpr возвращает выражение сортировки результата SearchProducts по релевантности
+// (меньше - релевантнее) или пустую строку, если ранжирование недоступно
+func RelevanceExpr(input string) string {
+	if !fullTextEnabled || fullTextMatch(ParseSearchQuery(input).FreeText()) == "" {
+		return ""
+	}
+	return "fts.rank"
+}
+
+// OrderByRelevance сортирует результат SearchProducts по релевантности.
+// Без полнотекстового индекса или слов для поиска порядок не меняется.
+func OrderByRelevance(query *gorm.DB, input string) *gorm.DB {
+	if expr := RelevanceExpr(input); expr != "" {
+		return query.Order(expr)
+	}
+	return query
+}
@@ -0,0 +1,526 @@
+package database
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	"SanWarehouse/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// snapshotFormat и snapshotVersion - заголовок файла снимка, первая строка файла
+	snapshotFormat  = "sanwarehouse-snapshot"
+	snapshotVersion = 1
+	// snapshotBatchSize - сколько записей читается из базы за один запрос при выгрузке
+	snapshotBatchSize = 500
+)
+
+// snapshotEntities - сущности снимка в порядке выгрузки и загрузки: запись ссылается
+// только на сущности, выгруженные раньше нее. Строки заказов и изменения в журнале
+// выгружаются вместе с заказом и записью журнала.
+var snapshotEntities = []string{
+	"users",
+	"categories",
+	"brands",
+	"suppliers",
+	"products",
+	"purchase_orders",
+	"stock_movements",
+	"price_changes",
+	"exchange_rates",
+	"reservations",
+	"status_events",
+	"attachments",
+	"audit_entries",
+}
+
+// snapshotTables - таблицы, которые очищаются перед загрузкой снимка, зависимые первыми
+var snapshotTables = []interface{}{
+	&models.AuditChange{},
+	&models.AuditEntry{},
+	&models.Attachment{},
+	&models.StatusEvent{},
+	&models.Reservation{},
+	&models.ExchangeRate{},
+	&models.PriceChange{},
+	&models.StockMovement{},
+	&models.PurchaseOrderLine{},
+	&models.PurchaseOrder{},
+	&models.Product{},
+	&models.Supplier{},
+	&models.Brand{},
+	&models.Category{},
+	&models.User{},
+}
+
+// snapshotHeader - первая строка файла снимка
+type snapshotHeader struct {
+	Format    string    `json:"format"`
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	// Dialect - база, из которой выгружен снимок; для сведения, загрузить можно в любую
+	Dialect string `json:"dialect"`
+}
+
+// snapshotRecord - строка снимка с одной записью. Запись сериализуется по json-тегам
+// модели; поля, скрытые в тегах, но нужные для переноса, передаются рядом с ней.
+type snapshotRecord struct {
+	Entity string          `json:"entity"`
+	Record json.RawMessage `json:"record"`
+	// DeletedAt - время пометки удаления (товары, поставщики, заказы)
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// PasswordHash - хеш пароля пользователя
+	PasswordHash string `json:"password_hash,omitempty"`
+}
+
+// SnapshotCounts - количество записей снимка по сущностям (имена таблиц)
+type SnapshotCounts map[string]int
+
+// Total возвращает общее количество записей
+func (c SnapshotCounts) Total() int {
+	total := 0
+	for _, n := range c {
+		total += n
+	}
+	return total
+}
+
+// String перечисляет количество записей по сущностям в порядке снимка
+func (c SnapshotCounts) String() string {
+	var parts []string
+	for _, entity := range snapshotEntities {
+		if n := c[entity]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%s: %d", entity, n))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ExportSnapshot выгружает все данные базы в формате JSON Lines: заголовок, затем по строке
+// на запись, включая помеченные удаленными. Снимок загружается в другую базу, в том числе
+// другого драйвера, функцией ImportSnapshot. Файлы вложений в снимок не входят: каталог
+// AttachmentsDir копируется отдельно, имена файлов в записях сохраняются.
+func ExportSnapshot(w io.Writer) (SnapshotCounts, error) {
+	if err := requirePermission(models.PermManageBackups); err != nil {
+		return nil, err
+	}
+
+	out := bufio.NewWriter(w)
+	enc := json.NewEncoder(out)
+	header := snapshotHeader{Format: snapshotFormat, Version: snapshotVersion, CreatedAt: time.Now(), Dialect: Dialect()}
+	if err := enc.Encode(header); err != nil {
+		return nil, err
+	}
+
+	counts := SnapshotCounts{}
+	// Читаем в одной транзакции, чтобы снимок был согласованным
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		tx = tx.Unscoped().Session(&gorm.Session{})
+		tables := map[string]struct {
+			query *gorm.DB
+			batch interface{}
+		}{
+			"users":           {tx, &[]models.User{}},
+			"categories":      {tx, &[]models.Category{}},
+			"brands":          {tx, &[]models.Brand{}},
+			"suppliers":       {tx, &[]models.Supplier{}},
+			"products":        {tx, &[]models.Product{}},
+			"purchase_orders": {tx.Preload("Lines"), &[]models.PurchaseOrder{}},
+			"stock_movements": {tx, &[]models.StockMovement{}},
+			"price_changes":   {tx, &[]models.PriceChange{}},
+			"exchange_rates":  {tx, &[]models.ExchangeRate{}},
+			"reservations":    {tx, &[]models.Reservation{}},
+			"status_events":   {tx, &[]models.StatusEvent{}},
+			"attachments":     {tx, &[]models.Attachment{}},
+			"audit_entries":   {tx.Preload("Changes"), &[]models.AuditEntry{}},
+		}
+		for _, entity := range snapshotEntities {
+			t := tables[entity]
+			err := t.query.FindInBatches(t.batch, snapshotBatchSize, func(*gorm.DB, int) error {
+				records := reflect.ValueOf(t.batch).Elem()
+				for i := 0; i < records.Len(); i++ {
+					if err := writeSnapshotRecord(enc, entity, records.Index(i).Addr().Interface()); err != nil {
+						return err
+					}
+					counts[entity]++
+				}
+				return nil
+			}).Error
+			if err != nil {
+				return models.Errorf("%s: %w", entity, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return counts, out.Flush()
+}
+
+// writeSnapshotRecord записывает строку снимка с одной записью
+func writeSnapshotRecord(enc *json.Encoder, entity string, record interface{}) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	line := snapshotRecord{Entity: entity, Record: data}
+	var deletedAt gorm.DeletedAt
+	switch r := record.(type) {
+	case *models.User:
+		line.PasswordHash = r.PasswordHash
+	case *models.Product:
+		deletedAt = r.DeletedAt
+	case *models.Supplier:
+		deletedAt = r.DeletedAt
+	case *models.PurchaseOrder:
+		deletedAt = r.DeletedAt
+	}
+	if deletedAt.Valid {
+		line.DeletedAt = &deletedAt.Time
+	}
+	return enc.Encode(line)
+}
+
+// ImportSnapshot загружает снимок, выгруженный ExportSnapshot. Записи получают новые ID,
+// ссылки между ними пересчитываются. Загрузка выполняется в одной транзакции: при ошибке
+// база не изменяется. Если в базе уже есть данные, загрузка отклоняется, а с replace
+// существующие данные предварительно удаляются.
+func ImportSnapshot(r io.Reader, replace bool) (SnapshotCounts, error) {
+	if err := requirePermission(models.PermManageBackups); err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bufio.NewReader(r))
+	var header snapshotHeader
+	if err := dec.Decode(&header); err != nil || header.Format != snapshotFormat {
+		return nil, models.Errorf("файл не является снимком базы склада")
+	}
+	if header.Version > snapshotVersion {
+		return nil, models.Errorf("снимок версии %d создан более новой версией программы", header.Version)
+	}
+
+	imp := &snapshotImporter{counts: SnapshotCounts{}, ids: map[string]map[uint]uint{}}
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		// Журнал загружается из снимка, а статусы, история цен и события
+		// уже записаны в нем, поэтому ни журнал, ни хуки моделей не нужны
+		imp.tx = withoutAudit(tx).Session(&gorm.Session{SkipHooks: true})
+
+		empty, err := tablesEmpty(imp.tx, snapshotTables)
+		if err != nil {
+			return err
+		}
+		if !empty {
+			if !replace {
+				return models.Errorf("в базе уже есть данные; загрузите снимок в пустую базу или замените данные")
+			}
+			for _, table := range snapshotTables {
+				if err := imp.tx.Session(&gorm.Session{AllowGlobalUpdate: true}).Unscoped().Delete(table).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		for n := 1; ; n++ {
+			var line snapshotRecord
+			err := dec.Decode(&line)
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return models.Errorf("запись %d: %w", n, err)
+			}
+			if err := imp.restore(line); err != nil {
+				return models.Errorf("запись %d (%s): %w", n, line.Entity, err)
+			}
+			imp.counts[line.Entity]++
+		}
+		return imp.linkCategories()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return imp.counts, nil
+}
+
+// snapshotImporter создает записи снимка и запоминает соответствие прежних ID новым
+type snapshotImporter struct {
+	tx     *gorm.DB
+	counts SnapshotCounts
+	// ids - новые ID записей по сущности и прежнему ID
+	ids map[string]map[uint]uint
+	// parents - прежние родительские категории по новому ID категории: родитель
+	// может быть создан позже потомка, поэтому связи восстанавливаются в конце
+	parents map[uint]uint
+}
+
+// ref возвращает новый ID записи, на которую ссылается загружаемая
+func (imp *snapshotImporter) ref(entity string, id uint) (uint, error) {
+	if newID, ok := imp.ids[entity][id]; ok {
+		return newID, nil
+	}
+	return 0, models.Errorf("ссылка на отсутствующую в снимке запись %s %d", entity, id)
+}
+
+// optionalRef - ref для необязательной ссылки
+func (imp *snapshotImporter) optionalRef(entity string, id *uint) (*uint, error) {
+	if id == nil {
+		return nil, nil
+	}
+	newID, err := imp.ref(entity, *id)
+	if err != nil {
+		return nil, err
+	}
+	return &newID, nil
+}
+
+// restore создает запись из строки снимка
+func (imp *snapshotImporter) restore(line snapshotRecord) error {
+	var err error
+	switch line.Entity {
+	case "users":
+		var u models.User
+		if err := json.Unmarshal(line.Record, &u); err != nil {
+			return err
+		}
+		u.PasswordHash = line.PasswordHash
+		return imp.create(line.Entity, &u, &u.ID)
+
+	case "categories":
+		var c models.Category
+		if err := json.Unmarshal(line.Record, &c); err != nil {
+			return err
+		}
+		parent := c.ParentID
+		c.ParentID = nil
+		c.LookupKey = models.LookupKey(c.Path)
+		if err := imp.create(line.Entity, &c, &c.ID); err != nil {
+			return err
+		}
+		if parent != nil {
+			if imp.parents == nil {
+				imp.parents = map[uint]uint{}
+			}
+			imp.parents[c.ID] = *parent
+		}
+		return nil
+
+	case "brands":
+		var b models.Brand
+		if err := json.Unmarshal(line.Record, &b); err != nil {
+			return err
+		}
+		b.LookupKey = models.LookupKey(b.Name)
+		return imp.create(line.Entity, &b, &b.ID)
+
+	case "suppliers":
+		var s models.Supplier
+		if err := json.Unmarshal(line.Record, &s); err != nil {
+			return err
+		}
+		s.DeletedAt = snapshotDeletedAt(line)
+		return imp.create(line.Entity, &s, &s.ID)
+
+	case "products":
+		var p models.Product
+		if err := json.Unmarshal(line.Record, &p); err != nil {
+			return err
+		}
+		p.DeletedAt = snapshotDeletedAt(line)
+		// В снимках до появления валют закупочные цены - в валюте учета
+		p.PurchaseCurrency = snapshotCurrency(p.PurchaseCurrency)
+		if p.CategoryID, err = imp.optionalRef("categories", p.CategoryID); err != nil {
+			return err
+		}
+		if p.BrandID, err = imp.optionalRef("brands", p.BrandID); err != nil {
+			return err
+		}
+		if p.SupplierID, err = imp.optionalRef("suppliers", p.SupplierID); err != nil {
+			return err
+		}
+		return imp.create(line.Entity, &p, &p.ID)
+
+	case "purchase_orders":
+		var o models.PurchaseOrder
+		if err := json.Unmarshal(line.Record, &o); err != nil {
+			return err
+		}
+		o.DeletedAt = snapshotDeletedAt(line)
+		o.Currency = snapshotCurrency(o.Currency)
+		if o.SupplierID, err = imp.optionalRef("suppliers", o.SupplierID); err != nil {
+			return err
+		}
+		for i := range o.Lines {
+			o.Lines[i].ID, o.Lines[i].PurchaseOrderID = 0, 0
+			if o.Lines[i].ProductID, err = imp.ref("products", o.Lines[i].ProductID); err != nil {
+				return err
+			}
+		}
+		return imp.create(line.Entity, &o, &o.ID)
+
+	case "stock_movements":
+		var m models.StockMovement
+		if err := json.Unmarshal(line.Record, &m); err != nil {
+			return err
+		}
+		if m.ProductID, err = imp.ref("products", m.ProductID); err != nil {
+			return err
+		}
+		return imp.create(line.Entity, &m, &m.ID)
+
+	case "price_changes":
+		var c models.PriceChange
+		if err := json.Unmarshal(line.Record, &c); err != nil {
+			return err
+		}
+		c.OldPurchaseCurrency = snapshotCurrency(c.OldPurchaseCurrency)
+		c.NewPurchaseCurrency = snapshotCurrency(c.NewPurchaseCurrency)
+		if c.ProductID, err = imp.ref("products", c.ProductID); err != nil {
+			return err
+		}
+		return imp.create(line.Entity, &c, &c.ID)
+
+	case "exchange_rates":
+		var r models.ExchangeRate
+		if err := json.Unmarshal(line.Record, &r); err != nil {
+			return err
+		}
+		return imp.create(line.Entity, &r, &r.ID)
+
+	case "reservations":
+		var r models.Reservation
+		if err := json.Unmarshal(line.Record, &r); err != nil {
+			return err
+		}
+		if r.ProductID, err = imp.ref("products", r.ProductID); err != nil {
+			return err
+		}
+		return imp.create(line.Entity, &r, &r.ID)
+
+	case "status_events":
+		var e models.StatusEvent
+		if err := json.Unmarshal(line.Record, &e); err != nil {
+			return err
+		}
+		if e.ProductID, err = imp.ref("products", e.ProductID); err != nil {
+			return err
+		}
+		return imp.create(line.Entity, &e, &e.ID)
+
+	case "attachments":
+		var a models.Attachment
+		if err := json.Unmarshal(line.Record, &a); err != nil {
+			return err
+		}
+		if a.ProductID, err = imp.ref("products", a.ProductID); err != nil {
+			return err
+		}
+		return imp.create(line.Entity, &a, &a.ID)
+
+	case "audit_entries":
+		var e models.AuditEntry
+		if err := json.Unmarshal(line.Record, &e); err != nil {
+			return err
+		}
+		// Журнал хранит и записи, которых уже нет (удаленные категории, бренды):
+		// такие ссылки не переносятся, чтобы не указывать на чужую запись
+		if e.UserID != nil {
+			if id, ok := imp.ids["users"][*e.UserID]; ok {
+				e.UserID = &id
+			} else {
+				e.UserID = nil
+			}
+		}
+		e.EntityID = imp.ids[e.Entity][e.EntityID]
+		for i := range e.Changes {
+			e.Changes[i].ID, e.Changes[i].AuditEntryID = 0, 0
+		}
+		return imp.create(line.Entity, &e, &e.ID)
+	}
+	return models.Errorf("неизвестная сущность")
+}
+
+// create сохраняет запись с новым ID и запоминает соответствие ID
+func (imp *snapshotImporter) create(entity string, record interface{}, id *uint) error {
+	oldID := *id
+	*id = 0
+
+	// Для нулевых значений полей со значением по умолчанию (is_active = false)
+	// GORM подставляет значение по умолчанию, поэтому они записываются отдельно
+	zeroDefaults, err := zeroDefaultColumns(imp.tx, record)
+	if err != nil {
+		return err
+	}
+	if err := imp.tx.Create(record).Error; err != nil {
+		return err
+	}
+	if len(zeroDefaults) > 0 {
+		if err := imp.tx.Table(entity).Where("id = ?", *id).UpdateColumns(zeroDefaults).Error; err != nil {
+			return err
+		}
+	}
+
+	if imp.ids[entity] == nil {
+		imp.ids[entity] = map[uint]uint{}
+	}
+	imp.ids[entity][oldID] = *id
+	return nil
+}
+
+// zeroDefaultColumns возвращает колонки записи с нулевым значением, для которых в модели
+// задано ненулевое значение по умолчанию
+func zeroDefaultColumns(tx *gorm.DB, record interface{}) (map[string]interface{}, error) {
+	stmt := &gorm.Statement{DB: tx}
+	if err := stmt.Parse(record); err != nil {
+		return nil, err
+	}
+	value := reflect.ValueOf(record).Elem()
+	columns := map[string]interface{}{}
+	for _, f := range stmt.Schema.Fields {
+		if f.DBName == "" || f.PrimaryKey || f.DefaultValueInterface == nil || reflect.ValueOf(f.DefaultValueInterface).IsZero() {
+			continue
+		}
+		if v, zero := f.ValueOf(tx.Statement.Context, value); zero {
+			columns[f.DBName] = v
+		}
+	}
+	return columns, nil
+}
+
+// linkCategories восстанавливает иерархию категорий после создания всех категорий
+func (imp *snapshotImporter) linkCategories() error {
+	for id, parent := range imp.parents {
+		parentID, err := imp.ref("categories", parent)
+		if err != nil {
+			return err
+		}
+		if err := imp.tx.Model(&models.Category{ID: id}).UpdateColumn("parent_id", parentID).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// snapshotDeletedAt возвращает пометку удаления из строки снимка
+func snapshotDeletedAt(line snapshotRecord) gorm.DeletedAt {
+	if line.DeletedAt == nil {
+		return gorm.DeletedAt{}
+	}
+	return gorm.DeletedAt{Time: *line.DeletedAt, Valid: true}
+}
+
+// snapshotCurrency возвращает валюту записи снимка; в снимках без валют - валюту учета
+func snapshotCurrency(c models.Currency) models.Currency {
+	if c == "" {
+		return models.BaseCurrency
+	}
+	return c
+}
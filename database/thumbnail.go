@@ -0,0 +1,60 @@
+package database
+
+import (
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"os"
+
+	_ "golang.org/x/image/bmp"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// thumbnailSize - наибольшая сторона миниатюры в пикселях
+const thumbnailSize = 160
+
+// makeThumbnail уменьшает изображение src так, чтобы оно вписалось в квадрат size,
+// и сохраняет результат в PNG. Маленькие изображения не увеличиваются.
+func makeThumbnail(src, dst string, size int) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	img, _, err := image.Decode(in)
+	if err != nil {
+		return err
+	}
+
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w > size || h > size {
+		if w >= h {
+			w, h = size, h*size/w
+		} else {
+			w, h = w*size/h, size
+		}
+	}
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+
+	thumb := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(thumb, thumb.Bounds(), img, bounds, draw.Src, nil)
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(out, thumb); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
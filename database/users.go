@@ -0,0 +1,167 @@
+package database
+
+import (
+	"strings"
+	"time"
+
+	"SanWarehouse/models"
+
+	"gorm.io/gorm"
+)
+
+// currentUser - пользователь, вошедший в приложение; nil для команд командной строки
+var currentUser *models.User
+
+// ErrInvalidCredentials - неверное имя пользователя или пароль. Причина намеренно не уточняется.
+var ErrInvalidCredentials = models.Errorf("неверное имя пользователя или пароль")
+
+// SetCurrentUser запоминает пользователя, от имени которого выполняются действия
+func SetCurrentUser(u *models.User) {
+	currentUser = u
+}
+
+// CurrentUser возвращает вошедшего пользователя или nil
+func CurrentUser() *models.User {
+	return currentUser
+}
+
+// Can сообщает, разрешено ли действие текущему пользователю.
+// Без входа (команды командной строки) разрешено все.
+func Can(p models.Permission) bool {
+	return currentUser == nil || currentUser.Can(p)
+}
+
+// requirePermission возвращает ошибку, если у текущего пользователя нет права p
+func requirePermission(p models.Permission) error {
+	if Can(p) {
+		return nil
+	}
+	return models.Errorf("недостаточно прав: %s", p)
+}
+
+// HasUsers сообщает, заведена ли хотя бы одна учетная запись
+func HasUsers() bool {
+	var count int64
+	DB.Model(&models.User{}).Count(&count)
+	return count > 0
+}
+
+// Authenticate проверяет имя пользователя и пароль и запоминает время входа
+func Authenticate(username, password string) (*models.User, error) {
+	var user models.User
+	err := DB.Where("username = ?", strings.ToLower(strings.TrimSpace(username))).Take(&user).Error
+	if err == gorm.ErrRecordNotFound {
+		// Хешируем пароль и для несуществующего пользователя, чтобы время ответа не выдавало имена
+		(&models.User{PasswordHash: dummyPasswordHash}).CheckPassword(password)
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !user.CheckPassword(password) {
+		return nil, ErrInvalidCredentials
+	}
+	if user.Disabled {
+		return nil, models.Errorf("учетная запись %s заблокирована", user.Username)
+	}
+
+	now := time.Now()
+	user.LastLoginAt = &now
+	DB.Model(&user).UpdateColumn("last_login_at", now)
+	return &user, nil
+}
+
+// dummyPasswordHash - хеш с теми же параметрами, что и у настоящих паролей
+const dummyPasswordHash = "pbkdf2-sha256$600000$AAAAAAAAAAAAAAAAAAAAAA$AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+
+// Users возвращает все учетные записи по имени
+func Users() []models.User {
+	var users []models.User
+	DB.Order("username").Find(&users)
+	return users
+}
+
+// CreateUser заводит учетную запись. Первую учетную запись (администратора при первом запуске)
+// может создать кто угодно, остальные - только пользователь с правом управления пользователями.
+func CreateUser(user *models.User, password string) error {
+	if HasUsers() {
+		if err := requirePermission(models.PermManageUsers); err != nil {
+			return err
+		}
+	}
+	if err := prepareUser(user); err != nil {
+		return err
+	}
+	if err := user.SetPassword(password); err != nil {
+		return err
+	}
+	return DB.Create(user).Error
+}
+
+// UpdateUser сохраняет имя, роль и блокировку учетной записи. Пустой password оставляет прежний пароль.
+func UpdateUser(user *models.User, password string) error {
+	if err := requirePermission(models.PermManageUsers); err != nil {
+		return err
+	}
+	if err := prepareUser(user); err != nil {
+		return err
+	}
+	if password != "" {
+		if err := user.SetPassword(password); err != nil {
+			return err
+		}
+	}
+
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(user).Error; err != nil {
+			return err
+		}
+		// Нельзя остаться без администратора: войти и исправить это будет некому
+		var admins int64
+		tx.Model(&models.User{}).Where("role = ? AND disabled = ?", models.RoleAdmin, false).Count(&admins)
+		if admins == 0 {
+			return models.Errorf("должен остаться хотя бы один активный администратор")
+		}
+		return nil
+	})
+}
+
+// ChangePassword меняет пароль текущего пользователя после проверки старого
+func ChangePassword(oldPassword, newPassword string) error {
+	if currentUser == nil {
+		return models.Errorf("вход не выполнен")
+	}
+	var user models.User
+	if err := DB.First(&user, currentUser.ID).Error; err != nil {
+		return err
+	}
+	if !user.CheckPassword(oldPassword) {
+		return models.Errorf("текущий пароль указан неверно")
+	}
+	if err := user.SetPassword(newPassword); err != nil {
+		return err
+	}
+	return DB.Model(&user).Update("password_hash", user.PasswordHash).Error
+}
+
+// prepareUser нормализует и проверяет поля учетной записи
+func prepareUser(user *models.User) error {
+	user.Username = strings.ToLower(strings.TrimSpace(user.Username))
+	user.FullName = strings.TrimSpace(user.FullName)
+	if user.Username == "" {
+		return models.Errorf("укажите имя пользователя")
+	}
+	if strings.ContainsAny(user.Username, " \t") {
+		return models.Errorf("имя пользователя не может содержать пробелы")
+	}
+	if user.Role.Title() == string(user.Role) {
+		return models.Errorf("неизвестная роль: %s", user.Role)
+	}
+
+	var count int64
+	DB.Model(&models.User{}).Where("username = ? AND id <> ?", user.Username, user.ID).Count(&count)
+	if count > 0 {
+		return models.Errorf("пользователь %s уже существует", user.Username)
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package database
+
+import (
+	"strings"
+
+	"SanWarehouse/models"
+)
+
+// ValidateProduct проверяет товар вместе с уникальностью артикула. Удаленные товары
+// тоже учитываются: уникальный индекс по sku распространяется и на них.
+func ValidateProduct(p *models.Product) models.ValidationErrors {
+	errs := p.Validate()
+	if _, ok := errs.ForField(models.FieldSKU); ok {
+		return errs
+	}
+
+	var count int64
+	DB.Unscoped().Model(&models.Product{}).
+		Where("sku = ? AND id <> ?", strings.TrimSpace(p.SKU), p.ID).
+		Count(&count)
+	if count > 0 {
+		errs.Add(models.FieldSKU, "Товар с таким артикулом уже существует")
+	}
+	return errs
+}
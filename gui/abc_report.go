@@ -0,0 +1,290 @@
+package gui
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"image/color"
+	"math"
+	"sort"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"gorm.io/gorm"
+
+	"SanWarehouse/analytics"
+	"SanWarehouse/database"
+	"SanWarehouse/models"
+)
+
+// classRow - результат ABC/XYZ анализа для одного товара
+type classRow struct {
+	product models.Product
+	value   float64
+	cv      float64
+	abc     string
+	xyz     string
+}
+
+var (
+	abcClasses = []string{"A", "B", "C"}
+	xyzClasses = []string{"X", "Y", "Z"}
+)
+
+// showABCReport - ABC/XYZ классификация товаров
+func (r *Reports) showABCReport() {
+	w := r.mainWindow.app.NewWindow(lang.L("ABC/XYZ анализ"))
+	w.Resize(fyne.NewSize(1000, 650))
+
+	abcThresholds := analytics.DefaultABCThresholds
+	xyzThresholds := analytics.DefaultXYZThresholds
+	byRevenue := false
+	periodDays := 180
+	var rows []classRow
+
+	aEntry := widget.NewEntry()
+	aEntry.SetText(strconv.FormatFloat(abcThresholds.A, 'f', 0, 64))
+	bEntry := widget.NewEntry()
+	bEntry.SetText(strconv.FormatFloat(abcThresholds.B, 'f', 0, 64))
+	xEntry := widget.NewEntry()
+	xEntry.SetText(strconv.FormatFloat(xyzThresholds.X, 'f', 2, 64))
+	yEntry := widget.NewEntry()
+	yEntry.SetText(strconv.FormatFloat(xyzThresholds.Y, 'f', 2, 64))
+
+	stockValue, revenue := lang.L("Стоимость запаса"), lang.L("Выручка")
+	basis := widget.NewRadioGroup([]string{stockValue, revenue}, func(s string) {
+		byRevenue = s == revenue
+	})
+	basis.Horizontal = true
+	basis.SetSelected(stockValue)
+
+	headers := translateAll([]string{"SKU", "Наименование", "Показатель", "Коэф. вариации", "ABC", "XYZ"})
+	table := widget.NewTable(
+		func() (int, int) {
+			return len(rows) + 1, len(headers)
+		},
+		func() fyne.CanvasObject {
+			return container.NewStack(
+				canvas.NewRectangle(color.Transparent),
+				widget.NewLabel("Template"),
+			)
+		},
+		func(id widget.TableCellID, obj fyne.CanvasObject) {
+			container := obj.(*fyne.Container)
+			bg := container.Objects[0].(*canvas.Rectangle)
+			label := container.Objects[1].(*widget.Label)
+
+			if id.Row == 0 {
+				label.TextStyle.Bold = true
+				label.SetText(headers[id.Col])
+				bg.FillColor = &color.NRGBA{R: 200, G: 200, B: 200, A: 255}
+				bg.Refresh()
+				return
+			}
+			label.TextStyle.Bold = false
+			bg.FillColor = color.Transparent
+			bg.Refresh()
+
+			row := rows[id.Row-1]
+			switch id.Col {
+			case 0:
+				label.SetText(row.product.SKU)
+			case 1:
+				label.SetText(truncate(row.product.Name, 30))
+			case 2:
+				label.SetText(formatNumber(row.value, 2))
+			case 3:
+				if math.IsInf(row.cv, 1) {
+					label.SetText("—")
+				} else {
+					label.SetText(formatNumber(row.cv, 2))
+				}
+			case 4:
+				label.SetText(row.abc)
+			case 5:
+				label.SetText(row.xyz)
+			}
+		})
+	widths := []float32{100, 280, 120, 120, 60, 60}
+	for i, width := range widths {
+		table.SetColumnWidth(i, width)
+	}
+
+	matrix := container.NewGridWithColumns(4)
+
+	updateMatrix := func() {
+		type cell struct {
+			count int
+			value float64
+		}
+		cells := map[string]*cell{}
+		total := 0.0
+		for _, row := range rows {
+			key := row.abc + row.xyz
+			if cells[key] == nil {
+				cells[key] = &cell{}
+			}
+			cells[key].count++
+			cells[key].value += row.value
+			total += row.value
+		}
+
+		matrix.RemoveAll()
+		matrix.Add(widget.NewLabel(""))
+		for _, x := range xyzClasses {
+			matrix.Add(widget.NewLabelWithStyle(x, fyne.TextAlignCenter, fyne.TextStyle{Bold: true}))
+		}
+		for _, a := range abcClasses {
+			matrix.Add(widget.NewLabelWithStyle(a, fyne.TextAlignCenter, fyne.TextStyle{Bold: true}))
+			for _, x := range xyzClasses {
+				abc, xyz := a, x
+				c := cells[abc+xyz]
+				if c == nil {
+					c = &cell{}
+				}
+				share := 0.0
+				if total > 0 {
+					share = c.value / total * 100
+				}
+				text := fmt.Sprintf(lang.L("%s%s: %d шт. (%s%%)"), abc, xyz, c.count, formatNumber(share, 1))
+				btn := widget.NewButton(text, func() {
+					r.mainWindow.productList.FilterByClass(abc, xyz)
+					r.mainWindow.statusBar.SetText(fmt.Sprintf(lang.L("Фильтр: класс %s%s"), abc, xyz))
+				})
+				matrix.Add(btn)
+			}
+		}
+		matrix.Refresh()
+	}
+
+	calculate := func() {
+		var err error
+		if abcThresholds.A, err = strconv.ParseFloat(aEntry.Text, 64); err != nil {
+			showError(fmt.Errorf(lang.L("некорректная граница класса A: %s"), aEntry.Text), w)
+			return
+		}
+		if abcThresholds.B, err = strconv.ParseFloat(bEntry.Text, 64); err != nil || abcThresholds.B < abcThresholds.A {
+			showError(errors.New(lang.L("граница класса B должна быть не меньше границы A")), w)
+			return
+		}
+		if xyzThresholds.X, err = strconv.ParseFloat(xEntry.Text, 64); err != nil {
+			showError(fmt.Errorf(lang.L("некорректная граница класса X: %s"), xEntry.Text), w)
+			return
+		}
+		if xyzThresholds.Y, err = strconv.ParseFloat(yEntry.Text, 64); err != nil || xyzThresholds.Y < xyzThresholds.X {
+			showError(errors.New(lang.L("граница класса Y должна быть не меньше границы X")), w)
+			return
+		}
+
+		var products []models.Product
+		database.DB.Select("id, sku, name, category, quantity, purchase_price, purchase_currency, selling_price").Find(&products)
+		series := loadShipmentSeries(periodDays)
+		rates := database.CurrentExchangeRates()
+
+		items := make([]analytics.ValueItem, 0, len(products))
+		rows = rows[:0]
+		for _, p := range products {
+			row := classRow{product: p, cv: math.Inf(1)}
+
+			s := series[p.ID]
+			if byRevenue {
+				sold := 0.0
+				for _, v := range s {
+					sold += v
+				}
+				row.value = sold * p.SellingPrice.Float()
+			} else {
+				// Запас в валюте без курса не оценить, такой товар попадает в класс C
+				cost, _ := rates.Convert(p.PurchasePrice.Times(p.Quantity), p.PurchaseCurrency)
+				row.value = cost.Float()
+			}
+			if s != nil {
+				row.cv = analytics.CoefficientOfVariation(analytics.Aggregate(s, 7))
+			}
+			row.xyz = analytics.ClassifyXYZ(row.cv, xyzThresholds)
+
+			items = append(items, analytics.ValueItem{ID: p.ID, Value: row.value})
+			rows = append(rows, row)
+		}
+
+		classes := analytics.ClassifyABC(items, abcThresholds)
+		for i := range rows {
+			rows[i].abc = classes[rows[i].product.ID]
+		}
+		sort.SliceStable(rows, func(i, j int) bool {
+			return rows[i].value > rows[j].value
+		})
+
+		// Сохраняем классы, чтобы по ним можно было фильтровать список товаров
+		err = database.DB.Transaction(func(tx *gorm.DB) error {
+			for _, row := range rows {
+				if err := tx.Model(&models.Product{}).Where("id = ?", row.product.ID).
+					UpdateColumns(map[string]interface{}{"abc_class": row.abc, "xyz_class": row.xyz}).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			showError(err, w)
+		}
+
+		table.Refresh()
+		updateMatrix()
+		r.mainWindow.productList.RefreshList()
+	}
+
+	exportCSV := func() {
+		dialog.ShowFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil || writer == nil {
+				return
+			}
+			defer writer.Close()
+
+			cw := csv.NewWriter(writer)
+			cw.Write(headers)
+			for _, row := range rows {
+				cv := ""
+				if !math.IsInf(row.cv, 1) {
+					cv = fmt.Sprintf("%.4f", row.cv)
+				}
+				cw.Write([]string{row.product.SKU, row.product.Name, fmt.Sprintf("%.2f", row.value), cv, row.abc, row.xyz})
+			}
+			cw.Flush()
+			if err := cw.Error(); err != nil {
+				showError(err, w)
+				return
+			}
+
+			dialog.ShowInformation(lang.L("Экспорт завершен"), lang.L("Результат ABC/XYZ анализа выгружен в CSV"), w)
+		}, w)
+	}
+
+	controls := container.NewVBox(
+		container.NewHBox(widget.NewLabel(lang.L("ABC по:")), basis),
+		container.NewHBox(
+			widget.NewLabel(lang.L("A до, %:")), aEntry,
+			widget.NewLabel(lang.L("B до, %:")), bEntry,
+			widget.NewLabel(lang.L("X до:")), xEntry,
+			widget.NewLabel(lang.L("Y до:")), yEntry,
+		),
+		container.NewHBox(
+			widget.NewButtonWithIcon(lang.L("Рассчитать"), theme.ViewRefreshIcon(), calculate),
+			widget.NewButtonWithIcon(lang.L("Экспорт в CSV"), theme.DownloadIcon(), exportCSV),
+		),
+		widget.NewLabelWithStyle(lang.L("Матрица ABC/XYZ (нажмите на ячейку, чтобы отфильтровать список товаров)"),
+			fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		matrix,
+	)
+
+	w.SetContent(container.NewBorder(controls, nil, nil, nil, table))
+	calculate()
+	w.Show()
+}
@@ -0,0 +1,187 @@
+package gui
+
+import (
+	"fmt"
+	"image/color"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"SanWarehouse/database"
+	"SanWarehouse/models"
+)
+
+// AlertCenter - панель уведомлений об изменении статуса товаров
+type AlertCenter struct {
+	mainWindow *MainWindow
+	events     []models.StatusEvent
+
+	button *widget.Button
+	list   *widget.List
+	panel  *fyne.Container
+
+	// ID последнего события, о котором уже отправлено уведомление
+	lastNotifiedID uint
+}
+
+func NewAlertCenter(mw *MainWindow) *AlertCenter {
+	ac := &AlertCenter{
+		mainWindow: mw,
+	}
+
+	// Не засыпаем пользователя уведомлениями о старых событиях при запуске
+	database.DB.Model(&models.StatusEvent{}).Select("coalesce(max(id), 0)").Scan(&ac.lastNotifiedID)
+
+	ac.button = widget.NewButtonWithIcon(lang.L("Уведомления"), theme.WarningIcon(), ac.Toggle)
+
+	ac.list = widget.NewList(
+		func() int {
+			return len(ac.events)
+		},
+		func() fyne.CanvasObject {
+			label := widget.NewLabel("Template")
+			label.Wrapping = fyne.TextWrapWord
+			ack := widget.NewButtonWithIcon("", theme.ConfirmIcon(), nil)
+			snooze := widget.NewButtonWithIcon("", theme.HistoryIcon(), nil)
+			return container.NewBorder(nil, nil, nil, container.NewHBox(ack, snooze), label)
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			if id < 0 || id >= len(ac.events) {
+				return
+			}
+			event := ac.events[id]
+
+			row := obj.(*fyne.Container)
+			label := row.Objects[0].(*widget.Label)
+			buttons := row.Objects[1].(*fyne.Container)
+			ack := buttons.Objects[0].(*widget.Button)
+			snooze := buttons.Objects[1].(*widget.Button)
+
+			label.SetText(fmt.Sprintf(lang.L("%s %s\n%s → %s | Доступно: %d\n%s"),
+				event.SKU, truncate(event.ProductName, 30),
+				lang.L(event.OldStatus.Title()), lang.L(event.NewStatus.Title()), event.Available,
+				formatDateTime(event.CreatedAt)))
+
+			ack.OnTapped = func() {
+				ac.acknowledge(event)
+			}
+			snooze.OnTapped = func() {
+				ac.showSnoozeMenu(event, snooze)
+			}
+		},
+	)
+	ac.list.OnSelected = func(id widget.ListItemID) {
+		ac.list.Unselect(id)
+	}
+
+	title := widget.NewLabelWithStyle(lang.L("Центр уведомлений"), fyne.TextAlignCenter, fyne.TextStyle{Bold: true})
+	ackAll := widget.NewButtonWithIcon(lang.L("Прочитать все"), theme.ConfirmIcon(), ac.acknowledgeAll)
+
+	// Прозрачный прямоугольник задает минимальную ширину панели
+	sizer := canvas.NewRectangle(color.Transparent)
+	sizer.SetMinSize(fyne.NewSize(340, 0))
+
+	ac.panel = container.NewStack(sizer, container.NewBorder(title, ackAll, nil, nil, ac.list))
+	ac.panel.Hide()
+
+	return ac
+}
+
+// Button возвращает кнопку со счетчиком непрочитанных уведомлений
+func (ac *AlertCenter) Button() fyne.CanvasObject {
+	return ac.button
+}
+
+// Panel возвращает боковую панель со списком уведомлений
+func (ac *AlertCenter) Panel() fyne.CanvasObject {
+	return ac.panel
+}
+
+// Toggle показывает или скрывает панель уведомлений
+func (ac *AlertCenter) Toggle() {
+	if ac.panel.Visible() {
+		ac.panel.Hide()
+	} else {
+		ac.Refresh()
+		ac.panel.Show()
+	}
+	ac.mainWindow.window.Content().Refresh()
+}
+
+// Refresh перечитывает активные события и отправляет уведомления о новых
+func (ac *AlertCenter) Refresh() {
+	var events []models.StatusEvent
+	database.DB.Where("acknowledged = ?", false).Order("created_at desc").Limit(200).Find(&events)
+
+	now := time.Now()
+	ac.events = ac.events[:0]
+	for _, e := range events {
+		if e.IsActive(now) {
+			ac.events = append(ac.events, e)
+		}
+	}
+
+	for i := len(events) - 1; i >= 0; i-- {
+		e := events[i]
+		if e.ID <= ac.lastNotifiedID {
+			continue
+		}
+		ac.lastNotifiedID = e.ID
+		if e.IsAlert() {
+			ac.notify(e)
+		}
+	}
+
+	if len(ac.events) > 0 {
+		ac.button.SetText(fmt.Sprintf(lang.L("Уведомления (%d)"), len(ac.events)))
+		ac.button.Importance = widget.WarningImportance
+	} else {
+		ac.button.SetText(lang.L("Уведомления"))
+		ac.button.Importance = widget.MediumImportance
+	}
+	ac.button.Refresh()
+	ac.list.Refresh()
+}
+
+func (ac *AlertCenter) notify(e models.StatusEvent) {
+	title := lang.L("Низкий запас товара")
+	if e.NewStatus == models.StatusOutOfStock {
+		title = lang.L("Товар закончился")
+	}
+	content := fmt.Sprintf(lang.L("%s %s: доступно %d шт."), e.SKU, e.ProductName, e.Available)
+	ac.mainWindow.app.SendNotification(fyne.NewNotification(title, content))
+}
+
+func (ac *AlertCenter) acknowledge(e models.StatusEvent) {
+	e.Acknowledge(time.Now())
+	database.DB.Save(&e)
+	ac.Refresh()
+}
+
+func (ac *AlertCenter) acknowledgeAll() {
+	database.DB.Model(&models.StatusEvent{}).
+		Where("acknowledged = ?", false).
+		Updates(map[string]interface{}{"acknowledged": true, "acknowledged_at": time.Now()})
+	ac.Refresh()
+}
+
+func (ac *AlertCenter) snooze(e models.StatusEvent, d time.Duration) {
+	e.Snooze(time.Now(), d)
+	database.DB.Save(&e)
+	ac.Refresh()
+}
+
+func (ac *AlertCenter) showSnoozeMenu(e models.StatusEvent, from fyne.CanvasObject) {
+	menu := fyne.NewMenu("",
+		fyne.NewMenuItem(lang.L("Отложить на 1 час"), func() { ac.snooze(e, time.Hour) }),
+		fyne.NewMenuItem(lang.L("Отложить на 1 день"), func() { ac.snooze(e, 24*time.Hour) }),
+		fyne.NewMenuItem(lang.L("Отложить на неделю"), func() { ac.snooze(e, 7*24*time.Hour) }),
+	)
+	widget.ShowPopUpMenuAtRelativePosition(menu, ac.mainWindow.window.Canvas(),
+		fyne.NewPos(0, from.Size().Height), from)
+}
@@ -0,0 +1,253 @@
+package gui
+
+import (
+	"fmt"
+	"image/color"
+	"net/url"
+	"path/filepath"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"SanWarehouse/database"
+	"SanWarehouse/models"
+)
+
+// Расширения файлов, предлагаемые при выборе изображений и документов
+var (
+	imageExtensions    = []string{".jpg", ".jpeg", ".png", ".gif", ".bmp", ".webp"}
+	documentExtensions = []string{".pdf", ".doc", ".docx", ".xls", ".xlsx", ".odt", ".txt", ".jpg", ".jpeg", ".png"}
+)
+
+// Размер плитки галереи
+var galleryTileSize = fyne.NewSize(130, 150)
+
+// AttachmentGallery - изображения и документы товара в виде плиток.
+// Изменения (добавление, удаление, выбор основного) сохраняются сразу.
+type AttachmentGallery struct {
+	window    fyne.Window
+	productID uint
+	editable  bool
+	// onChange вызывается после изменения вложений (может быть nil)
+	onChange func()
+
+	attachments []models.Attachment
+	selected    int
+
+	tiles      *fyne.Container
+	info       *widget.Label
+	openBtn    *widget.Button
+	primaryBtn *widget.Button
+	removeBtn  *widget.Button
+}
+
+func NewAttachmentGallery(parent fyne.Window, productID uint, editable bool) *AttachmentGallery {
+	return &AttachmentGallery{
+		window:    parent,
+		productID: productID,
+		editable:  editable,
+		selected:  -1,
+	}
+}
+
+// Widget создает содержимое галереи
+func (g *AttachmentGallery) Widget() fyne.CanvasObject {
+	if g.productID == 0 {
+		return widget.NewLabel(lang.L("Изображения и документы можно добавить после сохранения товара"))
+	}
+
+	g.tiles = container.NewGridWrap(galleryTileSize)
+	g.info = widget.NewLabel("")
+	g.openBtn = widget.NewButtonWithIcon(lang.L("Открыть"), theme.FileIcon(), g.openSelected)
+
+	buttons := container.NewHBox(g.openBtn)
+	if g.editable {
+		g.primaryBtn = widget.NewButtonWithIcon(lang.L("Сделать основным"), theme.ConfirmIcon(), g.setPrimary)
+		g.removeBtn = widget.NewButtonWithIcon(lang.L("Удалить"), theme.DeleteIcon(), g.removeSelected)
+		buttons.Add(g.primaryBtn)
+		buttons.Add(g.removeBtn)
+		buttons.Add(widget.NewSeparator())
+		buttons.Add(widget.NewButtonWithIcon(lang.L("Изображение"), theme.ContentAddIcon(), func() {
+			g.addFile(models.AttachmentImage, imageExtensions)
+		}))
+		buttons.Add(widget.NewButtonWithIcon(lang.L("Документ"), theme.ContentAddIcon(), func() {
+			g.addFile(models.AttachmentDocument, documentExtensions)
+		}))
+	}
+
+	// Без минимальной высоты галерея схлопывается внутри прокручиваемой формы
+	scroll := container.NewVScroll(g.tiles)
+	scroll.SetMinSize(fyne.NewSize(galleryTileSize.Width*3, galleryTileSize.Height))
+
+	g.Reload()
+	return container.NewBorder(nil, container.NewVBox(g.info, buttons), nil, nil, scroll)
+}
+
+// Reload перечитывает вложения товара из БД
+func (g *AttachmentGallery) Reload() {
+	g.attachments = database.ProductAttachments(g.productID)
+	g.selected = -1
+	g.rebuild()
+}
+
+// rebuild пересоздает плитки и обновляет состояние кнопок
+func (g *AttachmentGallery) rebuild() {
+	g.tiles.RemoveAll()
+	for i := range g.attachments {
+		g.tiles.Add(g.tile(i))
+	}
+	g.tiles.Refresh()
+
+	var current *models.Attachment
+	if g.selected >= 0 {
+		current = &g.attachments[g.selected]
+	}
+
+	switch {
+	case current != nil:
+		g.info.SetText(fmt.Sprintf("%s, %s", current.FileName, formatFileSize(current.Size)))
+	case len(g.attachments) == 0:
+		g.info.SetText(lang.L("Файлов нет"))
+	default:
+		g.info.SetText(fmt.Sprintf(lang.L("Файлов: %d"), len(g.attachments)))
+	}
+
+	setEnabled(g.openBtn, current != nil)
+	if g.editable {
+		setEnabled(g.removeBtn, current != nil)
+		setEnabled(g.primaryBtn, current != nil && current.IsImage() && !current.IsPrimary)
+	}
+}
+
+// tile создает плитку вложения: миниатюра или значок документа с подписью
+func (g *AttachmentGallery) tile(i int) fyne.CanvasObject {
+	a := &g.attachments[i]
+
+	var preview fyne.CanvasObject
+	if thumb := database.ThumbnailPath(a); thumb != "" {
+		img := canvas.NewImageFromFile(thumb)
+		img.FillMode = canvas.ImageFillContain
+		preview = img
+	} else {
+		icon := widget.NewIcon(theme.FileTextIcon())
+		preview = container.NewCenter(container.NewGridWrap(fyne.NewSize(64, 64), icon))
+	}
+
+	caption := truncate(a.DisplayName(), 16)
+	if a.IsPrimary {
+		caption = "★ " + caption
+	}
+	label := widget.NewLabel(caption)
+	label.Alignment = fyne.TextAlignCenter
+
+	bg := canvas.NewRectangle(color.Transparent)
+	if i == g.selected {
+		bg.FillColor = theme.Color(theme.ColorNameSelection)
+	}
+
+	// Прозрачная кнопка поверх плитки выделяет ее по нажатию
+	tap := widget.NewButton("", func() {
+		g.selected = i
+		g.rebuild()
+	})
+	tap.Importance = widget.LowImportance
+
+	return container.NewStack(bg, container.NewBorder(nil, label, nil, nil, preview), tap)
+}
+
+// addFile выбирает файл и добавляет его к товару
+func (g *AttachmentGallery) addFile(kind models.AttachmentKind, extensions []string) {
+	fd := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			showError(err, g.window)
+			return
+		}
+		if reader == nil {
+			return
+		}
+		defer reader.Close()
+
+		if _, err := database.AddAttachment(g.productID, kind, reader.URI().Name(), reader); err != nil {
+			showError(err, g.window)
+			return
+		}
+		g.changed()
+	}, g.window)
+	fd.SetFilter(storage.NewExtensionFileFilter(extensions))
+	fd.Show()
+}
+
+// openSelected открывает выбранный файл во внешней программе
+func (g *AttachmentGallery) openSelected() {
+	if g.selected < 0 {
+		return
+	}
+	path, err := filepath.Abs(database.AttachmentPath(&g.attachments[g.selected]))
+	if err != nil {
+		showError(err, g.window)
+		return
+	}
+	u, err := url.Parse(storage.NewFileURI(path).String())
+	if err != nil {
+		showError(err, g.window)
+		return
+	}
+	if err := fyne.CurrentApp().OpenURL(u); err != nil {
+		showError(err, g.window)
+	}
+}
+
+// setPrimary делает выбранное изображение основным
+func (g *AttachmentGallery) setPrimary() {
+	if g.selected < 0 {
+		return
+	}
+	if err := database.SetPrimaryAttachment(g.attachments[g.selected].ID); err != nil {
+		showError(err, g.window)
+		return
+	}
+	g.changed()
+}
+
+// removeSelected удаляет выбранное вложение после подтверждения
+func (g *AttachmentGallery) removeSelected() {
+	if g.selected < 0 {
+		return
+	}
+	a := g.attachments[g.selected]
+	dialog.ShowConfirm(lang.L("Удаление файла"), fmt.Sprintf(lang.L("Удалить файл %s?"), a.FileName), func(ok bool) {
+		if !ok {
+			return
+		}
+		if err := database.DeleteAttachment(a.ID); err != nil {
+			showError(err, g.window)
+			return
+		}
+		g.changed()
+	}, g.window)
+}
+
+func (g *AttachmentGallery) changed() {
+	g.Reload()
+	if g.onChange != nil {
+		g.onChange()
+	}
+}
+
+// formatFileSize возвращает размер файла в читаемом виде
+func formatFileSize(size int64) string {
+	switch {
+	case size >= 1<<20:
+		return formatNumber(float64(size)/(1<<20), 1) + " " + lang.L("МБ")
+	case size >= 1<<10:
+		return formatNumber(float64(size)/(1<<10), 0) + " " + lang.L("КБ")
+	default:
+		return formatInt(size) + " " + lang.L("Б")
+	}
+}
@@ -0,0 +1,322 @@
+package gui
+
+import (
+	"encoding/csv"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"SanWarehouse/database"
+	"SanWarehouse/models"
+)
+
+// auditEntityTitles - названия сущностей журнала по именам таблиц. Названия здесь и ниже
+// переводятся при отображении.
+var auditEntityTitles = map[string]string{
+	"products":        "Товар",
+	"suppliers":       "Поставщик",
+	"categories":      "Категория",
+	"brands":          "Бренд",
+	"purchase_orders": "Заказ поставщику",
+	"reservations":    "Резерв",
+	"attachments":     "Файл",
+	"exchange_rates":  "Курс валюты",
+	"users":           "Пользователь",
+}
+
+// auditFieldTitles - названия полей журнала по именам колонок
+var auditFieldTitles = map[string]string{
+	"sku":               "SKU",
+	"name":              "Наименование",
+	"category":          "Категория",
+	"category_id":       "Категория (ID)",
+	"brand":             "Бренд",
+	"brand_id":          "Бренд (ID)",
+	"description":       "Описание",
+	"quantity":          "Количество",
+	"reserved_quantity": "Зарезервировано",
+	"purchase_price":    "Закупочная цена",
+	"purchase_currency": "Валюта закупки",
+	"selling_price":     "Цена продажи",
+	"min_stock_level":   "Мин. уровень",
+	"reorder_point":     "Точка заказа",
+	"reorder_quantity":  "Размер партии заказа",
+	"max_stock_level":   "Макс. уровень",
+	"lead_time_days":    "Срок поставки (дней)",
+	"supplier_id":       "Поставщик (ID)",
+	"location":          "Расположение",
+	"status":            "Статус",
+	"weight":            "Вес (кг)",
+	"dimensions":        "Габариты",
+	"material":          "Материал",
+	"marketplace_id":    "ID на маркетплейсе",
+	"is_active":         "Активен",
+	"path":              "Путь",
+	"parent_id":         "Родитель (ID)",
+	"number":            "Номер",
+	"customer":          "Клиент",
+	"reference":         "Документ",
+	"expires_at":        "Действует до",
+	"username":          "Пользователь",
+	"full_name":         "Имя",
+	"password_hash":     "Пароль",
+	"role":              "Роль",
+	"disabled":          "Заблокирован",
+	"currency":          "Валюта",
+	"date":              "Дата",
+	"rate":              "Курс",
+}
+
+const (
+	auditAllEntities = "Все объекты"
+	auditAllActions  = "Все действия"
+	auditAllUsers    = "Все пользователи"
+	auditAllFields   = "Все поля"
+	// auditSystemUser - действия без входа: команды командной строки и служебные операции
+	auditSystemUser = "система"
+	// auditViewLimit - сколько записей журнала показывается в окне; экспорт выгружает все
+	auditViewLimit = 1000
+)
+
+var auditPeriods = []struct {
+	title string
+	days  int
+}{
+	{"За сегодня", 1},
+	{"За 7 дней", 7},
+	{"За 30 дней", 30},
+	{"За все время", 0},
+}
+
+func auditEntityTitle(entity string) string {
+	if title, ok := auditEntityTitles[entity]; ok {
+		return lang.L(title)
+	}
+	return entity
+}
+
+func auditFieldTitle(field string) string {
+	if title, ok := auditFieldTitles[field]; ok {
+		return lang.L(title)
+	}
+	return field
+}
+
+// auditValueText возвращает значение поля для показа: статусы и роли - названиями
+func auditValueText(field, v string) string {
+	switch v {
+	case "":
+		return "—"
+	case "true":
+		return lang.L("Да")
+	case "false":
+		return lang.L("Нет")
+	}
+	switch field {
+	case "status":
+		return lang.L(models.ProductStatus(v).Title())
+	case "role":
+		return lang.L(models.Role(v).Title())
+	}
+	return v
+}
+
+var auditHeaders = []string{"Дата", "Пользователь", "Действие", "Объект", "Поле", "Было", "Стало"}
+
+// auditRows разворачивает записи журнала в строки таблицы: по строке на измененное поле.
+// Без права просмотра закупочных цен их изменения не показываются.
+func auditRows(entries []models.AuditEntry) [][]string {
+	showPurchase := database.Can(models.PermViewPurchasePrices)
+
+	var rows [][]string
+	for _, e := range entries {
+		user := e.Username
+		if user == "" {
+			user = lang.L(auditSystemUser)
+		}
+		object := auditEntityTitle(e.Entity) + " " + e.EntityLabel
+		row := func(field, oldValue, newValue string) []string {
+			return []string{formatTimestamp(e.CreatedAt), user, lang.L(e.Action.Title()), object, field, oldValue, newValue}
+		}
+
+		shown := 0
+		for _, c := range e.Changes {
+			if (c.Field == "purchase_price" || c.Field == "purchase_currency") && !showPurchase {
+				continue
+			}
+			rows = append(rows, row(auditFieldTitle(c.Field), auditValueText(c.Field, c.OldValue), auditValueText(c.Field, c.NewValue)))
+			shown++
+		}
+		if shown == 0 {
+			rows = append(rows, row("—", "—", "—"))
+		}
+	}
+	return rows
+}
+
+// AuditLog - окно журнала изменений с отбором и выгрузкой в CSV
+type AuditLog struct {
+	mainWindow *MainWindow
+	window     fyne.Window
+
+	search       *widget.Entry
+	entitySelect *widget.Select
+	actionSelect *widget.Select
+	userSelect   *widget.Select
+	fieldSelect  *widget.Select
+	periodSelect *widget.Select
+
+	rows    [][]string
+	table   *widget.Table
+	summary *widget.Label
+}
+
+func NewAuditLog(mw *MainWindow) *AuditLog {
+	return &AuditLog{mainWindow: mw}
+}
+
+// Show открывает окно журнала изменений
+func (a *AuditLog) Show() {
+	a.window = a.mainWindow.app.NewWindow(lang.L("Журнал изменений"))
+	a.window.Resize(fyne.NewSize(1150, 650))
+
+	reload := func(string) { a.reload() }
+
+	a.search = widget.NewEntry()
+	a.search.SetPlaceHolder(lang.L("SKU, название, пользователь или значение"))
+	a.search.OnSubmitted = reload
+
+	entities := []string{lang.L(auditAllEntities)}
+	for _, title := range auditEntityTitles {
+		entities = append(entities, lang.L(title))
+	}
+	sort.Strings(entities[1:])
+	a.entitySelect = widget.NewSelect(entities, reload)
+	a.entitySelect.SetSelectedIndex(0)
+
+	actions := []string{lang.L(auditAllActions)}
+	for _, action := range models.AuditActions {
+		actions = append(actions, lang.L(action.Title()))
+	}
+	a.actionSelect = widget.NewSelect(actions, reload)
+	a.actionSelect.SetSelectedIndex(0)
+
+	a.userSelect = widget.NewSelect(append([]string{lang.L(auditAllUsers)}, database.AuditUsernames()...), reload)
+	a.userSelect.SetSelectedIndex(0)
+
+	fields := []string{lang.L(auditAllFields)}
+	for _, title := range auditFieldTitles {
+		fields = append(fields, lang.L(title))
+	}
+	sort.Strings(fields[1:])
+	// В переводе разные поля могут называться одинаково
+	fields = slices.Compact(fields)
+	a.fieldSelect = widget.NewSelect(fields, reload)
+	a.fieldSelect.SetSelectedIndex(0)
+
+	a.periodSelect = widget.NewSelect(nil, reload)
+	for _, p := range auditPeriods {
+		a.periodSelect.Options = append(a.periodSelect.Options, lang.L(p.title))
+	}
+	a.periodSelect.SetSelectedIndex(1)
+
+	a.summary = widget.NewLabel("")
+	a.table = newTextTable(translateAll(auditHeaders), []float32{150, 120, 100, 220, 160, 170, 170}, &a.rows)
+
+	searchBtn := widget.NewButtonWithIcon("", theme.SearchIcon(), a.reload)
+	exportBtn := widget.NewButtonWithIcon(lang.L("Экспорт в CSV"), theme.DownloadIcon(), a.exportCSV)
+
+	filters := container.NewVBox(
+		container.NewBorder(nil, nil, nil, searchBtn, a.search),
+		container.NewHBox(a.entitySelect, a.actionSelect, a.userSelect, a.fieldSelect, a.periodSelect),
+	)
+	a.window.SetContent(container.NewBorder(
+		filters,
+		container.NewBorder(nil, nil, nil, exportBtn, a.summary),
+		nil, nil,
+		a.table,
+	))
+	a.reload()
+	a.window.Show()
+}
+
+// filter собирает условия отбора из элементов окна
+func (a *AuditLog) filter() database.AuditFilter {
+	f := database.AuditFilter{Search: strings.TrimSpace(a.search.Text)}
+
+	for entity, title := range auditEntityTitles {
+		if lang.L(title) == a.entitySelect.Selected {
+			f.Entity = entity
+		}
+	}
+	for _, action := range models.AuditActions {
+		if lang.L(action.Title()) == a.actionSelect.Selected {
+			f.Action = action
+		}
+	}
+	if a.userSelect.SelectedIndex() > 0 {
+		f.Username = a.userSelect.Selected
+	}
+	// Одно название может соответствовать полям разных сущностей
+	for field, title := range auditFieldTitles {
+		if lang.L(title) == a.fieldSelect.Selected {
+			f.Fields = append(f.Fields, field)
+		}
+	}
+	if i := a.periodSelect.SelectedIndex(); i >= 0 {
+		if p := auditPeriods[i]; p.days > 0 {
+			now := time.Now()
+			f.Since = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).AddDate(0, 0, 1-p.days)
+		}
+	}
+	return f
+}
+
+// reload перечитывает журнал по текущему отбору
+func (a *AuditLog) reload() {
+	if a.table == nil {
+		return
+	}
+	entries, total := database.AuditEntries(a.filter(), auditViewLimit)
+	a.rows = auditRows(entries)
+	if total > int64(len(entries)) {
+		a.summary.SetText(fmt.Sprintf(lang.L("Показаны последние %d из %d записей"), len(entries), total))
+	} else {
+		a.summary.SetText(fmt.Sprintf(lang.L("Записей: %d"), total))
+	}
+	a.table.ScrollToTop()
+	a.table.Refresh()
+}
+
+// exportCSV выгружает все записи журнала по текущему отбору
+func (a *AuditLog) exportCSV() {
+	save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil || writer == nil {
+			return
+		}
+		defer writer.Close()
+
+		entries, _ := database.AuditEntries(a.filter(), 0)
+		w := csv.NewWriter(writer)
+		w.Write(translateAll(auditHeaders))
+		w.WriteAll(auditRows(entries))
+		if err := w.Error(); err != nil {
+			showError(err, a.window)
+			return
+		}
+		dialog.ShowInformation(lang.L("Экспорт завершен"), fmt.Sprintf(lang.L("Выгружено записей журнала: %d"), len(entries)), a.window)
+	}, a.window)
+	save.SetFileName(fmt.Sprintf("audit_%s.csv", time.Now().Format("20060102")))
+	save.SetFilter(storage.NewExtensionFileFilter([]string{".csv"}))
+	save.Show()
+}
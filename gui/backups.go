@@ -0,0 +1,377 @@
+package gui
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"SanWarehouse/config"
+	"SanWarehouse/database"
+	"SanWarehouse/models"
+)
+
+// backupCheckInterval - как часто проверяется, не пора ли создать копию по расписанию
+const backupCheckInterval = 10 * time.Minute
+
+// backupIntervals - варианты периода автоматического копирования; названия переводятся при отображении
+var backupIntervals = []struct {
+	title string
+	hours int
+}{
+	{"Отключено", 0},
+	{"Каждый час", 1},
+	{"Каждые 6 часов", 6},
+	{"Ежедневно", 24},
+	{"Еженедельно", 24 * 7},
+}
+
+// createAutoBackup создает автоматическую копию и удаляет устаревшие
+func createAutoBackup(settings config.Backup, kind database.BackupKind) (*database.Backup, error) {
+	backup, err := database.CreateBackup(settings.Dir, kind)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := database.RotateBackups(settings.Dir, settings.Keep); err != nil {
+		return backup, models.Errorf("не удалось удалить старые копии: %w", err)
+	}
+	return backup, nil
+}
+
+// runBackupSchedule создает копии по расписанию, пока открыто приложение
+func (mw *MainWindow) runBackupSchedule() {
+	if !database.BackupSupported() {
+		return
+	}
+	for {
+		var settings config.Backup
+		fyne.DoAndWait(func() { settings = mw.config.Backup })
+
+		if database.BackupDue(settings.Dir, time.Duration(settings.IntervalHours)*time.Hour) {
+			backup, err := createAutoBackup(settings, database.BackupScheduled)
+			fyne.Do(func() {
+				if err != nil {
+					log.Println("Резервное копирование:", err)
+					mw.statusBar.SetText(fmt.Sprintf(lang.L("Ошибка резервного копирования: %v"), errorText(err)))
+					return
+				}
+				mw.statusBar.SetText(fmt.Sprintf(lang.L("Создана резервная копия %s"), filepath.Base(backup.Path)))
+			})
+		}
+		time.Sleep(backupCheckInterval)
+	}
+}
+
+// backupOnExit создает копию при выходе, если это включено в настройках
+func (mw *MainWindow) backupOnExit() {
+	if !mw.config.Backup.OnExit || !database.BackupSupported() {
+		return
+	}
+	if _, err := createAutoBackup(mw.config.Backup, database.BackupOnExit); err != nil {
+		log.Println("Резервное копирование при выходе:", err)
+	}
+}
+
+// BackupManager - окно резервных копий: настройки, создание, проверка и восстановление,
+// а также выгрузка и загрузка снимка данных в JSON
+type BackupManager struct {
+	mainWindow *MainWindow
+	window     fyne.Window
+
+	backups  []database.Backup
+	rows     [][]string
+	table    *widget.Table
+	selected int
+}
+
+func NewBackupManager(mw *MainWindow) *BackupManager {
+	return &BackupManager{mainWindow: mw, selected: -1}
+}
+
+// Show открывает окно резервных копий
+func (m *BackupManager) Show() {
+	m.window = m.mainWindow.app.NewWindow(lang.L("Резервные копии"))
+	m.window.Resize(fyne.NewSize(800, 520))
+
+	// Снимок JSON переносит данные в другую базу, в том числе PostgreSQL
+	snapshot := container.NewHBox(
+		widget.NewButtonWithIcon(lang.L("Выгрузить в JSON..."), theme.DownloadIcon(), m.exportSnapshot),
+		widget.NewButtonWithIcon(lang.L("Загрузить из JSON..."), theme.UploadIcon(), m.importSnapshot),
+	)
+	if !database.BackupSupported() {
+		hint := widget.NewLabel(lang.L("Резервное копирование доступно только для базы SQLite; базу PostgreSQL копируйте с помощью pg_dump. " +
+			"Перенести данные в другую базу можно через снимок JSON."))
+		hint.Wrapping = fyne.TextWrapWord
+		m.window.SetContent(container.NewVBox(hint, snapshot))
+		m.window.Show()
+		return
+	}
+
+	headers := translateAll([]string{"Дата", "Вид", "Размер", "Файл"})
+	m.table = newTextTable(headers, []float32{150, 170, 90, 340}, &m.rows)
+	m.table.OnSelected = func(id widget.TableCellID) {
+		m.selected = id.Row - 1
+	}
+	m.reload()
+
+	createBtn := widget.NewButtonWithIcon(lang.L("Создать копию"), theme.DocumentSaveIcon(), m.create)
+	checkBtn := widget.NewButtonWithIcon(lang.L("Проверить"), theme.ConfirmIcon(), func() {
+		if b := m.selectedBackup(); b != nil {
+			m.check(b.Path)
+		}
+	})
+	restoreBtn := widget.NewButtonWithIcon(lang.L("Восстановить"), theme.HistoryIcon(), func() {
+		if b := m.selectedBackup(); b != nil {
+			m.confirmRestore(b.Path)
+		}
+	})
+	fromFileBtn := widget.NewButtonWithIcon(lang.L("Восстановить из файла..."), theme.FolderOpenIcon(), m.restoreFromFile)
+
+	m.window.SetContent(container.NewBorder(
+		m.settingsForm(),
+		container.NewVBox(container.NewHBox(createBtn, checkBtn, restoreBtn, fromFileBtn), snapshot),
+		nil, nil,
+		m.table,
+	))
+	m.window.Show()
+}
+
+// settingsForm - настройки автоматического копирования
+func (m *BackupManager) settingsForm() fyne.CanvasObject {
+	settings := m.mainWindow.config.Backup
+
+	dir := widget.NewEntry()
+	dir.SetText(settings.Dir)
+
+	titles := make([]string, len(backupIntervals))
+	for i, b := range backupIntervals {
+		titles[i] = lang.L(b.title)
+	}
+	interval := widget.NewSelect(titles, nil)
+	// Период, заданный в файле настроек вручную, сохраняется, пока не выбран другой
+	interval.PlaceHolder = fmt.Sprintf(lang.L("Каждые %d ч"), settings.IntervalHours)
+	for i, b := range backupIntervals {
+		if b.hours == settings.IntervalHours {
+			interval.SetSelectedIndex(i)
+		}
+	}
+
+	keep := widget.NewEntry()
+	keep.SetText(strconv.Itoa(settings.Keep))
+	onExit := widget.NewCheck(lang.L("Создавать копию при выходе"), nil)
+	onExit.SetChecked(settings.OnExit)
+
+	saveBtn := widget.NewButton(lang.L("Сохранить настройки"), func() {
+		updated := settings
+		updated.Dir = strings.TrimSpace(dir.Text)
+		if updated.Dir == "" {
+			dialog.ShowInformation(lang.L("Резервные копии"), lang.L("Укажите каталог копий"), m.window)
+			return
+		}
+		if i := interval.SelectedIndex(); i >= 0 {
+			updated.IntervalHours = backupIntervals[i].hours
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(keep.Text))
+		if err != nil || n < 0 {
+			dialog.ShowInformation(lang.L("Резервные копии"), lang.L("Количество хранимых копий - целое число не меньше нуля"), m.window)
+			return
+		}
+		updated.Keep = n
+		updated.OnExit = onExit.Checked
+
+		previous := m.mainWindow.config.Backup
+		m.mainWindow.config.Backup = updated
+		if err := m.mainWindow.config.Save(); err != nil {
+			m.mainWindow.config.Backup = previous
+			showError(err, m.window)
+			return
+		}
+		settings = updated
+		m.reload()
+		m.mainWindow.statusBar.SetText(lang.L("Настройки резервного копирования сохранены"))
+	})
+
+	form := widget.NewForm(
+		widget.NewFormItem(lang.L("Каталог копий"), dir),
+		widget.NewFormItem(lang.L("Автоматически"), interval),
+		widget.NewFormItem(lang.L("Хранить копий"), keep),
+		widget.NewFormItem("", onExit),
+	)
+	hint := widget.NewLabel(lang.L("Хранится указанное число последних автоматических копий; копии, созданные вручную, не удаляются (0 - хранить все)."))
+	hint.Wrapping = fyne.TextWrapWord
+	return container.NewVBox(form, hint, container.NewHBox(saveBtn), widget.NewSeparator())
+}
+
+func (m *BackupManager) reload() {
+	backups, err := database.ListBackups(m.mainWindow.config.Backup.Dir)
+	if err != nil {
+		showError(err, m.window)
+	}
+	m.backups = backups
+	m.rows = m.rows[:0]
+	for _, b := range backups {
+		m.rows = append(m.rows, []string{
+			formatTimestamp(b.CreatedAt),
+			lang.L(b.Kind.Title()),
+			formatNumber(float64(b.Size)/(1<<20), 1) + " " + lang.L("МБ"),
+			filepath.Base(b.Path),
+		})
+	}
+	m.table.UnselectAll()
+	m.selected = -1
+	m.table.Refresh()
+}
+
+func (m *BackupManager) selectedBackup() *database.Backup {
+	if m.selected < 0 || m.selected >= len(m.backups) {
+		dialog.ShowInformation(lang.L("Резервные копии"), lang.L("Выберите копию"), m.window)
+		return nil
+	}
+	return &m.backups[m.selected]
+}
+
+// create создает копию вручную; такие копии не удаляются при ротации
+func (m *BackupManager) create() {
+	backup, err := database.CreateBackup(m.mainWindow.config.Backup.Dir, database.BackupManual)
+	if err != nil {
+		showError(err, m.window)
+		return
+	}
+	m.reload()
+	m.mainWindow.statusBar.SetText(fmt.Sprintf(lang.L("Создана резервная копия %s"), filepath.Base(backup.Path)))
+}
+
+// check проверяет целостность копии
+func (m *BackupManager) check(path string) {
+	if err := database.CheckBackup(path); err != nil {
+		showError(err, m.window)
+		return
+	}
+	dialog.ShowInformation(lang.L("Проверка копии"), fmt.Sprintf(lang.L("Копия исправна: %s"), filepath.Base(path)), m.window)
+}
+
+// restoreFromFile восстанавливает базу из файла, выбранного пользователем
+func (m *BackupManager) restoreFromFile() {
+	open := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil || reader == nil {
+			return
+		}
+		reader.Close()
+		m.confirmRestore(reader.URI().Path())
+	}, m.window)
+	open.SetFilter(storage.NewExtensionFileFilter([]string{".db"}))
+	open.Show()
+}
+
+// confirmRestore проверяет копию и после подтверждения заменяет ею базу.
+// После восстановления нужно войти заново: учетные записи в копии могут отличаться.
+func (m *BackupManager) confirmRestore(path string) {
+	if !m.mainWindow.requirePermission(models.PermManageBackups) {
+		return
+	}
+	if err := database.CheckBackup(path); err != nil {
+		showError(err, m.window)
+		return
+	}
+
+	message := fmt.Sprintf(lang.L("Заменить базу копией %s?\n\nВсе изменения после создания копии будут потеряны. "+
+		"Текущая база предварительно сохраняется в каталог копий. После восстановления нужно войти заново."), filepath.Base(path))
+	if !database.BackupHasFiles(path) {
+		message += "\n\n" + lang.L("Файлы товаров в копии не сохранены: текущие файлы останутся, "+
+			"у восстановленных товаров часть изображений и документов может не открыться.")
+	}
+	dialog.ShowConfirm(lang.L("Восстановление базы"), message, func(ok bool) {
+		if !ok {
+			return
+		}
+		current, err := database.RestoreBackup(path, m.mainWindow.config.Backup.Dir)
+		if err != nil {
+			showError(err, m.window)
+			return
+		}
+		mw := m.mainWindow
+		mw.logout()
+		dialog.ShowInformation(lang.L("Восстановление базы"),
+			fmt.Sprintf(lang.L("База восстановлена из %s.\nПрежняя база сохранена в %s"), filepath.Base(path), current.Path), mw.window)
+	}, m.window)
+}
+
+// exportSnapshot выгружает все данные базы в файл снимка JSON Lines
+func (m *BackupManager) exportSnapshot() {
+	save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil || writer == nil {
+			return
+		}
+		defer writer.Close()
+
+		counts, err := database.ExportSnapshot(writer)
+		if err != nil {
+			showError(err, m.window)
+			return
+		}
+		dialog.ShowInformation(lang.L("Выгрузка завершена"),
+			fmt.Sprintf(lang.L("Выгружено записей: %d\n%s\n\nФайлы товаров (%s) копируйте отдельно."), counts.Total(), counts, database.AttachmentsDir), m.window)
+	}, m.window)
+	save.SetFileName(fmt.Sprintf("warehouse-%s.jsonl", time.Now().Format("20060102")))
+	save.SetFilter(storage.NewExtensionFileFilter([]string{".jsonl"}))
+	save.Show()
+}
+
+// importSnapshot заменяет данные базы снимком JSON Lines после подтверждения. Перед загрузкой
+// база SQLite сохраняется в каталог копий. После загрузки нужно войти заново.
+func (m *BackupManager) importSnapshot() {
+	if !m.mainWindow.requirePermission(models.PermManageBackups) {
+		return
+	}
+	open := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil || reader == nil {
+			return
+		}
+
+		message := fmt.Sprintf(lang.L("Заменить все данные базы данными из снимка %s?\n\n"+
+			"Записи получат новые номера (ID). После загрузки нужно войти заново."), reader.URI().Name())
+		if database.BackupSupported() {
+			message += "\n" + lang.L("Текущая база предварительно сохраняется в каталог копий.")
+		}
+		dialog.ShowConfirm(lang.L("Загрузка снимка"), message, func(ok bool) {
+			defer reader.Close()
+			if !ok {
+				return
+			}
+
+			var current *database.Backup
+			if database.BackupSupported() {
+				current, err = database.CreateBackup(m.mainWindow.config.Backup.Dir, database.BackupBeforeRestore)
+				if err != nil {
+					showError(models.Errorf("не удалось сохранить текущую базу: %w", err), m.window)
+					return
+				}
+			}
+			counts, err := database.ImportSnapshot(reader, true)
+			if err != nil {
+				showError(err, m.window)
+				return
+			}
+
+			info := fmt.Sprintf(lang.L("Загружено записей: %d\n%s"), counts.Total(), counts)
+			if current != nil {
+				info += "\n" + fmt.Sprintf(lang.L("Прежняя база сохранена в %s"), current.Path)
+			}
+			mw := m.mainWindow
+			mw.logout()
+			dialog.ShowInformation(lang.L("Загрузка снимка"), info, mw.window)
+		}, m.window)
+	}, m.window)
+	open.SetFilter(storage.NewExtensionFileFilter([]string{".jsonl", ".json"}))
+	open.Show()
+}
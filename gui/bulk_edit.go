@@ -0,0 +1,348 @@
+package gui
+
+import (
+	"fmt"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"SanWarehouse/database"
+	"SanWarehouse/models"
+)
+
+// Названия операций массового изменения; названия здесь и ниже переводятся при отображении
+const (
+	bulkKeepTitle    = "Не менять"
+	bulkSetTitle     = "Установить"
+	bulkClearTitle   = "Очистить"
+	bulkPercentTitle = "Изменить на %"
+)
+
+var bulkOpTitles = map[string]models.BulkOp{
+	bulkSetTitle:     models.BulkSet,
+	bulkClearTitle:   models.BulkClear,
+	bulkPercentTitle: models.BulkPercent,
+}
+
+// bulkFieldDef описывает строку диалога массового изменения
+type bulkFieldDef struct {
+	field models.BulkField
+	title string
+	ops   []string
+	value func(p *models.Product) string
+}
+
+var bulkFields = []bulkFieldDef{
+	{models.BulkCategory, "Категория", []string{bulkSetTitle},
+		func(p *models.Product) string { return p.Category }},
+	{models.BulkBrand, "Бренд", []string{bulkSetTitle, bulkClearTitle},
+		func(p *models.Product) string { return p.Brand }},
+	{models.BulkLocation, "Расположение", []string{bulkSetTitle, bulkClearTitle},
+		func(p *models.Product) string { return p.Location }},
+	{models.BulkMaterial, "Материал", []string{bulkSetTitle, bulkClearTitle},
+		func(p *models.Product) string { return p.Material }},
+	{models.BulkMinStock, "Мин. запас", []string{bulkSetTitle, bulkClearTitle},
+		func(p *models.Product) string { return strconv.Itoa(p.MinStockLevel) }},
+	{models.BulkActive, "Активен", []string{bulkSetTitle},
+		func(p *models.Product) string { return yesNo(p.IsActive) }},
+	{models.BulkPurchasePrice, "Закупочная цена", []string{bulkSetTitle, bulkPercentTitle, bulkClearTitle},
+		func(p *models.Product) string { return formatPrice(p.PurchasePrice, p.PurchaseCurrency) }},
+	{models.BulkSellingPrice, "Цена продажи", []string{bulkSetTitle, bulkPercentTitle, bulkClearTitle},
+		func(p *models.Product) string { return formatMoney(p.SellingPrice) }},
+}
+
+func yesNo(b bool) string {
+	if b {
+		return lang.L("Да")
+	}
+	return lang.L("Нет")
+}
+
+// bulkPreviewRow - одно изменяемое значение в предпросмотре
+type bulkPreviewRow struct {
+	sku      string
+	name     string
+	field    string
+	oldValue string
+	newValue string
+}
+
+// BulkEdit - окно массового изменения товаров
+type BulkEdit struct {
+	mainWindow *MainWindow
+	window     fyne.Window
+
+	scope      *widget.RadioGroup
+	opSelects  []*widget.Select
+	values     []*widget.Entry
+	activeFlag *widget.Select
+
+	preview []bulkPreviewRow
+	table   *widget.Table
+	summary *widget.Label
+}
+
+// Варианты области применения
+const (
+	bulkScopeSelected = "Выделенные товары"
+	bulkScopeListed   = "Все товары в списке"
+)
+
+func NewBulkEdit(mw *MainWindow) *BulkEdit {
+	return &BulkEdit{mainWindow: mw}
+}
+
+// Show открывает окно массового изменения
+func (b *BulkEdit) Show() {
+	selected := len(b.mainWindow.productList.SelectedIDs())
+	listed := len(b.mainWindow.productList.ListedIDs())
+	if listed == 0 {
+		dialog.ShowInformation(lang.L("Массовое изменение"), lang.L("В списке нет товаров"), b.mainWindow.window)
+		return
+	}
+
+	b.window = b.mainWindow.app.NewWindow(lang.L("Массовое изменение товаров"))
+	b.window.Resize(fyne.NewSize(900, 650))
+
+	b.scope = widget.NewRadioGroup([]string{
+		fmt.Sprintf("%s (%d)", lang.L(bulkScopeSelected), selected),
+		fmt.Sprintf("%s (%d)", lang.L(bulkScopeListed), listed),
+	}, func(string) { b.updatePreview() })
+	b.scope.Horizontal = true
+	if selected > 0 {
+		b.scope.SetSelected(b.scope.Options[0])
+	} else {
+		b.scope.SetSelected(b.scope.Options[1])
+		b.scope.Disable()
+	}
+
+	// Строки полей: название, операция, значение
+	grid := container.NewGridWithColumns(3)
+	for _, def := range bulkFields {
+		var valueWidget fyne.CanvasObject
+		entry := widget.NewEntry()
+		entry.OnChanged = func(string) { b.updatePreview() }
+		valueWidget = entry
+		if def.field == models.BulkCategory || def.field == models.BulkBrand {
+			// Категория и бренд выбираются из справочников
+			values := database.CategoryPaths()
+			if def.field == models.BulkBrand {
+				values = database.BrandNames()
+			}
+			lookup := newLookupEntry(values)
+			filter := lookup.OnChanged
+			lookup.OnChanged = func(s string) {
+				filter(s)
+				b.updatePreview()
+			}
+			entry = &lookup.Entry
+			valueWidget = lookup
+		}
+		entry.Disable()
+		if def.field == models.BulkActive {
+			b.activeFlag = widget.NewSelect([]string{yesNo(true), yesNo(false)}, func(string) { b.updatePreview() })
+			b.activeFlag.SetSelectedIndex(0)
+			b.activeFlag.Disable()
+			valueWidget = b.activeFlag
+		}
+
+		opSelect := widget.NewSelect(translateAll(append([]string{bulkKeepTitle}, def.ops...)), nil)
+		opSelect.OnChanged = func(op string) {
+			enabled := op == lang.L(bulkSetTitle) || op == lang.L(bulkPercentTitle)
+			if def.field == models.BulkActive {
+				setEnabled(b.activeFlag, enabled)
+			} else {
+				setEnabled(entry, enabled)
+			}
+			b.updatePreview()
+		}
+		opSelect.SetSelectedIndex(0)
+		// Цены меняет только роль с правом изменения цен
+		if (def.field == models.BulkPurchasePrice || def.field == models.BulkSellingPrice) && !database.Can(models.PermEditPrices) {
+			opSelect.Disable()
+		}
+
+		b.opSelects = append(b.opSelects, opSelect)
+		b.values = append(b.values, entry)
+		grid.Add(widget.NewLabel(lang.L(def.title)))
+		grid.Add(opSelect)
+		grid.Add(valueWidget)
+	}
+
+	// Предпросмотр изменений
+	headers := translateAll([]string{"SKU", "Наименование", "Поле", "Было", "Станет"})
+	b.table = widget.NewTable(
+		func() (int, int) {
+			return len(b.preview) + 1, len(headers)
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("Template")
+		},
+		func(id widget.TableCellID, obj fyne.CanvasObject) {
+			label := obj.(*widget.Label)
+			if id.Row == 0 {
+				label.TextStyle.Bold = true
+				label.SetText(headers[id.Col])
+				return
+			}
+			label.TextStyle.Bold = false
+
+			row := b.preview[id.Row-1]
+			switch id.Col {
+			case 0:
+				label.SetText(row.sku)
+			case 1:
+				label.SetText(truncate(row.name, 30))
+			case 2:
+				label.SetText(row.field)
+			case 3:
+				label.SetText(row.oldValue)
+			case 4:
+				label.SetText(row.newValue)
+			}
+		})
+	widths := []float32{110, 260, 150, 140, 140}
+	for i, w := range widths {
+		b.table.SetColumnWidth(i, w)
+	}
+
+	b.summary = widget.NewLabel("")
+
+	applyBtn := widget.NewButtonWithIcon(lang.L("Применить"), theme.ConfirmIcon(), b.apply)
+	applyBtn.Importance = widget.HighImportance
+	closeBtn := widget.NewButton(lang.L("Закрыть"), func() { b.window.Close() })
+
+	top := container.NewVBox(
+		container.NewHBox(widget.NewLabel(lang.L("Применить к:")), b.scope),
+		widget.NewCard(lang.L("Изменения"), "", grid),
+	)
+	bottom := container.NewBorder(nil, nil, b.summary, container.NewHBox(applyBtn, closeBtn))
+
+	b.window.SetContent(container.NewBorder(top, bottom, nil, nil, b.table))
+	b.updatePreview()
+	b.window.Show()
+}
+
+func setEnabled(w fyne.Disableable, enabled bool) {
+	if enabled {
+		w.Enable()
+	} else {
+		w.Disable()
+	}
+}
+
+// targetIDs возвращает ID товаров, к которым применяются изменения
+func (b *BulkEdit) targetIDs() []uint {
+	if b.scope.Selected == b.scope.Options[0] {
+		return b.mainWindow.productList.SelectedIDs()
+	}
+	return b.mainWindow.productList.ListedIDs()
+}
+
+// changes собирает изменения из формы
+func (b *BulkEdit) changes() []models.BulkChange {
+	var changes []models.BulkChange
+	for i, def := range bulkFields {
+		// Первый вариант - "Не менять", остальные - операции поля
+		n := b.opSelects[i].SelectedIndex()
+		if n < 1 {
+			continue
+		}
+		op := bulkOpTitles[def.ops[n-1]]
+		value := b.values[i].Text
+		if def.field == models.BulkActive {
+			value = strconv.FormatBool(b.activeFlag.SelectedIndex() == 0)
+		}
+		changes = append(changes, models.BulkChange{Field: def.field, Op: op, Value: value})
+	}
+	return changes
+}
+
+// updatePreview пересчитывает таблицу предпросмотра
+func (b *BulkEdit) updatePreview() {
+	if b.table == nil || b.summary == nil {
+		return
+	}
+	b.preview = b.preview[:0]
+
+	changes := b.changes()
+	ids := b.targetIDs()
+	if len(changes) == 0 || len(ids) == 0 {
+		b.summary.SetText(fmt.Sprintf(lang.L("Товаров: %d. Выберите изменяемые поля"), len(ids)))
+		b.table.Refresh()
+		return
+	}
+
+	products, err := database.ProductsByIDs(ids)
+	if err != nil {
+		b.summary.SetText(fmt.Sprintf(lang.L("Ошибка: %v"), errorText(err)))
+		b.table.Refresh()
+		return
+	}
+
+	affected := 0
+	for _, p := range products {
+		changed := p
+		if err := models.ApplyBulkChanges(&changed, changes); err != nil {
+			b.summary.SetText(fmt.Sprintf(lang.L("Ошибка: %v"), errorText(err)))
+			b.preview = b.preview[:0]
+			b.table.Refresh()
+			return
+		}
+
+		rowChanged := false
+		for _, def := range bulkFields {
+			oldValue, newValue := def.value(&p), def.value(&changed)
+			if oldValue == newValue {
+				continue
+			}
+			b.preview = append(b.preview, bulkPreviewRow{
+				sku:      p.SKU,
+				name:     p.Name,
+				field:    lang.L(def.title),
+				oldValue: oldValue,
+				newValue: newValue,
+			})
+			rowChanged = true
+		}
+		if rowChanged {
+			affected++
+		}
+	}
+
+	b.summary.SetText(fmt.Sprintf(lang.L("Будет изменено товаров: %d из %d"), affected, len(ids)))
+	b.table.Refresh()
+}
+
+// apply сохраняет изменения в одной транзакции
+func (b *BulkEdit) apply() {
+	changes := b.changes()
+	ids := b.targetIDs()
+	if len(changes) == 0 || len(ids) == 0 {
+		dialog.ShowInformation(lang.L("Массовое изменение"), lang.L("Нет изменений для применения"), b.window)
+		return
+	}
+
+	message := fmt.Sprintf(lang.L("Применить изменения к %d товарам?"), len(ids))
+	dialog.ShowConfirm(lang.L("Массовое изменение"), message, func(ok bool) {
+		if !ok {
+			return
+		}
+		revisions, err := database.BulkUpdateProducts(ids, changes)
+		if err != nil {
+			showError(err, b.window)
+			return
+		}
+		b.mainWindow.record(bulkEditCommand(revisions))
+
+		b.mainWindow.productList.RefreshList()
+		b.mainWindow.alerts.Refresh()
+		b.mainWindow.statusBar.SetText(fmt.Sprintf(lang.L("Массово изменено товаров: %d (Ctrl+Z - отменить)"), len(revisions)))
+		b.window.Close()
+	}, b.window)
+}
@@ -0,0 +1,353 @@
+package gui
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"SanWarehouse/database"
+	"SanWarehouse/models"
+)
+
+// maxLookupOptions ограничивает длину выпадающего списка при вводе
+const maxLookupOptions = 50
+
+// newLookupEntry создает поле ввода со списком значений справочника. Список сужается
+// по мере ввода, а новое значение можно ввести вручную - оно будет добавлено в справочник.
+func newLookupEntry(values []string) *widget.SelectEntry {
+	entry := widget.NewSelectEntry(values)
+	entry.OnChanged = func(s string) {
+		entry.SetOptions(filterOptions(values, s))
+	}
+	return entry
+}
+
+// filterOptions возвращает значения, содержащие строку s без учета регистра
+func filterOptions(values []string, s string) []string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	var options []string
+	for _, v := range values {
+		if s == "" || strings.Contains(strings.ToLower(v), s) {
+			options = append(options, v)
+			if len(options) == maxLookupOptions {
+				break
+			}
+		}
+	}
+	return options
+}
+
+// Варианты свертки категорий в отчетах, индекс - уровень (0 - без свертки)
+var categoryLevels = []string{"Без свертки", "До 1 уровня", "До 2 уровня", "До 3 уровня"}
+
+// newCategoryLevelSelect создает выбор уровня, до которого сворачиваются категории в отчете
+func newCategoryLevelSelect(onChange func(level int)) *widget.Select {
+	sel := widget.NewSelect(translateAll(categoryLevels), nil)
+	sel.SetSelectedIndex(0)
+	sel.OnChanged = func(string) {
+		onChange(sel.SelectedIndex())
+	}
+	return sel
+}
+
+// categoryTitle возвращает подпись группы отчета по категориям
+func categoryTitle(g database.CategoryGroup) string {
+	if g.Path == "" {
+		return lang.L("Без категории")
+	}
+	if g.Subtree {
+		return fmt.Sprintf(lang.L("%s (с подкатегориями)"), g.Path)
+	}
+	return g.Path
+}
+
+// CatalogEditor - окно справочников категорий и брендов: переименование, перенос и объединение дубликатов
+type CatalogEditor struct {
+	mainWindow *MainWindow
+	window     fyne.Window
+
+	categories       map[uint]*models.Category
+	categoryChildren map[uint][]uint
+	categoryCounts   map[uint]int64
+	categoryTree     *widget.Tree
+	selectedCategory uint
+
+	brands        []models.Brand
+	brandCounts   map[uint]int64
+	brandList     *widget.List
+	selectedBrand int
+}
+
+func NewCatalogEditor(mw *MainWindow) *CatalogEditor {
+	return &CatalogEditor{mainWindow: mw, selectedBrand: -1}
+}
+
+// Show открывает окно справочников
+func (e *CatalogEditor) Show() {
+	e.window = e.mainWindow.app.NewWindow(lang.L("Справочники"))
+	e.window.Resize(fyne.NewSize(700, 550))
+
+	e.loadCategories()
+	e.loadBrands()
+
+	tabs := container.NewAppTabs(
+		container.NewTabItemWithIcon(lang.L("Категории"), theme.FolderIcon(), e.categoriesTab()),
+		container.NewTabItemWithIcon(lang.L("Бренды"), theme.ListIcon(), e.brandsTab()),
+	)
+
+	e.window.SetContent(tabs)
+	e.window.Show()
+}
+
+// loadCategories перечитывает дерево категорий
+func (e *CatalogEditor) loadCategories() {
+	var categories []models.Category
+	database.DB.Order("name").Find(&categories)
+
+	e.categories = make(map[uint]*models.Category, len(categories))
+	e.categoryChildren = map[uint][]uint{}
+	for i := range categories {
+		c := &categories[i]
+		e.categories[c.ID] = c
+		var parent uint
+		if c.ParentID != nil {
+			parent = *c.ParentID
+		}
+		e.categoryChildren[parent] = append(e.categoryChildren[parent], c.ID)
+	}
+	e.categoryCounts = database.CategoryProductCounts()
+	if e.categories[e.selectedCategory] == nil {
+		e.selectedCategory = 0
+	}
+}
+
+// loadBrands перечитывает список брендов
+func (e *CatalogEditor) loadBrands() {
+	e.brands = nil
+	database.DB.Order("name").Find(&e.brands)
+	e.brandCounts = database.BrandProductCounts()
+	if e.selectedBrand >= len(e.brands) {
+		e.selectedBrand = -1
+	}
+}
+
+// changed обновляет окно и список товаров после изменения справочников
+func (e *CatalogEditor) changed() {
+	e.loadCategories()
+	e.loadBrands()
+	e.categoryTree.Refresh()
+	e.brandList.Refresh()
+	e.mainWindow.productList.RefreshList()
+}
+
+// categoriesTab - дерево категорий с числом товаров
+func (e *CatalogEditor) categoriesTab() fyne.CanvasObject {
+	uid := func(id uint) widget.TreeNodeID {
+		return strconv.FormatUint(uint64(id), 10)
+	}
+	parseUID := func(node widget.TreeNodeID) uint {
+		id, _ := strconv.ParseUint(node, 10, 64)
+		return uint(id)
+	}
+
+	e.categoryTree = widget.NewTree(
+		func(node widget.TreeNodeID) []widget.TreeNodeID {
+			var ids []widget.TreeNodeID
+			for _, id := range e.categoryChildren[parseUID(node)] {
+				ids = append(ids, uid(id))
+			}
+			return ids
+		},
+		func(node widget.TreeNodeID) bool {
+			return node == "" || len(e.categoryChildren[parseUID(node)]) > 0
+		},
+		func(bool) fyne.CanvasObject {
+			return widget.NewLabel("Template")
+		},
+		func(node widget.TreeNodeID, _ bool, obj fyne.CanvasObject) {
+			c := e.categories[parseUID(node)]
+			if c == nil {
+				return
+			}
+			obj.(*widget.Label).SetText(fmt.Sprintf("%s (%d)", c.Name, e.categoryCounts[c.ID]))
+		},
+	)
+	e.categoryTree.OnSelected = func(node widget.TreeNodeID) {
+		e.selectedCategory = parseUID(node)
+	}
+	e.categoryTree.OpenAllBranches()
+
+	selected := func() *models.Category {
+		c := e.categories[e.selectedCategory]
+		if c == nil {
+			dialog.ShowInformation(lang.L("Категории"), lang.L("Выберите категорию"), e.window)
+		}
+		return c
+	}
+
+	renameBtn := widget.NewButtonWithIcon(lang.L("Переименовать или перенести"), theme.DocumentCreateIcon(), func() {
+		c := selected()
+		if c == nil {
+			return
+		}
+		entry := widget.NewEntry()
+		entry.SetText(c.Path)
+		hint := widget.NewLabel(lang.L("Полный путь через \">\": измените последний уровень, чтобы переименовать,\nили предыдущие, чтобы перенести в другую ветку"))
+		dialog.ShowCustomConfirm(fmt.Sprintf(lang.L("Категория %s"), c.Name), lang.L("Сохранить"), lang.L("Отмена"), container.NewVBox(hint, entry), func(ok bool) {
+			if !ok {
+				return
+			}
+			if err := database.RenameCategory(c.ID, entry.Text); err != nil {
+				showError(err, e.window)
+				return
+			}
+			e.changed()
+		}, e.window)
+	})
+
+	mergeBtn := widget.NewButtonWithIcon(lang.L("Объединить с..."), theme.ContentPasteIcon(), func() {
+		c := selected()
+		if c == nil {
+			return
+		}
+		var paths []string
+		ids := map[string]uint{}
+		for _, other := range e.categories {
+			if other.ID != c.ID {
+				paths = append(paths, other.Path)
+				ids[other.Path] = other.ID
+			}
+		}
+		e.showMergeDialog(lang.L("Объединить категорию"), c.Path, paths, func(target string) error {
+			id, ok := ids[target]
+			if !ok {
+				return fmt.Errorf(lang.L("категория %s не найдена"), target)
+			}
+			return database.MergeCategories(c.ID, id)
+		})
+	})
+
+	deleteBtn := widget.NewButtonWithIcon(lang.L("Удалить"), theme.DeleteIcon(), func() {
+		c := selected()
+		if c == nil {
+			return
+		}
+		if err := database.DeleteCategory(c.ID); err != nil {
+			showError(err, e.window)
+			return
+		}
+		e.changed()
+	})
+
+	return container.NewBorder(nil, container.NewHBox(renameBtn, mergeBtn, deleteBtn), nil, nil, e.categoryTree)
+}
+
+// brandsTab - список брендов с числом товаров
+func (e *CatalogEditor) brandsTab() fyne.CanvasObject {
+	e.brandList = widget.NewList(
+		func() int { return len(e.brands) },
+		func() fyne.CanvasObject { return widget.NewLabel("Template") },
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			b := e.brands[id]
+			obj.(*widget.Label).SetText(fmt.Sprintf("%s (%d)", b.Name, e.brandCounts[b.ID]))
+		},
+	)
+	e.brandList.OnSelected = func(id widget.ListItemID) {
+		e.selectedBrand = id
+	}
+
+	selected := func() *models.Brand {
+		if e.selectedBrand < 0 || e.selectedBrand >= len(e.brands) {
+			dialog.ShowInformation(lang.L("Бренды"), lang.L("Выберите бренд"), e.window)
+			return nil
+		}
+		return &e.brands[e.selectedBrand]
+	}
+
+	renameBtn := widget.NewButtonWithIcon(lang.L("Переименовать"), theme.DocumentCreateIcon(), func() {
+		b := selected()
+		if b == nil {
+			return
+		}
+		entry := widget.NewEntry()
+		entry.SetText(b.Name)
+		dialog.ShowCustomConfirm(fmt.Sprintf(lang.L("Бренд %s"), b.Name), lang.L("Сохранить"), lang.L("Отмена"), entry, func(ok bool) {
+			if !ok {
+				return
+			}
+			if err := database.RenameBrand(b.ID, entry.Text); err != nil {
+				showError(err, e.window)
+				return
+			}
+			e.changed()
+		}, e.window)
+	})
+
+	mergeBtn := widget.NewButtonWithIcon(lang.L("Объединить с..."), theme.ContentPasteIcon(), func() {
+		b := selected()
+		if b == nil {
+			return
+		}
+		var names []string
+		ids := map[string]uint{}
+		for _, other := range e.brands {
+			if other.ID != b.ID {
+				names = append(names, other.Name)
+				ids[other.Name] = other.ID
+			}
+		}
+		e.showMergeDialog(lang.L("Объединить бренд"), b.Name, names, func(target string) error {
+			id, ok := ids[target]
+			if !ok {
+				return fmt.Errorf(lang.L("бренд %s не найден"), target)
+			}
+			return database.MergeBrands(b.ID, id)
+		})
+	})
+
+	deleteBtn := widget.NewButtonWithIcon(lang.L("Удалить"), theme.DeleteIcon(), func() {
+		b := selected()
+		if b == nil {
+			return
+		}
+		if err := database.DeleteBrand(b.ID); err != nil {
+			showError(err, e.window)
+			return
+		}
+		e.changed()
+	})
+
+	return container.NewBorder(nil, container.NewHBox(renameBtn, mergeBtn, deleteBtn), nil, nil, e.brandList)
+}
+
+// showMergeDialog выбирает запись, с которой объединяется source, и выполняет merge после подтверждения;
+// title - заголовок диалога
+func (e *CatalogEditor) showMergeDialog(title, source string, targets []string, merge func(target string) error) {
+	sort.Strings(targets)
+	target := newLookupEntry(targets)
+	target.SetPlaceHolder(lang.L("Начните вводить название"))
+
+	content := container.NewVBox(
+		widget.NewLabel(fmt.Sprintf(lang.L("Товары из «%s» будут перенесены в выбранную запись,\nа «%s» будет удалена"), source, source)),
+		target,
+	)
+	dialog.ShowCustomConfirm(title, lang.L("Объединить"), lang.L("Отмена"), content, func(ok bool) {
+		if !ok {
+			return
+		}
+		if err := merge(target.Text); err != nil {
+			showError(err, e.window)
+			return
+		}
+		e.changed()
+		e.mainWindow.statusBar.SetText(fmt.Sprintf(lang.L("«%s» объединено с «%s»"), source, target.Text))
+	}, e.window)
+}
@@ -0,0 +1,549 @@
+package gui
+
+import (
+	"fmt"
+	"image/color"
+	"image/png"
+	"math"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/driver/software"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// ChartPoint - точка данных графика
+type ChartPoint struct {
+	Label string
+	Value float64
+}
+
+type ChartKind int
+
+const (
+	BarChart ChartKind = iota
+	PieChart
+	LineChart
+)
+
+// Отступы области построения от краев графика
+const (
+	chartTitleHeight  float32 = 30
+	chartAxisWidth    float32 = 70
+	chartLabelsHeight float32 = 30
+	chartPadding      float32 = 10
+	chartLegendWidth  float32 = 200
+)
+
+var chartPalette = []color.Color{
+	&color.NRGBA{R: 66, G: 133, B: 244, A: 255},
+	&color.NRGBA{R: 219, G: 68, B: 55, A: 255},
+	&color.NRGBA{R: 244, G: 180, B: 0, A: 255},
+	&color.NRGBA{R: 15, G: 157, B: 88, A: 255},
+	&color.NRGBA{R: 171, G: 71, B: 188, A: 255},
+	&color.NRGBA{R: 0, G: 172, B: 193, A: 255},
+	&color.NRGBA{R: 255, G: 112, B: 67, A: 255},
+	&color.NRGBA{R: 158, G: 157, B: 36, A: 255},
+	&color.NRGBA{R: 92, G: 107, B: 192, A: 255},
+	&color.NRGBA{R: 240, G: 98, B: 146, A: 255},
+}
+
+// Chart - график (столбчатый, круговой или линейный), нарисованный примитивами canvas
+type Chart struct {
+	widget.BaseWidget
+
+	Kind   ChartKind
+	Title  string
+	Points []ChartPoint
+	// Format форматирует значения для осей и подсказок
+	Format func(float64) string
+
+	hovered  int
+	mousePos fyne.Position
+}
+
+// NewChart создает график указанного типа
+func NewChart(kind ChartKind, title string, points []ChartPoint) *Chart {
+	c := &Chart{
+		Kind:    kind,
+		Title:   title,
+		Points:  points,
+		hovered: -1,
+		Format: func(v float64) string {
+			return formatNumber(v, 0)
+		},
+	}
+	c.ExtendBaseWidget(c)
+	return c
+}
+
+// SetPoints заменяет данные графика
+func (c *Chart) SetPoints(points []ChartPoint) {
+	c.Points = points
+	c.hovered = -1
+	c.Refresh()
+}
+
+func (c *Chart) CreateRenderer() fyne.WidgetRenderer {
+	return &chartRenderer{chart: c}
+}
+
+// MouseIn реализует desktop.Hoverable
+func (c *Chart) MouseIn(ev *desktop.MouseEvent) {
+	c.MouseMoved(ev)
+}
+
+// MouseMoved показывает подсказку для элемента под курсором
+func (c *Chart) MouseMoved(ev *desktop.MouseEvent) {
+	idx := c.pointAt(ev.Position, c.Size())
+	if idx == c.hovered && idx < 0 {
+		return
+	}
+	c.hovered = idx
+	c.mousePos = ev.Position
+	c.Refresh()
+}
+
+// MouseOut скрывает подсказку
+func (c *Chart) MouseOut() {
+	if c.hovered < 0 {
+		return
+	}
+	c.hovered = -1
+	c.Refresh()
+}
+
+// plotArea возвращает область построения графика
+func (c *Chart) plotArea(size fyne.Size) (fyne.Position, fyne.Size) {
+	switch c.Kind {
+	case PieChart:
+		return fyne.NewPos(chartPadding, chartTitleHeight),
+			fyne.NewSize(size.Width-chartLegendWidth-2*chartPadding, size.Height-chartTitleHeight-chartPadding)
+	default:
+		return fyne.NewPos(chartAxisWidth, chartTitleHeight),
+			fyne.NewSize(size.Width-chartAxisWidth-chartPadding, size.Height-chartTitleHeight-chartLabelsHeight)
+	}
+}
+
+// valueRange возвращает границы шкалы значений, всегда включающие ноль
+func (c *Chart) valueRange() (float64, float64) {
+	minV, maxV := 0.0, 0.0
+	for _, p := range c.Points {
+		minV = math.Min(minV, p.Value)
+		maxV = math.Max(maxV, p.Value)
+	}
+	if maxV == minV {
+		maxV = minV + 1
+	}
+	return minV, maxV
+}
+
+// pointAt возвращает индекс точки данных под курсором или -1
+func (c *Chart) pointAt(pos fyne.Position, size fyne.Size) int {
+	n := len(c.Points)
+	if n == 0 {
+		return -1
+	}
+	origin, area := c.plotArea(size)
+
+	switch c.Kind {
+	case BarChart:
+		if pos.X < origin.X || pos.X > origin.X+area.Width || pos.Y < origin.Y || pos.Y > origin.Y+area.Height {
+			return -1
+		}
+		idx := int((pos.X - origin.X) / (area.Width / float32(n)))
+		if idx >= n {
+			idx = n - 1
+		}
+		return idx
+
+	case LineChart:
+		if pos.X < origin.X-chartPadding || pos.X > origin.X+area.Width+chartPadding {
+			return -1
+		}
+		if n == 1 {
+			return 0
+		}
+		step := area.Width / float32(n-1)
+		idx := int(math.Round(float64((pos.X - origin.X) / step)))
+		if idx < 0 {
+			idx = 0
+		}
+		if idx >= n {
+			idx = n - 1
+		}
+		return idx
+
+	case PieChart:
+		center, radius := c.pieGeometry(size)
+		dx := float64(pos.X - center.X)
+		dy := float64(pos.Y - center.Y)
+		if math.Hypot(dx, dy) > float64(radius) {
+			return -1
+		}
+		// 0° - вверх, по часовой стрелке, как у canvas.Arc
+		angle := math.Atan2(dx, -dy) * 180 / math.Pi
+		if angle < 0 {
+			angle += 360
+		}
+		total := c.pieTotal()
+		start := 0.0
+		for i, p := range c.Points {
+			if p.Value <= 0 {
+				continue
+			}
+			end := start + p.Value/total*360
+			if angle >= start && angle < end {
+				return i
+			}
+			start = end
+		}
+	}
+	return -1
+}
+
+func (c *Chart) pieGeometry(size fyne.Size) (fyne.Position, float32) {
+	origin, area := c.plotArea(size)
+	radius := float32(math.Min(float64(area.Width), float64(area.Height))) / 2
+	center := fyne.NewPos(origin.X+area.Width/2, origin.Y+area.Height/2)
+	return center, radius
+}
+
+func (c *Chart) pieTotal() float64 {
+	total := 0.0
+	for _, p := range c.Points {
+		if p.Value > 0 {
+			total += p.Value
+		}
+	}
+	return total
+}
+
+func (c *Chart) color(i int) color.Color {
+	return chartPalette[i%len(chartPalette)]
+}
+
+type chartRenderer struct {
+	chart   *Chart
+	objects []fyne.CanvasObject
+}
+
+func (r *chartRenderer) Destroy() {}
+
+func (r *chartRenderer) Layout(size fyne.Size) {
+	r.build(size)
+}
+
+func (r *chartRenderer) MinSize() fyne.Size {
+	return fyne.NewSize(400, 300)
+}
+
+func (r *chartRenderer) Objects() []fyne.CanvasObject {
+	return r.objects
+}
+
+func (r *chartRenderer) Refresh() {
+	r.build(r.chart.Size())
+	canvas.Refresh(r.chart)
+}
+
+// build перестраивает примитивы графика под текущий размер
+func (r *chartRenderer) build(size fyne.Size) {
+	c := r.chart
+	r.objects = r.objects[:0]
+	if size.Width <= 0 || size.Height <= 0 {
+		return
+	}
+
+	fg := theme.Color(theme.ColorNameForeground)
+
+	bg := canvas.NewRectangle(theme.Color(theme.ColorNameBackground))
+	bg.Resize(size)
+	r.objects = append(r.objects, bg)
+
+	title := canvas.NewText(c.Title, fg)
+	title.TextStyle.Bold = true
+	title.Alignment = fyne.TextAlignCenter
+	title.Move(fyne.NewPos(0, 4))
+	title.Resize(fyne.NewSize(size.Width, chartTitleHeight-8))
+	r.objects = append(r.objects, title)
+
+	if len(c.Points) == 0 {
+		empty := canvas.NewText(lang.L("Нет данных"), fg)
+		empty.Alignment = fyne.TextAlignCenter
+		empty.Move(fyne.NewPos(0, size.Height/2))
+		empty.Resize(fyne.NewSize(size.Width, 20))
+		r.objects = append(r.objects, empty)
+		return
+	}
+
+	switch c.Kind {
+	case BarChart:
+		r.buildAxes(size)
+		r.buildBars(size)
+	case LineChart:
+		r.buildAxes(size)
+		r.buildLine(size)
+	case PieChart:
+		r.buildPie(size)
+	}
+
+	r.buildTooltip(size)
+}
+
+func (r *chartRenderer) valueY(v float64, size fyne.Size) float32 {
+	origin, area := r.chart.plotArea(size)
+	minV, maxV := r.chart.valueRange()
+	return origin.Y + area.Height - float32((v-minV)/(maxV-minV))*area.Height
+}
+
+func (r *chartRenderer) buildAxes(size fyne.Size) {
+	c := r.chart
+	origin, area := c.plotArea(size)
+	fg := theme.Color(theme.ColorNameForeground)
+	grid := theme.Color(theme.ColorNameSeparator)
+
+	minV, maxV := c.valueRange()
+	const ticks = 5
+	for i := 0; i <= ticks; i++ {
+		v := minV + (maxV-minV)*float64(i)/ticks
+		y := r.valueY(v, size)
+
+		line := canvas.NewLine(grid)
+		line.Position1 = fyne.NewPos(origin.X, y)
+		line.Position2 = fyne.NewPos(origin.X+area.Width, y)
+		r.objects = append(r.objects, line)
+
+		label := canvas.NewText(c.Format(v), fg)
+		label.TextSize = theme.CaptionTextSize()
+		label.Alignment = fyne.TextAlignTrailing
+		label.Move(fyne.NewPos(0, y-8))
+		label.Resize(fyne.NewSize(chartAxisWidth-6, 16))
+		r.objects = append(r.objects, label)
+	}
+
+	yAxis := canvas.NewLine(fg)
+	yAxis.Position1 = fyne.NewPos(origin.X, origin.Y)
+	yAxis.Position2 = fyne.NewPos(origin.X, origin.Y+area.Height)
+	r.objects = append(r.objects, yAxis)
+
+	zero := r.valueY(0, size)
+	xAxis := canvas.NewLine(fg)
+	xAxis.Position1 = fyne.NewPos(origin.X, zero)
+	xAxis.Position2 = fyne.NewPos(origin.X+area.Width, zero)
+	r.objects = append(r.objects, xAxis)
+}
+
+// axisLabel добавляет подпись под осью X, обрезая ее по ширине
+func (r *chartRenderer) axisLabel(text string, centerX, width float32, size fyne.Size) {
+	fg := theme.Color(theme.ColorNameForeground)
+	textSize := theme.CaptionTextSize()
+
+	runes := []rune(text)
+	for len(runes) > 1 && fyne.MeasureText(string(runes), textSize, fyne.TextStyle{}).Width > width {
+		runes = runes[:len(runes)-1]
+	}
+	if len(runes) < len([]rune(text)) && len(runes) > 1 {
+		runes = append(runes[:len(runes)-1], '…')
+	}
+
+	label := canvas.NewText(string(runes), fg)
+	label.TextSize = textSize
+	label.Alignment = fyne.TextAlignCenter
+	label.Move(fyne.NewPos(centerX-width/2, size.Height-chartLabelsHeight+6))
+	label.Resize(fyne.NewSize(width, 16))
+	r.objects = append(r.objects, label)
+}
+
+func (r *chartRenderer) buildBars(size fyne.Size) {
+	c := r.chart
+	origin, area := c.plotArea(size)
+	slot := area.Width / float32(len(c.Points))
+	barWidth := slot * 0.7
+	zero := r.valueY(0, size)
+
+	for i, p := range c.Points {
+		y := r.valueY(p.Value, size)
+		top, height := y, zero-y
+		if height < 0 {
+			top, height = zero, -height
+		}
+
+		fill := c.color(i)
+		if i == c.hovered {
+			fill = theme.Color(theme.ColorNameHover)
+		}
+		bar := canvas.NewRectangle(fill)
+		bar.StrokeColor = c.color(i)
+		bar.StrokeWidth = 1
+		x := origin.X + slot*float32(i) + (slot-barWidth)/2
+		bar.Move(fyne.NewPos(x, top))
+		bar.Resize(fyne.NewSize(barWidth, height))
+		r.objects = append(r.objects, bar)
+
+		r.axisLabel(p.Label, x+barWidth/2, slot, size)
+	}
+}
+
+func (r *chartRenderer) buildLine(size fyne.Size) {
+	c := r.chart
+	origin, area := c.plotArea(size)
+	n := len(c.Points)
+	step := float32(0)
+	if n > 1 {
+		step = area.Width / float32(n-1)
+	}
+	lineColor := c.color(0)
+
+	// Подписываем не больше восьми точек по оси X
+	labelEvery := (n + 7) / 8
+	var prev fyne.Position
+	for i, p := range c.Points {
+		pos := fyne.NewPos(origin.X+step*float32(i), r.valueY(p.Value, size))
+		if i > 0 {
+			segment := canvas.NewLine(lineColor)
+			segment.StrokeWidth = 2
+			segment.Position1 = prev
+			segment.Position2 = pos
+			r.objects = append(r.objects, segment)
+		}
+		prev = pos
+
+		if i%labelEvery == 0 {
+			width := step * float32(labelEvery)
+			if width <= 0 {
+				width = area.Width
+			}
+			r.axisLabel(p.Label, pos.X, width, size)
+		}
+	}
+
+	if c.hovered >= 0 && c.hovered < n {
+		radius := float32(5)
+		pos := fyne.NewPos(origin.X+step*float32(c.hovered), r.valueY(c.Points[c.hovered].Value, size))
+		dot := canvas.NewCircle(lineColor)
+		dot.Move(fyne.NewPos(pos.X-radius, pos.Y-radius))
+		dot.Resize(fyne.NewSize(2*radius, 2*radius))
+		r.objects = append(r.objects, dot)
+	}
+}
+
+func (r *chartRenderer) buildPie(size fyne.Size) {
+	c := r.chart
+	center, radius := c.pieGeometry(size)
+	total := c.pieTotal()
+	fg := theme.Color(theme.ColorNameForeground)
+
+	start := float32(0)
+	for i, p := range c.Points {
+		if p.Value <= 0 || total <= 0 {
+			continue
+		}
+		end := start + float32(p.Value/total*360)
+
+		arc := canvas.NewPieArc(start, end, c.color(i))
+		r2 := radius
+		if i == c.hovered {
+			r2 += 6
+		}
+		arc.Move(fyne.NewPos(center.X-r2, center.Y-r2))
+		arc.Resize(fyne.NewSize(2*r2, 2*r2))
+		r.objects = append(r.objects, arc)
+		start = end
+	}
+
+	// Легенда справа от диаграммы
+	legendX := size.Width - chartLegendWidth
+	y := chartTitleHeight
+	for i, p := range c.Points {
+		box := canvas.NewRectangle(c.color(i))
+		box.Move(fyne.NewPos(legendX, y+3))
+		box.Resize(fyne.NewSize(12, 12))
+		r.objects = append(r.objects, box)
+
+		share := 0.0
+		if total > 0 && p.Value > 0 {
+			share = p.Value / total * 100
+		}
+		label := canvas.NewText(fmt.Sprintf("%s (%s%%)", p.Label, formatNumber(share, 1)), fg)
+		label.TextSize = theme.CaptionTextSize()
+		if i == c.hovered {
+			label.TextStyle.Bold = true
+		}
+		label.Move(fyne.NewPos(legendX+18, y))
+		r.objects = append(r.objects, label)
+		y += 18
+	}
+}
+
+func (r *chartRenderer) buildTooltip(size fyne.Size) {
+	c := r.chart
+	if c.hovered < 0 || c.hovered >= len(c.Points) {
+		return
+	}
+	p := c.Points[c.hovered]
+
+	text := fmt.Sprintf("%s: %s", p.Label, c.Format(p.Value))
+	if c.Kind == PieChart {
+		if total := c.pieTotal(); total > 0 {
+			text += fmt.Sprintf(" (%s%%)", formatNumber(p.Value/total*100, 1))
+		}
+	}
+
+	label := canvas.NewText(text, theme.Color(theme.ColorNameForeground))
+	label.TextSize = theme.CaptionTextSize()
+	textSize := fyne.MeasureText(text, label.TextSize, label.TextStyle)
+	boxSize := fyne.NewSize(textSize.Width+12, textSize.Height+8)
+
+	// Не даем подсказке выйти за границы графика
+	pos := c.mousePos.Add(fyne.NewPos(12, 12))
+	if pos.X+boxSize.Width > size.Width {
+		pos.X = size.Width - boxSize.Width
+	}
+	if pos.Y+boxSize.Height > size.Height {
+		pos.Y = c.mousePos.Y - boxSize.Height - 4
+	}
+
+	box := canvas.NewRectangle(theme.Color(theme.ColorNameOverlayBackground))
+	box.StrokeColor = theme.Color(theme.ColorNameShadow)
+	box.StrokeWidth = 1
+	box.CornerRadius = 4
+	box.Move(pos)
+	box.Resize(boxSize)
+
+	label.Move(pos.Add(fyne.NewPos(6, 4)))
+	label.Resize(textSize)
+
+	r.objects = append(r.objects, box, label)
+}
+
+// ExportChartPNG сохраняет график в PNG-файл, выбранный пользователем
+func ExportChartPNG(c *Chart, parent fyne.Window) {
+	save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil || writer == nil {
+			return
+		}
+		defer writer.Close()
+
+		// Рисуем копию графика на отдельном холсте, чтобы не трогать отображаемый виджет
+		copyChart := NewChart(c.Kind, c.Title, c.Points)
+		copyChart.Format = c.Format
+
+		offscreen := software.NewCanvas()
+		offscreen.SetPadded(false)
+		offscreen.SetContent(copyChart)
+		offscreen.Resize(fyne.NewSize(900, 500))
+
+		if err := png.Encode(writer, offscreen.Capture()); err != nil {
+			showError(err, parent)
+			return
+		}
+		dialog.ShowInformation(lang.L("Экспорт завершен"), lang.L("График сохранен в PNG"), parent)
+	}, parent)
+	save.SetFileName("chart.png")
+	save.Show()
+}
@@ -0,0 +1,166 @@
+package gui
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"SanWarehouse/analytics"
+	"SanWarehouse/database"
+	"SanWarehouse/models"
+)
+
+// stockValueByCategory возвращает стоимость запаса по закупочным ценам в валюте учета в разрезе
+// категорий, свернутых до уровня level (0 - без свертки)
+func stockValueByCategory(level int) []ChartPoint {
+	cost := database.PurchaseCostSQL(database.CurrentExchangeRates())
+	var points []ChartPoint
+	for _, g := range database.CategoryGroups(level) {
+		var value models.Money
+		g.Apply(database.DB.Model(&models.Product{})).
+			Select("coalesce(sum(quantity * " + cost + "), 0)").
+			Scan(&value)
+		label := g.Path
+		if label == "" {
+			label = lang.L("Без категории")
+		}
+		points = append(points, ChartPoint{Label: label, Value: value.Float()})
+	}
+	sort.SliceStable(points, func(i, j int) bool { return points[i].Value > points[j].Value })
+	return points
+}
+
+// marginByBrand возвращает маржинальность запаса в процентах по брендам. Товары, закупочную
+// цену которых не пересчитать в валюту учета, в расчет не входят.
+func marginByBrand() []ChartPoint {
+	type row struct {
+		Brand       string
+		PurchaseSum float64
+		SellingSum  float64
+	}
+	cost := database.PurchaseCostSQL(database.CurrentExchangeRates())
+	var rows []row
+	database.DB.Model(&models.Product{}).
+		Select("brand, sum(quantity * " + cost + ") as purchase_sum, " +
+			"sum(CASE WHEN " + cost + " IS NULL THEN 0 ELSE quantity * selling_price END) as selling_sum").
+		Group("brand").
+		Order("brand").
+		Scan(&rows)
+
+	points := make([]ChartPoint, 0, len(rows))
+	for _, r := range rows {
+		margin := 0.0
+		if r.SellingSum > 0 {
+			margin = (r.SellingSum - r.PurchaseSum) / r.SellingSum * 100
+		}
+		points = append(points, ChartPoint{Label: r.Brand, Value: margin})
+	}
+	return points
+}
+
+// stockLevelHistory возвращает остаток товара (или всего склада при productID == 0) по дням
+func stockLevelHistory(productID uint, days int) []ChartPoint {
+	now := time.Now()
+	from := now.AddDate(0, 0, -days+1)
+
+	query := database.DB.Model(&models.StockMovement{}).Where("created_at >= ?", from.AddDate(0, 0, -1))
+	current := 0
+	if productID != 0 {
+		query = query.Where("product_id = ?", productID)
+		var p models.Product
+		database.DB.First(&p, productID)
+		current = p.Quantity
+	} else {
+		database.DB.Model(&models.Product{}).Select("coalesce(sum(quantity), 0)").Scan(&current)
+	}
+
+	var stored []models.StockMovement
+	query.Find(&stored)
+
+	movements := make([]analytics.Movement, 0, len(stored))
+	for _, m := range stored {
+		movements = append(movements, analytics.Movement{Date: m.CreatedAt, Quantity: m.Quantity})
+	}
+
+	levels := analytics.StockHistory(current, movements, from, now)
+	points := make([]ChartPoint, len(levels))
+	for i, v := range levels {
+		points[i] = ChartPoint{Label: formatDayMonth(from.AddDate(0, 0, i)), Value: v}
+	}
+	return points
+}
+
+// showChartsReport - графики по складу
+func (r *Reports) showChartsReport() {
+	w := r.mainWindow.app.NewWindow(lang.L("Графики"))
+	w.Resize(fyne.NewSize(1000, 650))
+
+	money := func(v float64) string {
+		return formatNumber(v, 0) + " " + lang.L("руб.")
+	}
+
+	valueBar := NewChart(BarChart, lang.L("Стоимость запаса по категориям"), stockValueByCategory(1))
+	valueBar.Format = money
+
+	valuePie := NewChart(PieChart, lang.L("Доли категорий в стоимости запаса"), valueBar.Points)
+	valuePie.Format = money
+
+	marginBar := NewChart(BarChart, lang.L("Маржинальность по брендам"), marginByBrand())
+	marginBar.Format = func(v float64) string {
+		return formatNumber(v, 1) + "%"
+	}
+
+	stockLine := NewChart(LineChart, lang.L("Остаток на складе за 90 дней"), stockLevelHistory(0, 90))
+	stockLine.Format = func(v float64) string {
+		return formatNumber(v, 0) + " " + lang.L("шт.")
+	}
+
+	// Выбор товара для графика остатков: варианты ищутся по мере ввода,
+	// чтобы не загружать в список весь каталог
+	allProducts := lang.L("Все товары")
+	ids := map[string]uint{}
+	productSelect := widget.NewSelectEntry(nil)
+	productSelect.SetPlaceHolder(lang.L("SKU или название товара"))
+	productSelect.OnChanged = func(s string) {
+		if id, ok := ids[s]; ok || s == allProducts {
+			stockLine.Title = fmt.Sprintf(lang.L("Остаток на складе за 90 дней: %s"), s)
+			stockLine.SetPoints(stockLevelHistory(id, 90))
+			return
+		}
+
+		var products []models.Product
+		database.SearchProducts(database.DB.Model(&models.Product{}), s).
+			Select("products.id, sku, name").Order("sku").Limit(30).Find(&products)
+		options := []string{allProducts}
+		for _, p := range products {
+			key := p.SKU + " " + truncate(p.Name, 30)
+			options = append(options, key)
+			ids[key] = p.ID
+		}
+		productSelect.SetOptions(options)
+	}
+	productSelect.OnChanged("")
+
+	withExport := func(chart *Chart, top fyne.CanvasObject) fyne.CanvasObject {
+		export := widget.NewButtonWithIcon(lang.L("Сохранить PNG"), theme.DocumentSaveIcon(), func() {
+			ExportChartPNG(chart, w)
+		})
+		return container.NewBorder(top, container.NewHBox(export), nil, nil, chart)
+	}
+
+	tabs := container.NewAppTabs(
+		container.NewTabItem(lang.L("Стоимость по категориям"), withExport(valueBar, nil)),
+		container.NewTabItem(lang.L("Доли категорий"), withExport(valuePie, nil)),
+		container.NewTabItem(lang.L("Маржа по брендам"), withExport(marginBar, nil)),
+		container.NewTabItem(lang.L("Остатки во времени"), withExport(stockLine, container.NewBorder(nil, nil, widget.NewLabel(lang.L("Товар:")), nil, productSelect))),
+	)
+
+	w.SetContent(tabs)
+	w.Show()
+}
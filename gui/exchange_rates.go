@@ -0,0 +1,211 @@
+package gui
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"SanWarehouse/database"
+	"SanWarehouse/models"
+)
+
+// ExchangeRateManager - окно курсов валют: по ним закупочные цены пересчитываются в валюту учета
+type ExchangeRateManager struct {
+	mainWindow *MainWindow
+	window     fyne.Window
+
+	rates    []models.ExchangeRate
+	rows     [][]string
+	table    *widget.Table
+	current  *widget.Label
+	selected int
+}
+
+func NewExchangeRateManager(mw *MainWindow) *ExchangeRateManager {
+	return &ExchangeRateManager{mainWindow: mw, selected: -1}
+}
+
+// Show открывает окно курсов. Изменять курсы может роль с правом изменения цен.
+func (m *ExchangeRateManager) Show() {
+	m.window = m.mainWindow.app.NewWindow(lang.L("Курсы валют"))
+	m.window.Resize(fyne.NewSize(520, 450))
+
+	headers := translateAll([]string{"Дата", "Валюта", "Курс"})
+	m.table = newTextTable(headers, []float32{130, 90, 220}, &m.rows)
+	m.table.OnSelected = func(id widget.TableCellID) {
+		m.selected = id.Row - 1
+	}
+	m.current = widget.NewLabel("")
+	m.current.Wrapping = fyne.TextWrapWord
+	m.reload()
+
+	addBtn := widget.NewButtonWithIcon(lang.L("Добавить"), theme.ContentAddIcon(), func() {
+		m.showRateDialog(&models.ExchangeRate{Currency: models.CurrencyEUR, Date: time.Now()})
+	})
+	editBtn := widget.NewButtonWithIcon(lang.L("Изменить"), theme.DocumentCreateIcon(), func() {
+		if rate, ok := m.selectedRate(); ok {
+			m.showRateDialog(&rate)
+		}
+	})
+	deleteBtn := widget.NewButtonWithIcon(lang.L("Удалить"), theme.DeleteIcon(), m.deleteRate)
+	importBtn := widget.NewButtonWithIcon(lang.L("Загрузить из файла..."), theme.UploadIcon(), m.importRates)
+	canEdit := database.Can(models.PermEditPrices)
+	for _, btn := range []*widget.Button{addBtn, editBtn, deleteBtn, importBtn} {
+		setEnabled(btn, canEdit)
+	}
+
+	hint := widget.NewLabel(fmt.Sprintf(lang.L("Курс - сколько %s стоит одна единица валюты. "+
+		"Курс действует с указанной даты до следующего курса этой валюты."), lang.L("руб.")))
+	hint.Wrapping = fyne.TextWrapWord
+
+	m.window.SetContent(container.NewBorder(
+		container.NewVBox(m.current, hint),
+		container.NewHBox(addBtn, editBtn, deleteBtn, importBtn),
+		nil, nil, m.table))
+	m.window.Show()
+}
+
+func (m *ExchangeRateManager) reload() {
+	m.rates = database.ExchangeRateList()
+	m.rows = m.rows[:0]
+	for _, r := range m.rates {
+		m.rows = append(m.rows, []string{
+			formatDate(r.Date),
+			string(r.Currency),
+			formatRate(r.Rate) + " " + lang.L("руб."),
+		})
+	}
+	m.table.UnselectAll()
+	m.selected = -1
+	m.table.Refresh()
+
+	current := database.CurrentExchangeRates()
+	currencies := make([]models.Currency, 0, len(current))
+	for c := range current {
+		currencies = append(currencies, c)
+	}
+	sort.Slice(currencies, func(i, j int) bool { return currencies[i] < currencies[j] })
+	parts := make([]string, len(currencies))
+	for i, c := range currencies {
+		parts[i] = string(c) + " " + formatRate(current[c])
+	}
+	if len(parts) == 0 {
+		m.current.SetText(lang.L("Курсы не заданы: закупочные цены в других валютах не войдут в себестоимость"))
+		return
+	}
+	m.current.SetText(fmt.Sprintf(lang.L("Действующие курсы: %s"), strings.Join(parts, "; ")))
+}
+
+func (m *ExchangeRateManager) selectedRate() (models.ExchangeRate, bool) {
+	if m.selected < 0 || m.selected >= len(m.rates) {
+		dialog.ShowInformation(lang.L("Курсы валют"), lang.L("Выберите курс"), m.window)
+		return models.ExchangeRate{}, false
+	}
+	return m.rates[m.selected], true
+}
+
+// showRateDialog добавляет (ID == 0) или изменяет курс
+func (m *ExchangeRateManager) showRateDialog(rate *models.ExchangeRate) {
+	date := widget.NewDateEntry()
+	day := rate.Date
+	date.SetDate(&day)
+
+	var currencies []string
+	for _, c := range models.Currencies {
+		if c != models.BaseCurrency {
+			currencies = append(currencies, string(c))
+		}
+	}
+	currency := widget.NewSelectEntry(currencies)
+	currency.SetText(string(rate.Currency))
+
+	value := widget.NewEntry()
+	if rate.Rate > 0 {
+		value.SetText(formatRate(rate.Rate))
+	}
+	value.SetPlaceHolder("98,50")
+
+	items := []*widget.FormItem{
+		widget.NewFormItem(lang.L("Дата"), date),
+		widget.NewFormItem(lang.L("Валюта"), currency),
+		widget.NewFormItem(fmt.Sprintf(lang.L("Курс, %s"), lang.L("руб.")), value),
+	}
+
+	title := lang.L("Новый курс")
+	if rate.ID != 0 {
+		title = fmt.Sprintf(lang.L("Курс %s"), rate.AuditLabel())
+	}
+	dialog.ShowForm(title, lang.L("Сохранить"), lang.L("Отмена"), items, func(ok bool) {
+		if !ok {
+			return
+		}
+		if date.Date == nil {
+			showError(errors.New(lang.L("Укажите дату")), m.window)
+			return
+		}
+		parsed, err := models.ParseDecimal(value.Text)
+		if err != nil {
+			showError(err, m.window)
+			return
+		}
+		rate.Date, rate.Currency, rate.Rate = *date.Date, models.Currency(currency.Text), parsed
+		if err := database.SaveExchangeRate(rate); err != nil {
+			showError(err, m.window)
+			return
+		}
+		m.reload()
+		m.mainWindow.statusBar.SetText(fmt.Sprintf(lang.L("Курс %s сохранен"), rate.AuditLabel()))
+	}, m.window)
+}
+
+func (m *ExchangeRateManager) deleteRate() {
+	rate, ok := m.selectedRate()
+	if !ok {
+		return
+	}
+	dialog.ShowConfirm(lang.L("Удаление курса"), fmt.Sprintf(lang.L("Удалить курс %s?"), rate.AuditLabel()), func(ok bool) {
+		if !ok {
+			return
+		}
+		if err := database.DeleteExchangeRate(rate.ID); err != nil {
+			showError(err, m.window)
+			return
+		}
+		m.reload()
+	}, m.window)
+}
+
+// importRates загружает курсы из CSV-файла
+func (m *ExchangeRateManager) importRates() {
+	open := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil || reader == nil {
+			return
+		}
+		defer reader.Close()
+
+		count, err := database.ImportExchangeRates(reader)
+		if err != nil {
+			showError(err, m.window)
+			return
+		}
+		m.reload()
+		dialog.ShowInformation(lang.L("Курсы валют"), fmt.Sprintf(lang.L("Загружено курсов: %d"), count), m.window)
+	}, m.window)
+	open.SetFilter(storage.NewExtensionFileFilter([]string{".csv", ".txt"}))
+	open.Show()
+}
+
+// formatRate форматирует курс валюты: до четырех знаков после запятой, без лишних нулей
+func formatRate(rate float64) string {
+	return strings.TrimRight(strings.TrimRight(formatNumber(rate, 4), "0"), ".,")
+}
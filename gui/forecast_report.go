@@ -0,0 +1,242 @@
+package gui
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/widget"
+
+	"SanWarehouse/analytics"
+	"SanWarehouse/database"
+	"SanWarehouse/models"
+)
+
+// forecastRow - строка отчета по прогнозу спроса
+type forecastRow struct {
+	product  models.Product
+	forecast analytics.Forecast
+	accuracy analytics.Accuracy
+}
+
+// loadShipmentSeries возвращает дневные ряды отгрузок по товарам за последние days дней
+func loadShipmentSeries(days int) map[uint][]float64 {
+	now := time.Now()
+	from := now.AddDate(0, 0, -days+1)
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+
+	var movements []models.StockMovement
+	database.DB.Select("product_id, created_at, quantity").
+		Where("type = ? AND created_at >= ?", models.MovementShipment, from).
+		Find(&movements)
+
+	shipments := map[uint][]analytics.Shipment{}
+	for _, m := range movements {
+		shipments[m.ProductID] = append(shipments[m.ProductID], analytics.Shipment{
+			Date:     m.CreatedAt,
+			Quantity: -m.Quantity,
+		})
+	}
+
+	series := make(map[uint][]float64, len(shipments))
+	for id, s := range shipments {
+		series[id] = analytics.DailySeries(s, from, now)
+	}
+	return series
+}
+
+// showForecastReport - прогноз спроса по истории отгрузок
+func (r *Reports) showForecastReport() {
+	w := r.mainWindow.app.NewWindow(lang.L("Прогноз спроса"))
+	w.Resize(fyne.NewSize(1100, 600))
+
+	params := analytics.DefaultForecastParams
+	historyDays := 180
+	holdoutDays := 30
+	var rows []forecastRow
+
+	forecastHeaders := translateAll([]string{"SKU", "Наименование", "Доступно", "Спрос/день", "Дней запаса", "Исчерпание"})
+	accuracyHeaders := translateAll([]string{"SKU", "Наименование", "Прогноз", "Факт", "Ошибка", "Ошибка, %"})
+
+	cellText := func(row forecastRow, col int, accuracy bool) string {
+		switch col {
+		case 0:
+			return row.product.SKU
+		case 1:
+			return truncate(row.product.Name, 30)
+		}
+
+		if accuracy {
+			switch col {
+			case 2:
+				return formatNumber(row.accuracy.Forecast, 1)
+			case 3:
+				return formatNumber(row.accuracy.Actual, 0)
+			case 4:
+				return formatNumber(row.accuracy.Error, 1)
+			case 5:
+				if math.IsNaN(row.accuracy.ErrorPercent) {
+					return "—"
+				}
+				return formatNumber(row.accuracy.ErrorPercent, 1) + "%"
+			}
+			return ""
+		}
+
+		switch col {
+		case 2:
+			return formatInt(int64(row.product.AvailableQuantity()))
+		case 3:
+			return formatNumber(row.forecast.DailyDemand, 2)
+		case 4:
+			if math.IsInf(row.forecast.DaysOfCover, 1) {
+				return "∞"
+			}
+			return formatNumber(row.forecast.DaysOfCover, 0)
+		case 5:
+			if !row.forecast.HasStockOut {
+				return "—"
+			}
+			return formatDate(row.forecast.StockOutDate)
+		}
+		return ""
+	}
+
+	newTable := func(headers []string, accuracy bool) *widget.Table {
+		table := widget.NewTable(
+			func() (int, int) {
+				return len(rows) + 1, len(headers)
+			},
+			func() fyne.CanvasObject {
+				return container.NewStack(
+					canvas.NewRectangle(color.Transparent),
+					widget.NewLabel("Template"),
+				)
+			},
+			func(id widget.TableCellID, obj fyne.CanvasObject) {
+				container := obj.(*fyne.Container)
+				bg := container.Objects[0].(*canvas.Rectangle)
+				label := container.Objects[1].(*widget.Label)
+
+				if id.Row == 0 {
+					label.TextStyle.Bold = true
+					label.SetText(headers[id.Col])
+					bg.FillColor = &color.NRGBA{R: 200, G: 200, B: 200, A: 255}
+					bg.Refresh()
+					return
+				}
+
+				row := rows[id.Row-1]
+				label.TextStyle.Bold = false
+				bg.FillColor = color.Transparent
+				// Подсвечиваем товары, которые закончатся раньше, чем придет поставка
+				if !accuracy && row.forecast.HasStockOut && row.forecast.DaysOfCover <= float64(row.product.LeadTimeDays) {
+					bg.FillColor = &color.NRGBA{R: 255, G: 0, B: 0, A: 50}
+				}
+				bg.Refresh()
+				label.SetText(cellText(row, id.Col, accuracy))
+			})
+
+		widths := []float32{100, 280, 100, 100, 100, 110}
+		for i, width := range widths {
+			table.SetColumnWidth(i, width)
+		}
+		return table
+	}
+
+	forecastTable := newTable(forecastHeaders, false)
+	accuracyTable := newTable(accuracyHeaders, true)
+	summary := widget.NewLabel("")
+
+	load := func() {
+		series := loadShipmentSeries(historyDays)
+		empty := make([]float64, historyDays)
+
+		var products []models.Product
+		database.DB.Select("id, sku, name, quantity, reserved_quantity, lead_time_days").
+			Where("is_active = ?", true).
+			Find(&products)
+
+		now := time.Now()
+		rows = rows[:0]
+		var totalForecast, totalActual float64
+		for _, p := range products {
+			s, ok := series[p.ID]
+			if !ok {
+				s = empty
+			}
+			row := forecastRow{
+				product:  p,
+				forecast: analytics.ForecastProduct(s, p.AvailableQuantity(), now, params),
+				accuracy: analytics.Backtest(s, holdoutDays, params),
+			}
+			totalForecast += row.accuracy.Forecast
+			totalActual += row.accuracy.Actual
+			rows = append(rows, row)
+		}
+
+		// Сначала товары, которые закончатся раньше всех
+		sort.SliceStable(rows, func(i, j int) bool {
+			return rows[i].forecast.DaysOfCover < rows[j].forecast.DaysOfCover
+		})
+
+		text := fmt.Sprintf(lang.L("Товаров: %d | Прогноз на %d дн.: %s шт. | Факт: %s шт."),
+			len(rows), holdoutDays, formatNumber(totalForecast, 0), formatNumber(totalActual, 0))
+		if totalActual > 0 {
+			text += fmt.Sprintf(lang.L(" | Ошибка: %s%%"), formatNumber(math.Abs(totalForecast-totalActual)/totalActual*100, 1))
+		}
+		summary.SetText(text)
+		forecastTable.Refresh()
+		accuracyTable.Refresh()
+	}
+
+	methodTitles := translateAll([]string{"Скользящее среднее", "Экспоненциальное сглаживание"})
+	methods := map[string]analytics.Method{
+		methodTitles[0]: analytics.MethodMovingAverage,
+		methodTitles[1]: analytics.MethodExponential,
+	}
+	methodSelect := widget.NewSelect(methodTitles, func(s string) {
+		params.Method = methods[s]
+		load()
+	})
+
+	windowEntry := widget.NewEntry()
+	windowEntry.SetText(strconv.Itoa(params.Window))
+	windowEntry.OnSubmitted = func(s string) {
+		if v, err := strconv.Atoi(s); err == nil && v > 0 {
+			params.Window = v
+			load()
+		}
+	}
+
+	alphaEntry := widget.NewEntry()
+	alphaEntry.SetText(strconv.FormatFloat(params.Alpha, 'f', 2, 64))
+	alphaEntry.OnSubmitted = func(s string) {
+		if v, err := strconv.ParseFloat(s, 64); err == nil && v > 0 && v <= 1 {
+			params.Alpha = v
+			load()
+		}
+	}
+
+	controls := container.NewHBox(
+		widget.NewLabel(lang.L("Метод:")), methodSelect,
+		widget.NewLabel(lang.L("Окно (дней):")), windowEntry,
+		widget.NewLabel(lang.L("Альфа:")), alphaEntry,
+	)
+
+	tabs := container.NewAppTabs(
+		container.NewTabItem(lang.L("Прогноз"), forecastTable),
+		container.NewTabItem(fmt.Sprintf(lang.L("Прогноз и факт (последние %d дн.)"), holdoutDays), accuracyTable),
+	)
+
+	w.SetContent(container.NewBorder(controls, summary, nil, nil, tabs))
+	methodSelect.SetSelected(methodTitles[0])
+	w.Show()
+}
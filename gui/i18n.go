@@ -0,0 +1,154 @@
+package gui
+
+import (
+	"embed"
+	"errors"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/lang"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+
+	"SanWarehouse/config"
+	"SanWarehouse/models"
+)
+
+// translations - каталоги переводов интерфейса. Ключ сообщения - русский текст, поэтому
+// строка без перевода показывается по-русски. Каталоги содержат и строки самого Fyne
+// (кнопки диалогов, выбор файла), чтобы они были на том же языке, что и программа.
+//
+//go:embed translations
+var translations embed.FS
+
+// uiLanguage - язык интерфейса, выбранный при запуске
+var uiLanguage = config.LanguageRussian
+
+// printer форматирует числа по правилам языка интерфейса
+var printer = message.NewPrinter(language.Russian)
+
+// languageOptions - варианты выбора языка в настройках; code "" - язык системы
+var languageOptions = []struct{ code, title string }{
+	{"", "Как в системе"},
+	{config.LanguageRussian, "Русский"},
+	{config.LanguageEnglish, "English"},
+}
+
+// dateLayouts - форматы дат по языкам: дата, дата и время, время с секундами, день и месяц
+var dateLayouts = map[string][4]string{
+	config.LanguageRussian: {"02.01.2006", "02.01.2006 15:04", "02.01.2006 15:04:05", "02.01"},
+	config.LanguageEnglish: {"Jan 2, 2006", "Jan 2, 2006 15:04", "Jan 2, 2006 15:04:05", "Jan 2"},
+}
+
+// setupLanguage загружает перевод интерфейса на язык code; "" - язык системы: русский,
+// если система русская, иначе английский. Fyne выбирает каталог по языку системы и не
+// позволяет его переопределить, поэтому выбранный каталог регистрируется для языка
+// системы и для английского, который Fyne использует, если язык системы не поддерживается.
+func setupLanguage(code string) {
+	system := lang.SystemLocale()
+	if code == "" {
+		code = config.LanguageEnglish
+		if system.LanguageString() == config.LanguageRussian {
+			code = config.LanguageRussian
+		}
+	}
+	data, err := translations.ReadFile("translations/" + code + ".json")
+	if err != nil {
+		fyne.LogError("Неизвестный язык интерфейса "+code, err)
+		return
+	}
+
+	locales := []fyne.Locale{fyne.Locale(system.LanguageString())}
+	if system.LanguageString() != config.LanguageEnglish {
+		locales = append(locales, config.LanguageEnglish)
+	}
+	for _, l := range locales {
+		if err := lang.AddTranslationsForLocale(data, l); err != nil {
+			fyne.LogError("Ошибка загрузки перевода "+code, err)
+			return
+		}
+	}
+	uiLanguage = code
+	printer = message.NewPrinter(language.Make(code))
+}
+
+// formatNumber форматирует число с разделителями разрядов и decimals знаками после запятой
+func formatNumber(v float64, decimals int) string {
+	return printer.Sprintf("%.*f", decimals, v)
+}
+
+// formatInt форматирует целое число с разделителями разрядов
+func formatInt(n int64) string {
+	return printer.Sprintf("%d", n)
+}
+
+// formatMoney форматирует сумму в валюте учета
+func formatMoney(m models.Money) string {
+	return formatPrice(m, models.BaseCurrency)
+}
+
+// formatPrice форматирует сумму в валюте c: рубли - "руб.", остальные валюты - кодом
+func formatPrice(m models.Money, c models.Currency) string {
+	unit := string(c)
+	if c == models.CurrencyRUB || c == "" {
+		unit = lang.L("руб.")
+	}
+	return formatNumber(m.Float(), 2) + " " + unit
+}
+
+// formatDate форматирует дату
+func formatDate(t time.Time) string {
+	return t.Format(dateLayouts[uiLanguage][0])
+}
+
+// formatDateTime форматирует дату и время с точностью до минуты
+func formatDateTime(t time.Time) string {
+	return t.Format(dateLayouts[uiLanguage][1])
+}
+
+// formatTimestamp форматирует дату и время с секундами
+func formatTimestamp(t time.Time) string {
+	return t.Format(dateLayouts[uiLanguage][2])
+}
+
+// formatDayMonth форматирует день и месяц, например для подписей графиков
+func formatDayMonth(t time.Time) string {
+	return t.Format(dateLayouts[uiLanguage][3])
+}
+
+// translate переводит строку по каталогу; в отличие от lang.L подходит как func(string) string
+func translate(text string) string {
+	return lang.L(text)
+}
+
+// errorText возвращает текст ошибки на языке интерфейса: ошибки программы (models.Errorf)
+// и замечания проверки переводятся по каталогу, ошибки драйвера и системы - как есть
+func errorText(err error) string {
+	return models.LocalizeError(err, translate)
+}
+
+// showError показывает ошибку на языке интерфейса
+func showError(err error, parent fyne.Window) {
+	dialog.ShowError(errors.New(errorText(err)), parent)
+}
+
+// translateAll переводит список строк, например заголовки колонок таблицы
+func translateAll(texts []string) []string {
+	translated := make([]string, len(texts))
+	for i, text := range texts {
+		translated[i] = lang.L(text)
+	}
+	return translated
+}
+
+// currencyList перечисляет коды валют через запятую
+func currencyList(currencies []models.Currency) string {
+	codes := make([]string, len(currencies))
+	for i, c := range currencies {
+		codes[i] = string(c)
+	}
+	return strings.Join(codes, ", ")
+}
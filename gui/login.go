@@ -0,0 +1,189 @@
+package gui
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+
+	"SanWarehouse/database"
+	"SanWarehouse/models"
+)
+
+// LoginScreen - экран входа, который показывается в главном окне до списка товаров.
+// Если учетных записей еще нет, вместо входа предлагается создать администратора.
+type LoginScreen struct {
+	window  fyne.Window
+	onLogin func(*models.User)
+
+	message *widget.Label
+}
+
+func NewLoginScreen(w fyne.Window, onLogin func(*models.User)) *LoginScreen {
+	message := widget.NewLabel("")
+	message.Importance = widget.DangerImportance
+	message.Wrapping = fyne.TextWrapWord
+	message.Hide()
+	return &LoginScreen{window: w, onLogin: onLogin, message: message}
+}
+
+// Content возвращает содержимое экрана входа
+func (s *LoginScreen) Content() fyne.CanvasObject {
+	var form fyne.CanvasObject
+	if database.HasUsers() {
+		form = s.loginForm()
+	} else {
+		form = s.firstRunForm()
+	}
+
+	// Форма фиксированной ширины по центру окна
+	card := container.NewGridWrap(fyne.NewSize(380, form.MinSize().Height), form)
+	return container.NewVBox(layout.NewSpacer(), container.NewCenter(card), layout.NewSpacer())
+}
+
+// loginForm - вход по имени пользователя и паролю
+func (s *LoginScreen) loginForm() fyne.CanvasObject {
+	username := widget.NewEntry()
+	password := widget.NewPasswordEntry()
+
+	var loginBtn *widget.Button
+	login := func() {
+		s.message.Hide()
+		loginBtn.Disable()
+		name, pass := username.Text, password.Text
+		// Проверка пароля намеренно медленная, поэтому не блокируем интерфейс
+		go func() {
+			user, err := database.Authenticate(name, pass)
+			fyne.Do(func() {
+				loginBtn.Enable()
+				if err != nil {
+					password.SetText("")
+					s.showError(err)
+					return
+				}
+				s.onLogin(user)
+			})
+		}()
+	}
+	loginBtn = widget.NewButton(lang.L("Войти"), login)
+	loginBtn.Importance = widget.HighImportance
+	password.OnSubmitted = func(string) { login() }
+	username.OnSubmitted = func(string) { s.window.Canvas().Focus(password) }
+
+	form := container.NewVBox(
+		widget.NewLabelWithStyle(lang.L("Вход в систему"), fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
+		widget.NewForm(
+			widget.NewFormItem(lang.L("Пользователь"), username),
+			widget.NewFormItem(lang.L("Пароль"), password),
+		),
+		s.message,
+		loginBtn,
+	)
+	s.window.Canvas().Focus(username)
+	return form
+}
+
+// firstRunForm - создание учетной записи администратора при первом запуске и выбор
+// начальных данных: пустой склад или демонстрационные данные
+func (s *LoginScreen) firstRunForm() fyne.CanvasObject {
+	username := widget.NewEntry()
+	username.SetText("admin")
+	fullName := widget.NewEntry()
+	password := widget.NewPasswordEntry()
+	confirm := widget.NewPasswordEntry()
+
+	// На пустом складе можно сразу загрузить демонстрационные данные
+	emptyData, demoData := lang.L("Пустой склад"), lang.L("Демонстрационные данные")
+	data := widget.NewRadioGroup([]string{emptyData, demoData}, nil)
+	data.SetSelected(emptyData)
+	data.Required = true
+	if !database.WarehouseEmpty() {
+		data.Hide()
+	}
+
+	var createBtn *widget.Button
+	createBtn = widget.NewButton(lang.L("Создать и войти"), func() {
+		s.message.Hide()
+		if password.Text != confirm.Text {
+			s.showError(errors.New(lang.L("пароли не совпадают")))
+			return
+		}
+		user := &models.User{Username: username.Text, FullName: fullName.Text, Role: models.RoleAdmin}
+		if err := database.CreateUser(user, password.Text); err != nil {
+			s.showError(err)
+			return
+		}
+		if !data.Visible() || data.Selected != demoData {
+			s.onLogin(user)
+			return
+		}
+
+		createBtn.Disable()
+		createBtn.SetText(lang.L("Загрузка демонстрационных данных..."))
+		go func() {
+			_, err := database.LoadDemoData(database.DemoProducts, time.Now().UnixNano())
+			fyne.Do(func() {
+				s.onLogin(user)
+				if err != nil {
+					showError(models.Errorf("демонстрационные данные не загружены: %w", err), s.window)
+				}
+			})
+		}()
+	})
+	createBtn.Importance = widget.HighImportance
+
+	hint := widget.NewLabel(fmt.Sprintf(lang.L("Учетных записей еще нет. Создайте администратора: он сможет завести остальных пользователей. Пароль - не короче %d символов."), models.MinPasswordLength))
+	hint.Wrapping = fyne.TextWrapWord
+
+	return container.NewVBox(
+		widget.NewLabelWithStyle(lang.L("Первый запуск"), fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
+		hint,
+		widget.NewForm(
+			widget.NewFormItem(lang.L("Пользователь"), username),
+			widget.NewFormItem(lang.L("Имя"), fullName),
+			widget.NewFormItem(lang.L("Пароль"), password),
+			widget.NewFormItem(lang.L("Повтор пароля"), confirm),
+		),
+		data,
+		s.message,
+		createBtn,
+	)
+}
+
+func (s *LoginScreen) showError(err error) {
+	s.message.SetText(errorText(err))
+	s.message.Show()
+}
+
+// showChangePasswordDialog меняет пароль текущего пользователя
+func showChangePasswordDialog(parent fyne.Window) {
+	oldPassword := widget.NewPasswordEntry()
+	newPassword := widget.NewPasswordEntry()
+	confirm := widget.NewPasswordEntry()
+
+	items := []*widget.FormItem{
+		widget.NewFormItem(lang.L("Текущий пароль"), oldPassword),
+		widget.NewFormItem(lang.L("Новый пароль"), newPassword),
+		widget.NewFormItem(lang.L("Повтор пароля"), confirm),
+	}
+	dialog.ShowForm(lang.L("Смена пароля"), lang.L("Сменить"), lang.L("Отмена"), items, func(ok bool) {
+		if !ok {
+			return
+		}
+		if newPassword.Text != confirm.Text {
+			showError(errors.New(lang.L("пароли не совпадают")), parent)
+			return
+		}
+		if err := database.ChangePassword(oldPassword.Text, newPassword.Text); err != nil {
+			showError(err, parent)
+			return
+		}
+		dialog.ShowInformation(lang.L("Смена пароля"), lang.L("Пароль изменен"), parent)
+	}, parent)
+}
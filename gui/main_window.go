@@ -11,9 +11,14 @@ import (
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/lang"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 
+	"gorm.io/gorm"
+
+	"SanWarehouse/config"
 	"SanWarehouse/database"
 	"SanWarehouse/models"
 )
@@ -21,28 +26,75 @@ import (
 type MainWindow struct {
 	app         fyne.App
 	window      fyne.Window
+	config      *config.Config
 	productList *ProductList
+	alerts      *AlertCenter
 	statusBar   *widget.Label
+	userLabel   *widget.Label
+
+	// История действий для отмены (Ctrl+Z) и повтора (Ctrl+Shift+Z)
+	history    undoStack
+	undoAction *widget.ToolbarAction
+	redoAction *widget.ToolbarAction
 }
 
-func NewMainWindow() *MainWindow {
+func NewMainWindow(cfg *config.Config) *MainWindow {
+	// Язык выбирается до создания окон: подписи переводятся при построении интерфейса
+	setupLanguage(cfg.Language)
+
 	a := app.New()
-	w := a.NewWindow("Склад сантехнической гарнитуры")
+	w := a.NewWindow(lang.L("Склад сантехнической гарнитуры"))
 	w.Resize(fyne.NewSize(1200, 700))
 
 	mw := &MainWindow{
 		app:       a,
 		window:    w,
-		statusBar: widget.NewLabel("Готов к работе"),
+		config:    cfg,
+		statusBar: widget.NewLabel(lang.L("Готов к работе")),
+		userLabel: widget.NewLabel(""),
 	}
 
-	mw.setupUI()
 	return mw
 }
 
+// showLogin показывает в главном окне экран входа
+func (mw *MainWindow) showLogin() {
+	mw.window.SetContent(NewLoginScreen(mw.window, mw.onLogin).Content())
+}
+
+// onLogin запоминает пользователя и строит интерфейс с учетом его прав
+func (mw *MainWindow) onLogin(user *models.User) {
+	database.SetCurrentUser(user)
+	mw.userLabel.SetText(fmt.Sprintf("%s (%s)", user.DisplayName(), lang.L(user.Role.Title())))
+	mw.statusBar.SetText(lang.L("Готов к работе"))
+	mw.setupUI()
+}
+
+// logout закрывает остальные окна и возвращает к экрану входа
+func (mw *MainWindow) logout() {
+	for _, w := range mw.app.Driver().AllWindows() {
+		if w != mw.window {
+			w.Close()
+		}
+	}
+	database.SetCurrentUser(nil)
+	// Действия предыдущего пользователя отменить нельзя
+	mw.history.clear()
+	mw.showLogin()
+}
+
+// requirePermission сообщает об отсутствии права и возвращает false
+func (mw *MainWindow) requirePermission(p models.Permission) bool {
+	if database.Can(p) {
+		return true
+	}
+	dialog.ShowInformation(lang.L("Недостаточно прав"), fmt.Sprintf(lang.L("Действие недоступно для вашей роли: %s"), lang.L(p.Title())), mw.window)
+	return false
+}
+
 func (mw *MainWindow) setupUI() {
 	// Заголовок
-	title := canvas.NewText("Управление складом сантехники", color.White)
+	title := canvas.NewText(lang.L("Управление складом сантехники"), color.White)
 	title.TextSize = 20
 	title.Alignment = fyne.TextAlignCenter
 
@@ -52,95 +104,220 @@ func (mw *MainWindow) setupUI() {
 	// Создаем список товаров
 	mw.productList = NewProductList(mw)
 
-	// Создаем заголовки таблицы
-	headers := mw.productList.CreateHeader()
-
-	// Оборачиваем таблицу в контейнер с заголовками
+	// Таблица со строкой поиска и панелью фильтров по колонкам
 	tableContainer := container.NewBorder(
-		headers, // верхняя часть - заголовки
+		container.NewVBox(
+			mw.productList.SearchBar().Widget(),
+			container.NewHScroll(mw.productList.FilterBar()),
+		),
 		nil,
 		nil,
 		nil,
-		container.NewScroll(mw.productList), // центральная часть - таблица с прокруткой
+		mw.productList,
 	)
 
+	// Центр уведомлений
+	mw.alerts = NewAlertCenter(mw)
+
 	// Панель инструментов
-	toolbar := mw.createToolbar()
+	toolbar := container.NewBorder(nil, nil, nil, mw.alerts.Button(), mw.createToolbar())
 
 	// Основной контент
 	content := container.NewBorder(
 		container.NewVBox(header, toolbar),
-		mw.statusBar,
-		nil,
+		container.NewBorder(nil, nil, nil, mw.userLabel, mw.statusBar),
 		nil,
+		mw.alerts.Panel(),
 		tableContainer,
 	)
 
 	mw.window.SetContent(content)
 
+	// Ctrl+F переводит фокус в строку поиска
+	mw.window.Canvas().AddShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyF, Modifier: fyne.KeyModifierShortcutDefault}, func(fyne.Shortcut) {
+		mw.productList.SearchBar().Focus()
+	})
+	mw.window.Canvas().AddShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyZ, Modifier: fyne.KeyModifierShortcutDefault}, func(fyne.Shortcut) {
+		mw.undo()
+	})
+	mw.window.Canvas().AddShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyZ, Modifier: fyne.KeyModifierShortcutDefault | fyne.KeyModifierShift}, func(fyne.Shortcut) {
+		mw.redo()
+	})
+
 	// Загружаем данные
 	mw.productList.RefreshList()
+	mw.alerts.Refresh()
 }
 
 func (mw *MainWindow) createToolbar() *widget.Toolbar {
-	return &widget.Toolbar{
-		Items: []widget.ToolbarItem{
+	// Действия, недоступные роли пользователя, не показываются
+	var editing []widget.ToolbarItem
+	if database.Can(models.PermEditProducts) {
+		editing = append(editing,
 			widget.NewToolbarAction(theme.ContentAddIcon(), func() {
 				mw.showProductForm(nil)
 			}),
-			widget.NewToolbarSeparator(),
-			widget.NewToolbarAction(theme.ViewRefreshIcon(), func() {
-				mw.productList.RefreshList()
-			}),
-			widget.NewToolbarSeparator(),
-			widget.NewToolbarAction(theme.ContentRedoIcon(), func() {
-				mw.showLowStockReport()
+			widget.NewToolbarAction(theme.DocumentCreateIcon(), func() {
+				NewBulkEdit(mw).Show()
 			}),
-			widget.NewToolbarSeparator(),
-			widget.NewToolbarAction(theme.InfoIcon(), func() {
-				mw.showStatistics()
-			}),
-			widget.NewToolbarSeparator(),
-			widget.NewToolbarAction(theme.DocumentIcon(), func() {
-				reports := NewReports(mw)
-				reports.ShowReportsMenu()
+		)
+	}
+	if database.Can(models.PermManageCatalog) {
+		editing = append(editing, widget.NewToolbarAction(theme.FolderIcon(), func() {
+			NewCatalogEditor(mw).Show()
+		}))
+	}
+	if len(editing) > 0 {
+		editing = append(editing, widget.NewToolbarSeparator())
+	}
+
+	var orders []widget.ToolbarItem
+	if database.Can(models.PermManageOrders) {
+		orders = append(orders,
+			widget.NewToolbarAction(theme.StorageIcon(), func() {
+				NewReplenishment(mw).Show()
 			}),
 			widget.NewToolbarSeparator(),
-			widget.NewToolbarAction(theme.SearchIcon(), func() {
-				mw.showSearchDialog()
-			}),
-		},
+		)
+	}
+
+	account := []widget.ToolbarItem{
+		widget.NewToolbarSeparator(),
 	}
+	if database.Can(models.PermManageUsers) {
+		account = append(account, widget.NewToolbarAction(theme.AccountIcon(), func() {
+			NewUserManager(mw).Show()
+		}))
+	}
+	if database.Can(models.PermViewAuditLog) {
+		account = append(account, widget.NewToolbarAction(theme.HistoryIcon(), func() {
+			NewAuditLog(mw).Show()
+		}))
+	}
+	if database.Can(models.PermManageBackups) {
+		account = append(account, widget.NewToolbarAction(theme.DocumentSaveIcon(), func() {
+			NewBackupManager(mw).Show()
+		}))
+	}
+	account = append(account,
+		widget.NewToolbarAction(theme.SettingsIcon(), mw.showSettings),
+		widget.NewToolbarAction(theme.LogoutIcon(), mw.logout),
+	)
+
+	mw.undoAction = widget.NewToolbarAction(theme.ContentUndoIcon(), mw.undo)
+	mw.redoAction = widget.NewToolbarAction(theme.ContentRedoIcon(), mw.redo)
+	mw.updateUndoActions()
+
+	items := editing
+	items = append(items,
+		mw.undoAction,
+		mw.redoAction,
+		widget.NewToolbarSeparator(),
+		widget.NewToolbarAction(theme.ViewRefreshIcon(), func() {
+			mw.productList.ClearFilters()
+			mw.alerts.Refresh()
+		}),
+		widget.NewToolbarSeparator(),
+		widget.NewToolbarAction(theme.WarningIcon(), func() {
+			mw.showLowStockReport()
+		}),
+		widget.NewToolbarSeparator(),
+	)
+	items = append(items, orders...)
+	items = append(items,
+		widget.NewToolbarAction(theme.InfoIcon(), func() {
+			mw.showStatistics()
+		}),
+		widget.NewToolbarSeparator(),
+		widget.NewToolbarAction(theme.DocumentIcon(), func() {
+			reports := NewReports(mw)
+			reports.ShowReportsMenu()
+		}),
+		widget.NewToolbarSeparator(),
+		widget.NewToolbarAction(theme.SearchIcon(), func() {
+			mw.productList.SearchBar().Focus()
+		}),
+	)
+	items = append(items, account...)
+	return &widget.Toolbar{Items: items}
 }
 
 func (mw *MainWindow) showProductForm(product *models.Product) {
-	form := NewProductForm(mw.window, product, func(updatedProduct *models.Product) {
-		if product == nil {
-			// Создание нового продукта
-			database.DB.Create(updatedProduct)
+	form := NewProductForm(mw.window, product, func(updatedProduct *models.Product) error {
+		var c *command
+		if updatedProduct.ID == 0 {
+			// Создание нового продукта. Нулевой минимальный уровень GORM при создании заменяет
+			// значением по умолчанию, поэтому введенный в форме ноль записывается отдельно.
+			minStock := updatedProduct.MinStockLevel
+			err := database.DB.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Create(updatedProduct).Error; err != nil {
+					return err
+				}
+				if minStock == 0 && updatedProduct.MinStockLevel != 0 {
+					updatedProduct.MinStockLevel = 0
+					return tx.Save(updatedProduct).Error
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			// Отмена добавления удаляет товар, поэтому без права удаления ее не предлагаем
+			if database.Can(models.PermDeleteProducts) {
+				c = createProductCommand(updatedProduct)
+			}
 		} else {
-			// Обновление существующего
-			database.DB.Save(updatedProduct)
+			// Обновление существующего: для отмены запоминаем сохраненную версию.
+			// Если товар изменили после открытия формы, Save вернет database.ErrVersionConflict.
+			before, err := mw.loadProduct(updatedProduct.ID)
+			if err != nil {
+				return err
+			}
+			if err := database.DB.Save(updatedProduct).Error; err != nil {
+				return err
+			}
+			after, err := mw.loadProduct(updatedProduct.ID)
+			if err != nil {
+				return err
+			}
+			c = editProductCommand(before, after)
 		}
 		mw.productList.RefreshList()
-		mw.statusBar.SetText("Товар сохранен: " + updatedProduct.Name)
+		mw.alerts.Refresh()
+		if c == nil {
+			mw.statusBar.SetText(fmt.Sprintf(lang.L("Товар сохранен: %s"), updatedProduct.Name))
+			return nil
+		}
+		mw.record(c)
+		mw.statusBar.SetText(fmt.Sprintf(lang.L("Товар сохранен: %s (Ctrl+Z - отменить)"), updatedProduct.Name))
+		return nil
 	})
+	form.onFilesChanged = mw.productList.RefreshThumbnails
 	form.Show()
 }
 
 func (mw *MainWindow) showLowStockReport() {
-	var products []models.Product
-	database.DB.Where("quantity - reserved_quantity < min_stock_level AND quantity > 0").Find(&products)
+	query := database.DB.Model(&models.Product{}).Where("quantity - reserved_quantity < min_stock_level AND quantity > 0")
 
-	if len(products) == 0 {
-		dialog.ShowInformation("Отчет", "Товаров с низким запасом не найдено", mw.window)
+	var total int64
+	query.Count(&total)
+	if total == 0 {
+		dialog.ShowInformation(lang.L("Отчет"), lang.L("Товаров с низким запасом не найдено"), mw.window)
 		return
 	}
 
+	// Сначала товары с наименьшим доступным остатком
+	const limit = 300
+	var products []models.Product
+	query.Order("quantity - reserved_quantity").Limit(limit).Find(&products)
+
 	content := container.NewVBox()
+	if total > limit {
+		content.Add(widget.NewLabel(fmt.Sprintf(lang.L("Показаны %d из %d товаров"), limit, total)))
+	}
 	for _, p := range products {
 		available := p.AvailableQuantity()
-		text := fmt.Sprintf("%s - %s | Доступно: %d | Мин. уровень: %d",
+		text := fmt.Sprintf(lang.L("%s - %s | Доступно: %d | Мин. уровень: %d"),
 			p.SKU, p.Name, available, p.MinStockLevel)
 
 		// Создаем цветной индикатор
@@ -153,33 +330,43 @@ func (mw *MainWindow) showLowStockReport() {
 	scroll := container.NewScroll(content)
 	scroll.SetMinSize(fyne.NewSize(500, 400))
 
-	dialog.ShowCustom("Товары с низким запасом", "Закрыть", scroll, mw.window)
+	dialog.ShowCustom(lang.L("Товары с низким запасом"), lang.L("Закрыть"), scroll, mw.window)
 }
 
 func (mw *MainWindow) showStatistics() {
 	var totalProducts int64
-	var totalValue float64
+	var totalValue models.Money
 	var totalItems int
 	var outOfStock int64
 
+	rates := database.CurrentExchangeRates()
 	database.DB.Model(&models.Product{}).Count(&totalProducts)
-	database.DB.Model(&models.Product{}).Select("sum(quantity * purchase_price)").Scan(&totalValue)
+	database.DB.Model(&models.Product{}).Select("sum(quantity * " + database.PurchaseCostSQL(rates) + ")").Scan(&totalValue)
 	database.DB.Model(&models.Product{}).Select("sum(quantity)").Scan(&totalItems)
 	database.DB.Model(&models.Product{}).Where("quantity - reserved_quantity <= 0").Count(&outOfStock)
 
-	stats := fmt.Sprintf(`Статистика склада:
+	// Стоимость запасов считается по закупочным ценам в валюте учета и видна не всем ролям
+	value := lang.L("скрыто")
+	if database.Can(models.PermViewPurchasePrices) {
+		value = formatMoney(totalValue)
+		if missing := missingRates(rates); len(missing) > 0 {
+			value += " " + fmt.Sprintf(lang.L("(без товаров в %s: нет курса)"), currencyList(missing))
+		}
+	}
+
+	stats := fmt.Sprintf(lang.L(`Статистика склада:
     
-    Всего наименований: %d
-    Всего единиц товара: %d
-    Общая стоимость запасов: %.2f руб.
-    Товаров в наличии: %d
-    Товаров с нулевым запасом: %d
-    Товаров с низким запасом: %d`,
-		totalProducts, totalItems, totalValue,
-		totalProducts-outOfStock, outOfStock,
-		mw.getLowStockCount())
-
-	dialog.ShowInformation("Статистика", stats, mw.window)
+    Всего наименований: %s
+    Всего единиц товара: %s
+    Общая стоимость запасов: %s
+    Товаров в наличии: %s
+    Товаров с нулевым запасом: %s
+    Товаров с низким запасом: %s`),
+		formatInt(totalProducts), formatInt(int64(totalItems)), value,
+		formatInt(totalProducts-outOfStock), formatInt(outOfStock),
+		formatInt(mw.getLowStockCount()))
+
+	dialog.ShowInformation(lang.L("Статистика"), stats, mw.window)
 }
 
 func (mw *MainWindow) getLowStockCount() int64 {
@@ -190,23 +377,10 @@ func (mw *MainWindow) getLowStockCount() int64 {
 	return count
 }
 
-func (mw *MainWindow) showSearchDialog() {
-	searchEntry := widget.NewEntry()
-	searchEntry.SetPlaceHolder("Введите SKU или название товара...")
-
-	items := []*widget.FormItem{
-		widget.NewFormItem("Поиск", searchEntry),
-	}
-
-	dialog.ShowForm("Поиск товара", "Найти", "Отмена", items, func(b bool) {
-		if b {
-			query := searchEntry.Text
-			mw.productList.Search(query)
-			mw.statusBar.SetText("Поиск: " + query)
-		}
-	}, mw.window)
-}
-
+// Run показывает экран входа; список товаров появляется после входа
 func (mw *MainWindow) Run() {
+	mw.showLogin()
+	go mw.runBackupSchedule()
 	mw.window.ShowAndRun()
+	mw.backupOnExit()
 }
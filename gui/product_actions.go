@@ -0,0 +1,356 @@
+package gui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/widget"
+
+	"SanWarehouse/database"
+	"SanWarehouse/models"
+)
+
+// movementTitles - названия видов движения; переводятся при отображении
+var movementTitles = map[models.MovementType]string{
+	models.MovementReceipt:    "Поступление",
+	models.MovementShipment:   "Отгрузка",
+	models.MovementAdjustment: "Корректировка",
+}
+
+// loadProduct читает актуальную версию товара из БД
+func (mw *MainWindow) loadProduct(id uint) (*models.Product, error) {
+	var p models.Product
+	if err := database.DB.First(&p, id).Error; err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// editProduct открывает форму редактирования товара; без права изменения - карточку товара
+func (mw *MainWindow) editProduct(id uint) {
+	if !database.Can(models.PermEditProducts) {
+		mw.showProductDetail(id)
+		return
+	}
+	p, err := mw.loadProduct(id)
+	if err != nil {
+		showError(err, mw.window)
+		return
+	}
+	mw.showProductForm(p)
+}
+
+// onSelectionChanged показывает количество выделенных товаров в строке состояния
+func (mw *MainWindow) onSelectionChanged() {
+	ids := mw.productList.SelectedIDs()
+	switch len(ids) {
+	case 0:
+		mw.statusBar.SetText(lang.L("Готов к работе"))
+	case 1:
+		if p := mw.productList.CurrentProduct(); p != nil {
+			mw.statusBar.SetText(fmt.Sprintf(lang.L("Выбран товар: %s %s"), p.SKU, p.Name))
+		}
+	default:
+		mw.statusBar.SetText(fmt.Sprintf(lang.L("Выбрано товаров: %d"), len(ids)))
+	}
+}
+
+// productContextMenu строит контекстное меню для текущего товара и выделения
+func (mw *MainWindow) productContextMenu() *fyne.Menu {
+	current := mw.productList.CurrentProduct()
+	ids := mw.productList.SelectedIDs()
+
+	deleteTitle := lang.L("Удалить")
+	if len(ids) > 1 {
+		deleteTitle = fmt.Sprintf(lang.L("Удалить выбранные (%d)"), len(ids))
+	}
+
+	// Пункты, недоступные роли пользователя, показываются неактивными
+	allowed := func(item *fyne.MenuItem, p models.Permission) *fyne.MenuItem {
+		item.Disabled = !database.Can(p)
+		return item
+	}
+
+	return fyne.NewMenu("",
+		fyne.NewMenuItem(lang.L("Карточка товара"), func() { mw.showProductDetail(current.ID) }),
+		allowed(fyne.NewMenuItem(lang.L("Редактировать"), func() { mw.editProduct(current.ID) }), models.PermEditProducts),
+		allowed(fyne.NewMenuItem(lang.L("Дублировать"), func() { mw.duplicateProduct(current.ID) }), models.PermEditProducts),
+		fyne.NewMenuItemSeparator(),
+		allowed(fyne.NewMenuItem(lang.L("Корректировка остатка"), func() { mw.showAdjustStockDialog(current.ID) }), models.PermAdjustStock),
+		allowed(fyne.NewMenuItem(lang.L("Зарезервировать..."), func() { mw.showReserveDialog(current.ID) }), models.PermAdjustStock),
+		allowed(fyne.NewMenuItem(lang.L("Снять резерв..."), func() { mw.showReleaseReservationDialog(current.ID) }), models.PermAdjustStock),
+		fyne.NewMenuItem(lang.L("История движения"), func() { mw.showMovementHistory(current.ID) }),
+		allowed(fyne.NewMenuItem(lang.L("Массовое изменение..."), func() { NewBulkEdit(mw).Show() }), models.PermEditProducts),
+		fyne.NewMenuItemSeparator(),
+		allowed(fyne.NewMenuItem(deleteTitle, func() { mw.deleteProducts(ids) }), models.PermDeleteProducts),
+	)
+}
+
+// deleteProducts удаляет товары после подтверждения
+func (mw *MainWindow) deleteProducts(ids []uint) {
+	if len(ids) == 0 || !mw.requirePermission(models.PermDeleteProducts) {
+		return
+	}
+
+	message := fmt.Sprintf(lang.L("Удалить выбранные товары (%d шт.)?"), len(ids))
+	title := fmt.Sprintf(lang.L("Удаление товаров (%d)"), len(ids))
+	if len(ids) == 1 {
+		if p, err := mw.loadProduct(ids[0]); err == nil {
+			message = fmt.Sprintf(lang.L("Удалить товар %s «%s»?"), p.SKU, p.Name)
+			title = fmt.Sprintf(lang.L("Удаление товара %s"), p.SKU)
+		}
+	}
+
+	dialog.ShowConfirm(lang.L("Удаление товара"), message, func(ok bool) {
+		if !ok {
+			return
+		}
+		if err := mw.execute(deleteProductsCommand(ids, title)); err != nil {
+			showError(err, mw.window)
+			return
+		}
+		mw.productList.ClearSelection()
+		mw.productList.RefreshList()
+		mw.statusBar.SetText(fmt.Sprintf(lang.L("Удалено товаров: %d (Ctrl+Z - отменить)"), len(ids)))
+	}, mw.window)
+}
+
+// duplicateProduct открывает форму нового товара, заполненную данными существующего
+func (mw *MainWindow) duplicateProduct(id uint) {
+	p, err := mw.loadProduct(id)
+	if err != nil {
+		showError(err, mw.window)
+		return
+	}
+
+	duplicate := *p
+	duplicate.ID = 0
+	duplicate.Version = 0
+	duplicate.CreatedAt = time.Time{}
+	duplicate.UpdatedAt = time.Time{}
+	duplicate.Quantity = 0
+	duplicate.ReservedQuantity = 0
+	duplicate.Status = ""
+	duplicate.SKU = uniqueSKU(p.SKU + "-COPY")
+
+	mw.showProductForm(&duplicate)
+}
+
+// uniqueSKU подбирает свободный артикул, добавляя числовой суффикс
+func uniqueSKU(base string) string {
+	sku := base
+	for i := 2; ; i++ {
+		var count int64
+		database.DB.Unscoped().Model(&models.Product{}).Where("sku = ?", sku).Count(&count)
+		if count == 0 {
+			return sku
+		}
+		sku = base + "-" + strconv.Itoa(i)
+	}
+}
+
+// showAdjustStockDialog - поступление, отгрузка или корректировка остатка по факту
+func (mw *MainWindow) showAdjustStockDialog(id uint) {
+	if !mw.requirePermission(models.PermAdjustStock) {
+		return
+	}
+	p, err := mw.loadProduct(id)
+	if err != nil {
+		showError(err, mw.window)
+		return
+	}
+
+	types := []string{
+		lang.L(movementTitles[models.MovementReceipt]),
+		lang.L(movementTitles[models.MovementShipment]),
+		lang.L(movementTitles[models.MovementAdjustment]),
+	}
+	typeSelect := widget.NewSelect(types, nil)
+	typeSelect.SetSelected(types[0])
+
+	quantityEntry := widget.NewEntry()
+	quantityEntry.SetPlaceHolder(lang.L("Количество (для корректировки - фактический остаток)"))
+	referenceEntry := widget.NewEntry()
+	referenceEntry.SetPlaceHolder(lang.L("Номер документа"))
+	commentEntry := widget.NewEntry()
+
+	items := []*widget.FormItem{
+		widget.NewFormItem(lang.L("Товар"), widget.NewLabel(fmt.Sprintf(lang.L("%s %s (на складе %d шт.)"), p.SKU, p.Name, p.Quantity))),
+		widget.NewFormItem(lang.L("Операция"), typeSelect),
+		widget.NewFormItem(lang.L("Количество"), quantityEntry),
+		widget.NewFormItem(lang.L("Документ"), referenceEntry),
+		widget.NewFormItem(lang.L("Комментарий"), commentEntry),
+	}
+
+	dialog.ShowForm(lang.L("Корректировка остатка"), lang.L("Провести"), lang.L("Отмена"), items, func(ok bool) {
+		if !ok {
+			return
+		}
+
+		quantity, err := strconv.Atoi(strings.TrimSpace(quantityEntry.Text))
+		if err != nil || quantity < 0 {
+			showError(fmt.Errorf(lang.L("некорректное количество: %s"), quantityEntry.Text), mw.window)
+			return
+		}
+
+		var movementType models.MovementType
+		var delta int
+		switch typeSelect.Selected {
+		case types[0]:
+			movementType, delta = models.MovementReceipt, quantity
+		case types[1]:
+			movementType, delta = models.MovementShipment, -quantity
+		default:
+			movementType, delta = models.MovementAdjustment, quantity-p.Quantity
+		}
+		if delta == 0 {
+			return
+		}
+
+		if err := database.AdjustStock(p.ID, movementType, delta, referenceEntry.Text, commentEntry.Text); err != nil {
+			showError(err, mw.window)
+			return
+		}
+		mw.productList.RefreshList()
+		mw.alerts.Refresh()
+		mw.statusBar.SetText(fmt.Sprintf(lang.L("%s: %s, %+d шт."), typeSelect.Selected, p.SKU, delta))
+	}, mw.window)
+}
+
+// showReserveDialog резервирует товар под заказ клиента
+func (mw *MainWindow) showReserveDialog(id uint) {
+	if !mw.requirePermission(models.PermAdjustStock) {
+		return
+	}
+	p, err := mw.loadProduct(id)
+	if err != nil {
+		showError(err, mw.window)
+		return
+	}
+
+	quantityEntry := widget.NewEntry()
+	customerEntry := widget.NewEntry()
+	referenceEntry := widget.NewEntry()
+	daysEntry := widget.NewEntry()
+	daysEntry.SetPlaceHolder(lang.L("без срока"))
+
+	items := []*widget.FormItem{
+		widget.NewFormItem(lang.L("Товар"), widget.NewLabel(fmt.Sprintf("%s %s", p.SKU, p.Name))),
+		widget.NewFormItem(lang.L("Количество"), quantityEntry),
+		widget.NewFormItem(lang.L("Клиент"), customerEntry),
+		widget.NewFormItem(lang.L("Документ"), referenceEntry),
+		widget.NewFormItem(lang.L("Срок, дней"), daysEntry),
+	}
+
+	dialog.ShowForm(fmt.Sprintf(lang.L("Резерв (доступно %d шт.)"), p.AvailableQuantity()), lang.L("Зарезервировать"), lang.L("Отмена"), items, func(ok bool) {
+		if !ok {
+			return
+		}
+
+		quantity, err := strconv.Atoi(strings.TrimSpace(quantityEntry.Text))
+		if err != nil {
+			showError(fmt.Errorf(lang.L("некорректное количество: %s"), quantityEntry.Text), mw.window)
+			return
+		}
+		reservation := models.Reservation{
+			ProductID: p.ID,
+			Quantity:  quantity,
+			Customer:  strings.TrimSpace(customerEntry.Text),
+			Reference: strings.TrimSpace(referenceEntry.Text),
+		}
+		if text := strings.TrimSpace(daysEntry.Text); text != "" {
+			days, err := strconv.Atoi(text)
+			if err != nil || days <= 0 {
+				showError(fmt.Errorf(lang.L("некорректный срок резерва: %s"), text), mw.window)
+				return
+			}
+			expires := time.Now().AddDate(0, 0, days)
+			reservation.ExpiresAt = &expires
+		}
+
+		if err := database.ReserveStock(&reservation); err != nil {
+			showError(err, mw.window)
+			return
+		}
+		mw.productList.RefreshList()
+		mw.alerts.Refresh()
+		mw.statusBar.SetText(fmt.Sprintf(lang.L("Зарезервировано: %s, %d шт."), p.SKU, quantity))
+	}, mw.window)
+}
+
+// showReleaseReservationDialog снимает один из активных резервов товара
+func (mw *MainWindow) showReleaseReservationDialog(id uint) {
+	if !mw.requirePermission(models.PermAdjustStock) {
+		return
+	}
+	p, err := mw.loadProduct(id)
+	if err != nil {
+		showError(err, mw.window)
+		return
+	}
+
+	var reservations []models.Reservation
+	database.DB.Where("product_id = ? AND status = ?", id, models.ReservationActive).Order("created_at").Find(&reservations)
+	if len(reservations) == 0 {
+		dialog.ShowInformation(lang.L("Снять резерв"), fmt.Sprintf(lang.L("У товара %s нет активных резервов"), p.SKU), mw.window)
+		return
+	}
+
+	options := make([]string, len(reservations))
+	for i, r := range reservations {
+		options[i] = fmt.Sprintf(lang.L("%s: %s %s, %d шт."), formatDate(r.CreatedAt), r.Customer, r.Reference, r.Quantity)
+	}
+	reservationSelect := widget.NewSelect(options, nil)
+	reservationSelect.SetSelected(options[0])
+
+	items := []*widget.FormItem{
+		widget.NewFormItem(lang.L("Товар"), widget.NewLabel(fmt.Sprintf(lang.L("%s %s (в резерве %d шт.)"), p.SKU, p.Name, p.ReservedQuantity))),
+		widget.NewFormItem(lang.L("Резерв"), reservationSelect),
+	}
+
+	dialog.ShowForm(lang.L("Снять резерв"), lang.L("Снять"), lang.L("Отмена"), items, func(ok bool) {
+		index := reservationSelect.SelectedIndex()
+		if !ok || index < 0 {
+			return
+		}
+
+		reservation := reservations[index]
+		if err := database.ReleaseReservation(reservation.ID); err != nil {
+			showError(err, mw.window)
+			return
+		}
+		mw.productList.RefreshList()
+		mw.alerts.Refresh()
+		mw.statusBar.SetText(fmt.Sprintf(lang.L("Резерв снят: %s, %d шт."), p.SKU, reservation.Quantity))
+	}, mw.window)
+}
+
+// showMovementHistory показывает историю движения товара
+func (mw *MainWindow) showMovementHistory(id uint) {
+	p, err := mw.loadProduct(id)
+	if err != nil {
+		showError(err, mw.window)
+		return
+	}
+
+	var movements []models.StockMovement
+	database.DB.Where("product_id = ?", id).Order("created_at desc").Find(&movements)
+
+	rows := movementRows(movements)
+	table := newTextTable(translateAll(movementHeaders), movementWidths, &rows)
+
+	content := container.NewBorder(
+		widget.NewLabel(fmt.Sprintf(lang.L("%s %s | Движений: %d"), p.SKU, p.Name, len(movements))),
+		nil, nil, nil,
+		table,
+	)
+	d := dialog.NewCustom(lang.L("История движения"), lang.L("Закрыть"), content, mw.window)
+	d.Resize(fyne.NewSize(800, 500))
+	d.Show()
+}
@@ -0,0 +1,368 @@
+package gui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"SanWarehouse/database"
+	"SanWarehouse/models"
+)
+
+// Названия статусов заказов и резервов; переводятся при отображении
+var orderStatusTitles = map[models.OrderStatus]string{
+	models.OrderDraft:     "Черновик",
+	models.OrderPlaced:    "Размещен",
+	models.OrderReceived:  "Получен",
+	models.OrderCancelled: "Отменен",
+}
+
+var reservationStatusTitles = map[models.ReservationStatus]string{
+	models.ReservationActive:    "Активен",
+	models.ReservationReleased:  "Снят",
+	models.ReservationFulfilled: "Отгружен",
+}
+
+// newTextTable создает таблицу только для чтения с заголовком в первой строке.
+// rows читается при каждой отрисовке, поэтому после изменения данных достаточно Refresh.
+func newTextTable(headers []string, widths []float32, rows *[][]string) *widget.Table {
+	table := widget.NewTable(
+		func() (int, int) {
+			return len(*rows) + 1, len(headers)
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("Template")
+		},
+		func(id widget.TableCellID, obj fyne.CanvasObject) {
+			label := obj.(*widget.Label)
+			if id.Row == 0 {
+				label.TextStyle.Bold = true
+				label.SetText(headers[id.Col])
+				return
+			}
+			label.TextStyle.Bold = false
+			label.SetText((*rows)[id.Row-1][id.Col])
+		})
+	for i, w := range widths {
+		table.SetColumnWidth(i, w)
+	}
+	return table
+}
+
+// ProductDetail - карточка товара: все сведения о товаре на вкладках, без редактирования
+type ProductDetail struct {
+	mainWindow *MainWindow
+	window     fyne.Window
+	product    *models.Product
+}
+
+func NewProductDetail(mw *MainWindow, product *models.Product) *ProductDetail {
+	return &ProductDetail{mainWindow: mw, product: product}
+}
+
+// showProductDetail открывает карточку товара
+func (mw *MainWindow) showProductDetail(id uint) {
+	p, err := mw.loadProduct(id)
+	if err != nil {
+		showError(err, mw.window)
+		return
+	}
+	NewProductDetail(mw, p).Show()
+}
+
+// Show открывает окно карточки товара
+func (d *ProductDetail) Show() {
+	d.window = d.mainWindow.app.NewWindow(fmt.Sprintf("%s — %s", d.product.SKU, d.product.Name))
+	d.window.Resize(fyne.NewSize(900, 600))
+
+	tabs := container.NewAppTabs(
+		container.NewTabItemWithIcon(lang.L("Общее"), theme.InfoIcon(), d.generalTab()),
+		container.NewTabItemWithIcon(lang.L("Остатки по местам"), theme.StorageIcon(), d.locationsTab()),
+		container.NewTabItemWithIcon(lang.L("Движение"), theme.ListIcon(), d.movementsTab()),
+		container.NewTabItemWithIcon(lang.L("История цен"), theme.HistoryIcon(), d.pricesTab()),
+		container.NewTabItemWithIcon(lang.L("Заказы"), theme.DocumentIcon(), d.ordersTab()),
+		container.NewTabItemWithIcon(lang.L("Резервы"), theme.ContentCopyIcon(), d.reservationsTab()),
+		container.NewTabItemWithIcon(lang.L("Изображения"), theme.FileImageIcon(), d.imagesTab()),
+		container.NewTabItemWithIcon(lang.L("Изменения"), theme.DocumentCreateIcon(), d.auditTab()),
+	)
+
+	editBtn := widget.NewButtonWithIcon(lang.L("Редактировать"), theme.DocumentCreateIcon(), func() {
+		// Форма редактирования открывается в главном окне, карточка после этого устаревает
+		d.window.Close()
+		d.mainWindow.editProduct(d.product.ID)
+	})
+	editBtn.Importance = widget.HighImportance
+	setEnabled(editBtn, database.Can(models.PermEditProducts))
+	closeBtn := widget.NewButton(lang.L("Закрыть"), func() { d.window.Close() })
+
+	d.window.SetContent(container.NewBorder(nil, container.NewHBox(layout.NewSpacer(), editBtn, closeBtn), nil, nil, tabs))
+	d.window.Show()
+}
+
+// generalTab - основные сведения о товаре
+func (d *ProductDetail) generalTab() fyne.CanvasObject {
+	p := d.product
+
+	supplier := "—"
+	if p.SupplierID != nil {
+		var s models.Supplier
+		if database.DB.First(&s, *p.SupplierID).Error == nil {
+			supplier = s.Name
+		}
+	}
+
+	// Закупочная цена и маржа видны только ролям с доступом к закупочным ценам
+	purchasePrice := "—"
+	sellingPrice := formatMoney(p.SellingPrice)
+	if database.Can(models.PermViewPurchasePrices) {
+		purchasePrice = formatPrice(p.PurchasePrice, p.PurchaseCurrency)
+		// Маржа считается по закупочной цене в валюте учета, по текущему курсу
+		rates := database.CurrentExchangeRates()
+		cost, ok := rates.Convert(p.PurchasePrice, p.PurchaseCurrency)
+		switch {
+		case !ok:
+			purchasePrice = fmt.Sprintf(lang.L("%s (нет курса %s)"), purchasePrice, p.PurchaseCurrency)
+		case p.PurchaseCurrency != models.BaseCurrency:
+			purchasePrice = fmt.Sprintf(lang.L("%s (%s по курсу %s)"), purchasePrice, formatMoney(cost),
+				formatRate(rates[p.PurchaseCurrency]))
+		}
+		if ok {
+			margin := 0.0
+			if p.SellingPrice > 0 {
+				margin = float64(p.SellingPrice-cost) / float64(p.SellingPrice) * 100
+			}
+			sellingPrice = fmt.Sprintf(lang.L("%s (маржа %s%%)"), formatMoney(p.SellingPrice), formatNumber(margin, 1))
+		}
+	}
+	classes := strings.TrimSpace(p.ABCClass + p.XYZClass)
+	if classes == "" {
+		classes = "—"
+	}
+
+	form := widget.NewForm(
+		widget.NewFormItem("SKU", widget.NewLabel(p.SKU)),
+		widget.NewFormItem(lang.L("Название"), widget.NewLabel(p.Name)),
+		widget.NewFormItem(lang.L("Категория"), widget.NewLabel(p.Category)),
+		widget.NewFormItem(lang.L("Бренд"), widget.NewLabel(p.Brand)),
+		widget.NewFormItem(lang.L("Статус"), widget.NewLabel(lang.L(p.Status.Title()))),
+		widget.NewFormItem(lang.L("Количество"), widget.NewLabel(fmt.Sprintf(lang.L("%d шт. (в резерве %d, доступно %d)"), p.Quantity, p.ReservedQuantity, p.AvailableQuantity()))),
+		widget.NewFormItem(lang.L("Мин. запас"), widget.NewLabel(strconv.Itoa(p.MinStockLevel))),
+		widget.NewFormItem(lang.L("Цена закупки"), widget.NewLabel(purchasePrice)),
+		widget.NewFormItem(lang.L("Цена продажи"), widget.NewLabel(sellingPrice)),
+		widget.NewFormItem(lang.L("Поставщик"), widget.NewLabel(supplier)),
+		widget.NewFormItem(lang.L("Точка заказа"), widget.NewLabel(fmt.Sprintf(lang.L("%d шт., заказ %d шт., срок поставки %d дн."), p.ReorderPoint, p.ReorderQuantity, p.LeadTimeDays))),
+		widget.NewFormItem(lang.L("Расположение"), widget.NewLabel(p.Location)),
+		widget.NewFormItem(lang.L("Вес"), widget.NewLabel(formatNumber(p.Weight, 2)+" "+lang.L("кг"))),
+		widget.NewFormItem(lang.L("Размеры"), widget.NewLabel(p.Dimensions)),
+		widget.NewFormItem(lang.L("Материал"), widget.NewLabel(p.Material)),
+		widget.NewFormItem(lang.L("ID маркетплейса"), widget.NewLabel(p.MarketplaceID)),
+		widget.NewFormItem(lang.L("Класс ABC/XYZ"), widget.NewLabel(classes)),
+		widget.NewFormItem(lang.L("Активен"), widget.NewLabel(yesNo(p.IsActive))),
+		widget.NewFormItem(lang.L("Создан"), widget.NewLabel(formatDateTime(p.CreatedAt))),
+		widget.NewFormItem(lang.L("Изменен"), widget.NewLabel(formatDateTime(p.UpdatedAt))),
+	)
+
+	description := widget.NewLabel(p.Description)
+	description.Wrapping = fyne.TextWrapWord
+
+	return container.NewVScroll(container.NewVBox(
+		form,
+		widget.NewCard(lang.L("Описание"), "", description),
+	))
+}
+
+// locationsTab - остаток по местам хранения
+func (d *ProductDetail) locationsTab() fyne.CanvasObject {
+	var rows [][]string
+	for _, s := range database.StockByLocation(d.product) {
+		location := s.Location
+		if location == "" {
+			location = lang.L("не указано")
+		}
+		if s.Location == d.product.Location {
+			location = fmt.Sprintf(lang.L("%s (основное)"), location)
+		}
+		rows = append(rows, []string{location, formatInt(int64(s.Quantity))})
+	}
+
+	table := newTextTable(translateAll([]string{"Место хранения", "Количество"}), []float32{250, 120}, &rows)
+	return container.NewBorder(
+		widget.NewLabel(fmt.Sprintf(lang.L("Всего на складе: %d шт."), d.product.Quantity)),
+		nil, nil, nil, table)
+}
+
+// movementRows преобразует движения товара в строки таблицы
+func movementRows(movements []models.StockMovement) [][]string {
+	rows := make([][]string, 0, len(movements))
+	for _, m := range movements {
+		rows = append(rows, []string{
+			formatDateTime(m.CreatedAt),
+			lang.L(movementTitles[m.Type]),
+			fmt.Sprintf("%+d", m.Quantity),
+			m.Location,
+			m.Reference,
+			m.Comment,
+		})
+	}
+	return rows
+}
+
+// Заголовки таблицы движений; переводятся при создании таблицы
+var (
+	movementHeaders = []string{"Дата", "Операция", "Количество", "Место", "Документ", "Комментарий"}
+	movementWidths  = []float32{130, 120, 90, 90, 140, 200}
+)
+
+// movementsTab - история движения товара
+func (d *ProductDetail) movementsTab() fyne.CanvasObject {
+	var movements []models.StockMovement
+	database.DB.Where("product_id = ?", d.product.ID).Order("created_at desc").Find(&movements)
+
+	rows := movementRows(movements)
+	table := newTextTable(translateAll(movementHeaders), movementWidths, &rows)
+	return container.NewBorder(
+		widget.NewLabel(fmt.Sprintf(lang.L("Движений: %d"), len(movements))),
+		nil, nil, nil, table)
+}
+
+// pricesTab - история изменения цен
+func (d *ProductDetail) pricesTab() fyne.CanvasObject {
+	var changes []models.PriceChange
+	database.DB.Where("product_id = ?", d.product.ID).Order("created_at desc").Find(&changes)
+
+	showPurchase := database.Can(models.PermViewPurchasePrices)
+	rows := make([][]string, 0, len(changes))
+	for _, c := range changes {
+		purchase := "—"
+		if showPurchase {
+			purchase = formatPrice(c.OldPurchasePrice, c.OldPurchaseCurrency) + " → " +
+				formatPrice(c.NewPurchasePrice, c.NewPurchaseCurrency)
+		}
+		rows = append(rows, []string{
+			formatDateTime(c.CreatedAt),
+			purchase,
+			formatNumber(c.OldSellingPrice.Float(), 2) + " → " + formatNumber(c.NewSellingPrice.Float(), 2),
+			printer.Sprintf("%+.1f%%", c.SellingChangePercent()),
+		})
+	}
+
+	if len(changes) == 0 {
+		return container.NewCenter(widget.NewLabel(lang.L("Цены товара не менялись")))
+	}
+	return newTextTable(
+		translateAll([]string{"Дата", "Цена закупки", "Цена продажи", "Изменение"}),
+		[]float32{130, 240, 200, 110}, &rows)
+}
+
+// ordersTab - открытые заказы поставщикам
+func (d *ProductDetail) ordersTab() fyne.CanvasObject {
+	orders := database.OpenOrdersForProduct(d.product.ID)
+	showPrice := database.Can(models.PermViewPurchasePrices)
+
+	total := 0
+	rows := make([][]string, 0, len(orders))
+	for _, o := range orders {
+		supplier := "—"
+		if o.Supplier != nil {
+			supplier = o.Supplier.Name
+		}
+		expected := "—"
+		if o.ExpectedAt != nil {
+			expected = formatDate(*o.ExpectedAt)
+		}
+		for _, l := range o.Lines {
+			total += l.Quantity
+			price := "—"
+			if showPrice {
+				price = formatPrice(l.UnitPrice, o.Currency)
+			}
+			rows = append(rows, []string{
+				o.Number, lang.L(orderStatusTitles[o.Status]), supplier, expected,
+				formatInt(int64(l.Quantity)), price,
+			})
+		}
+	}
+
+	if len(rows) == 0 {
+		return container.NewCenter(widget.NewLabel(lang.L("Открытых заказов поставщикам нет")))
+	}
+	table := newTextTable(
+		translateAll([]string{"Заказ", "Статус", "Поставщик", "Ожидается", "Количество", "Цена"}),
+		[]float32{170, 100, 180, 100, 100, 130}, &rows)
+	return container.NewBorder(
+		widget.NewLabel(fmt.Sprintf(lang.L("Ожидается поступление: %d шт."), total)),
+		nil, nil, nil, table)
+}
+
+// imagesTab - изображения и документы товара; добавляются в форме редактирования
+func (d *ProductDetail) imagesTab() fyne.CanvasObject {
+	return NewAttachmentGallery(d.window, d.product.ID, false).Widget()
+}
+
+// auditTab - журнал изменений товара: кто, когда и какие поля менял
+func (d *ProductDetail) auditTab() fyne.CanvasObject {
+	const limit = 500
+	entries, total := database.AuditEntries(database.AuditFilter{Entity: "products", EntityID: d.product.ID}, limit)
+	if total == 0 {
+		return container.NewCenter(widget.NewLabel(lang.L("Изменений товара в журнале нет")))
+	}
+
+	// Колонка "Объект" в карточке товара не нужна
+	const objectColumn = 3
+	rows := auditRows(entries)
+	for i, row := range rows {
+		rows[i] = append(row[:objectColumn:objectColumn], row[objectColumn+1:]...)
+	}
+	headers := translateAll(append(auditHeaders[:objectColumn:objectColumn], auditHeaders[objectColumn+1:]...))
+
+	summary := fmt.Sprintf(lang.L("Изменений: %d"), total)
+	if total > limit {
+		summary = fmt.Sprintf(lang.L("Показаны последние %d из %d изменений"), limit, total)
+	}
+	return container.NewBorder(
+		widget.NewLabel(summary),
+		nil, nil, nil,
+		newTextTable(headers, []float32{150, 120, 100, 170, 200, 200}, &rows))
+}
+
+// reservationsTab - резервы товара под заказы клиентов
+func (d *ProductDetail) reservationsTab() fyne.CanvasObject {
+	var reservations []models.Reservation
+	database.DB.Where("product_id = ?", d.product.ID).Order("status = 'active' desc, created_at desc").Find(&reservations)
+
+	now := time.Now()
+	var rows [][]string
+	active := 0
+	for _, r := range reservations {
+		status := lang.L(reservationStatusTitles[r.Status])
+		if r.IsExpired(now) {
+			status = fmt.Sprintf(lang.L("%s (истек)"), status)
+		}
+		expires := "—"
+		if r.ExpiresAt != nil {
+			expires = formatDate(*r.ExpiresAt)
+		}
+		if r.IsActive() {
+			active += r.Quantity
+		}
+		rows = append(rows, []string{
+			formatDateTime(r.CreatedAt), r.Customer, r.Reference,
+			formatInt(int64(r.Quantity)), status, expires,
+		})
+	}
+
+	return container.NewBorder(
+		widget.NewLabel(fmt.Sprintf(lang.L("В резерве: %d шт. (по резервам %d шт.)"), d.product.ReservedQuantity, active)),
+		nil, nil, nil,
+		newTextTable(
+			translateAll([]string{"Дата", "Клиент", "Документ", "Количество", "Статус", "Действует до"}),
+			[]float32{130, 200, 130, 100, 100, 110}, &rows))
+}
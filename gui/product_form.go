@@ -1,31 +1,42 @@
 package gui
 
 import (
+	"errors"
+	"fmt"
 	"strconv"
+	"strings"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/lang"
 	"fyne.io/fyne/v2/widget"
 
+	"SanWarehouse/database"
 	"SanWarehouse/models"
 )
 
 type ProductForm struct {
 	window  fyne.Window
 	product *models.Product
-	onSave  func(*models.Product)
+	// original - товар при открытии формы, с ним сравниваются изменения других пользователей
+	original models.Product
+	// onSave сохраняет товар; ошибка database.ErrVersionConflict открывает окно разрешения конфликта
+	onSave func(*models.Product) error
+	// onFilesChanged вызывается после изменения изображений и документов товара
+	onFilesChanged func()
 
 	// Поля формы
 	skuLabel         *widget.Label
 	skuEntry         *widget.Entry
 	nameEntry        *widget.Entry
-	categoryEntry    *widget.Entry
-	brandEntry       *widget.Entry
+	categoryEntry    *widget.SelectEntry
+	brandEntry       *widget.SelectEntry
 	descEntry        *widget.Entry
 	quantityEntry    *widget.Entry
 	reservedEntry    *widget.Entry
 	purchaseEntry    *widget.Entry
+	currencyEntry    *widget.SelectEntry
 	sellingEntry     *widget.Entry
 	minStockEntry    *widget.Entry
 	locationEntry    *widget.Entry
@@ -34,13 +45,33 @@ type ProductForm struct {
 	materialEntry    *widget.Entry
 	marketplaceEntry *widget.Entry
 	activeCheck      *widget.Check
+
+	// Политика пополнения
+	reorderPointEntry *widget.Entry
+	reorderQtyEntry   *widget.Entry
+	maxStockEntry     *widget.Entry
+	leadTimeEntry     *widget.Entry
+	supplierEntry     *widget.SelectEntry
+
+	// Сообщения об ошибках под полями (по имени поля models.Field*)
+	messages map[string]*widget.Label
+	// touched - поля, которые пользователь уже изменял; до этого ошибки в них не показываются
+	touched map[string]bool
+	saveBtn *widget.Button
+	dialog  *dialog.CustomDialog
 }
 
-func NewProductForm(parent fyne.Window, product *models.Product, onSave func(*models.Product)) *ProductForm {
+func NewProductForm(parent fyne.Window, product *models.Product, onSave func(*models.Product) error) *ProductForm {
 	pf := &ProductForm{
 		window:  parent,
 		product: product,
 		onSave:  onSave,
+
+		messages: map[string]*widget.Label{},
+		touched:  map[string]bool{},
+	}
+	if product != nil {
+		pf.original = *product
 	}
 
 	pf.initFields()
@@ -51,12 +82,18 @@ func (pf *ProductForm) initFields() {
 	// Создаем поля ввода
 	pf.skuEntry = widget.NewEntry()
 	pf.nameEntry = widget.NewEntry()
-	pf.categoryEntry = widget.NewEntry()
-	pf.brandEntry = widget.NewEntry()
+	pf.categoryEntry = newLookupEntry(database.CategoryPaths())
+	pf.categoryEntry.SetPlaceHolder(lang.L("Сантехника > Смесители"))
+	pf.brandEntry = newLookupEntry(database.BrandNames())
 	pf.descEntry = widget.NewEntry()
 	pf.quantityEntry = widget.NewEntry()
 	pf.reservedEntry = widget.NewEntry()
 	pf.purchaseEntry = widget.NewEntry()
+	currencies := make([]string, len(models.Currencies))
+	for i, c := range models.Currencies {
+		currencies[i] = string(c)
+	}
+	pf.currencyEntry = widget.NewSelectEntry(currencies)
 	pf.sellingEntry = widget.NewEntry()
 	pf.minStockEntry = widget.NewEntry()
 	pf.locationEntry = widget.NewEntry()
@@ -64,7 +101,12 @@ func (pf *ProductForm) initFields() {
 	pf.dimensionsEntry = widget.NewEntry()
 	pf.materialEntry = widget.NewEntry()
 	pf.marketplaceEntry = widget.NewEntry()
-	pf.activeCheck = widget.NewCheck("Активен", nil)
+	pf.activeCheck = widget.NewCheck(lang.L("Активен"), nil)
+	pf.reorderPointEntry = widget.NewEntry()
+	pf.reorderQtyEntry = widget.NewEntry()
+	pf.maxStockEntry = widget.NewEntry()
+	pf.leadTimeEntry = widget.NewEntry()
+	pf.supplierEntry = widget.NewSelectEntry(database.SupplierNames())
 
 	// Если редактируем существующий товар, заполняем поля
 	if pf.product != nil {
@@ -75,8 +117,11 @@ func (pf *ProductForm) initFields() {
 		pf.descEntry.SetText(pf.product.Description)
 		pf.quantityEntry.SetText(strconv.Itoa(pf.product.Quantity))
 		pf.reservedEntry.SetText(strconv.Itoa(pf.product.ReservedQuantity))
-		pf.purchaseEntry.SetText(strconv.FormatFloat(pf.product.PurchasePrice, 'f', 2, 64))
-		pf.sellingEntry.SetText(strconv.FormatFloat(pf.product.SellingPrice, 'f', 2, 64))
+		if database.Can(models.PermViewPurchasePrices) {
+			pf.purchaseEntry.SetText(pf.product.PurchasePrice.String())
+			pf.currencyEntry.SetText(string(pf.product.PurchaseCurrency))
+		}
+		pf.sellingEntry.SetText(pf.product.SellingPrice.String())
 		pf.minStockEntry.SetText(strconv.Itoa(pf.product.MinStockLevel))
 		pf.locationEntry.SetText(pf.product.Location)
 		pf.weightEntry.SetText(strconv.FormatFloat(pf.product.Weight, 'f', 2, 64))
@@ -84,123 +129,377 @@ func (pf *ProductForm) initFields() {
 		pf.materialEntry.SetText(pf.product.Material)
 		pf.marketplaceEntry.SetText(pf.product.MarketplaceID)
 		pf.activeCheck.SetChecked(pf.product.IsActive)
+		pf.reorderPointEntry.SetText(strconv.Itoa(pf.product.ReorderPoint))
+		pf.reorderQtyEntry.SetText(strconv.Itoa(pf.product.ReorderQuantity))
+		pf.maxStockEntry.SetText(strconv.Itoa(pf.product.MaxStockLevel))
+		pf.leadTimeEntry.SetText(strconv.Itoa(pf.product.LeadTimeDays))
+		if pf.product.SupplierID != nil {
+			var supplier models.Supplier
+			if database.DB.First(&supplier, *pf.product.SupplierID).Error == nil {
+				pf.supplierEntry.SetText(supplier.Name)
+			}
+		}
 	} else {
 		pf.activeCheck.SetChecked(true)
+		if database.Can(models.PermViewPurchasePrices) {
+			pf.currencyEntry.SetText(string(models.BaseCurrency))
+		}
+	}
+
+	// Поля, которые роль пользователя не может изменять, доступны только для чтения
+	if !database.Can(models.PermViewPurchasePrices) {
+		pf.purchaseEntry.SetPlaceHolder(lang.L("скрыто"))
+		pf.currencyEntry.SetPlaceHolder(lang.L("скрыто"))
+	}
+	if !pf.canEditPurchasePrice() {
+		pf.purchaseEntry.Disable()
+		pf.currencyEntry.Disable()
+	}
+	if !database.Can(models.PermEditPrices) {
+		pf.sellingEntry.Disable()
 	}
+	if !database.Can(models.PermAdjustStock) {
+		pf.quantityEntry.Disable()
+		pf.reservedEntry.Disable()
+	}
+}
+
+// canEditPurchasePrice - закупочную цену изменяет только тот, кто может ее видеть
+func (pf *ProductForm) canEditPurchasePrice() bool {
+	return database.Can(models.PermEditPrices) && database.Can(models.PermViewPurchasePrices)
+}
+
+// formItem - поле формы; field - имя поля для сообщений проверки, "" если поле не проверяется
+type formItem struct {
+	label  string
+	field  string
+	widget fyne.CanvasObject
 }
 
 func (pf *ProductForm) Show() {
 	// Создаем форму
-	items := []*widget.FormItem{
-		widget.NewFormItem("SKU*", pf.skuEntry),
-		widget.NewFormItem("Наименование*", pf.nameEntry),
-		widget.NewFormItem("Категория", pf.categoryEntry),
-		widget.NewFormItem("Бренд", pf.brandEntry),
-		widget.NewFormItem("Описание", pf.descEntry),
-		widget.NewFormItem("Количество", pf.quantityEntry),
-		widget.NewFormItem("Зарезервировано", pf.reservedEntry),
-		widget.NewFormItem("Закупочная цена", pf.purchaseEntry),
-		widget.NewFormItem("Цена продажи", pf.sellingEntry),
-		widget.NewFormItem("Мин. уровень", pf.minStockEntry),
-		widget.NewFormItem("Расположение", pf.locationEntry),
-		widget.NewFormItem("Вес (кг)", pf.weightEntry),
-		widget.NewFormItem("Габариты", pf.dimensionsEntry),
-		widget.NewFormItem("Материал", pf.materialEntry),
-		widget.NewFormItem("ID на маркетплейсе", pf.marketplaceEntry),
+	items := []formItem{
+		{"SKU*", models.FieldSKU, pf.skuEntry},
+		{lang.L("Наименование*"), models.FieldName, pf.nameEntry},
+		{lang.L("Категория"), "", pf.categoryEntry},
+		{lang.L("Бренд"), "", pf.brandEntry},
+		{lang.L("Описание"), "", pf.descEntry},
+		{lang.L("Количество"), models.FieldQuantity, pf.quantityEntry},
+		{lang.L("Зарезервировано"), models.FieldReserved, pf.reservedEntry},
+		{lang.L("Закупочная цена"), models.FieldPurchasePrice, pf.purchaseEntry},
+		{lang.L("Валюта закупки"), models.FieldPurchaseCurrency, pf.currencyEntry},
+		{lang.L("Цена продажи"), models.FieldSellingPrice, pf.sellingEntry},
+		{lang.L("Мин. уровень"), models.FieldMinStockLevel, pf.minStockEntry},
+		{lang.L("Точка заказа"), models.FieldReorderPoint, pf.reorderPointEntry},
+		{lang.L("Размер партии заказа"), models.FieldReorderQuantity, pf.reorderQtyEntry},
+		{lang.L("Макс. уровень"), models.FieldMaxStockLevel, pf.maxStockEntry},
+		{lang.L("Срок поставки (дней)"), models.FieldLeadTimeDays, pf.leadTimeEntry},
+		{lang.L("Поставщик"), "", pf.supplierEntry},
+		{lang.L("Расположение"), "", pf.locationEntry},
+		{lang.L("Вес (кг)"), models.FieldWeight, pf.weightEntry},
+		{lang.L("Габариты"), "", pf.dimensionsEntry},
+		{lang.L("Материал"), "", pf.materialEntry},
+		{lang.L("ID на маркетплейсе"), "", pf.marketplaceEntry},
 	}
 
 	// Создаем контент с прокруткой
 	content := container.NewVBox()
 
 	for _, item := range items {
-		content.Add(widget.NewLabel(item.Text))
+		content.Add(widget.NewLabel(item.label))
 		content.Add(container.NewPadded(
-			item.Widget,
+			item.widget,
 		))
+
+		if item.field == "" {
+			continue
+		}
+		message := widget.NewLabel("")
+		message.Wrapping = fyne.TextWrapWord
+		message.Hide()
+		content.Add(message)
+		pf.messages[item.field] = message
+
+		// Проверяем форму при каждом изменении поля
+		entry, ok := item.widget.(*widget.Entry)
+		if selectEntry, isSelect := item.widget.(*widget.SelectEntry); isSelect {
+			entry, ok = &selectEntry.Entry, true
+		}
+		if ok {
+			field := item.field
+			entry.OnChanged = func(string) {
+				pf.touched[field] = true
+				pf.validate()
+			}
+		}
 	}
 
 	content.Add(container.NewPadded(pf.activeCheck))
 
+	// Файлы сохраняются сразу, независимо от кнопки "Сохранить"
+	var productID uint
+	if pf.product != nil {
+		productID = pf.product.ID
+	}
+	gallery := NewAttachmentGallery(pf.window, productID, database.Can(models.PermEditProducts))
+	gallery.onChange = pf.onFilesChanged
+	content.Add(widget.NewLabel(lang.L("Изображения и документы")))
+	content.Add(container.NewPadded(gallery.Widget()))
+
 	scroll := container.NewScroll(content)
 	scroll.SetMinSize(fyne.NewSize(500, 500))
 
-	title := "Добавление товара"
-	if pf.product != nil {
-		title = "Редактирование товара"
+	title := lang.L("Добавление товара")
+	if pf.product != nil && pf.product.ID != 0 {
+		title = lang.L("Редактирование товара")
+		// У существующего товара сразу показываем все замечания
+		for field := range pf.messages {
+			pf.touched[field] = true
+		}
 	}
 
-	dialog.ShowCustomConfirm(title, "Сохранить", "Отмена", scroll,
-		func(confirm bool) {
-			if confirm {
-				pf.saveProduct()
-			}
-		},
-		pf.window,
-	)
+	// Собственные кнопки вместо ShowCustomConfirm, чтобы блокировать сохранение
+	pf.saveBtn = widget.NewButton(lang.L("Сохранить"), pf.saveProduct)
+	pf.saveBtn.Importance = widget.HighImportance
+	cancelBtn := widget.NewButton(lang.L("Отмена"), func() { pf.dialog.Hide() })
+
+	pf.dialog = dialog.NewCustomWithoutButtons(title, scroll, pf.window)
+	pf.dialog.SetButtons([]fyne.CanvasObject{cancelBtn, pf.saveBtn})
+	pf.validate()
+	pf.dialog.Show()
 }
 
-func (pf *ProductForm) saveProduct() {
-	// Валидация
-	if pf.skuEntry.Text == "" || pf.nameEntry.Text == "" {
-		err := pf.validationError("Заполните обязательные поля (SKU и Наименование)")
-		dialog.ShowError(err, pf.window)
-		return
+// validate проверяет введенные данные, показывает замечания под полями
+// и разрешает сохранение, только если ошибок нет
+func (pf *ProductForm) validate() models.ValidationErrors {
+	_, errs := pf.collect()
+
+	for field, message := range pf.messages {
+		issue, ok := errs.ForField(field)
+		if !ok || !pf.touched[field] {
+			message.Hide()
+			continue
+		}
+		message.Importance = widget.DangerImportance
+		if issue.Warning {
+			message.Importance = widget.WarningImportance
+		}
+		message.SetText(issue.Localize(translate))
+		message.Show()
 	}
 
-	// Создаем или обновляем продукт
+	setEnabled(pf.saveBtn, !errs.HasErrors())
+	return errs
+}
+
+// collect собирает товар из полей формы, не изменяя редактируемый товар,
+// и проверяет его. Ошибки разбора чисел возвращаются как ошибки полей.
+func (pf *ProductForm) collect() (*models.Product, models.ValidationErrors) {
 	product := &models.Product{}
 	if pf.product != nil {
-		product = pf.product
+		copied := *pf.product
+		product = &copied
 	}
+	var errs models.ValidationErrors
 
-	product.SKU = pf.skuEntry.Text
-	product.Name = pf.nameEntry.Text
+	product.SKU = strings.TrimSpace(pf.skuEntry.Text)
+	product.Name = strings.TrimSpace(pf.nameEntry.Text)
 	product.Category = pf.categoryEntry.Text
 	product.Brand = pf.brandEntry.Text
 	product.Description = pf.descEntry.Text
 
-	// Конвертируем строки в числа
-	quantity, _ := strconv.Atoi(pf.quantityEntry.Text)
-	product.Quantity = quantity
-
-	reserved, _ := strconv.Atoi(pf.reservedEntry.Text)
-	product.ReservedQuantity = reserved
-
-	purchase, _ := strconv.ParseFloat(pf.purchaseEntry.Text, 64)
-	product.PurchasePrice = purchase
-
-	selling, _ := strconv.ParseFloat(pf.sellingEntry.Text, 64)
-	product.SellingPrice = selling
-
-	minStock, _ := strconv.Atoi(pf.minStockEntry.Text)
-	if minStock > 0 {
-		product.MinStockLevel = minStock
+	// Недоступные роли поля сохраняют прежние значения
+	if database.Can(models.PermAdjustStock) {
+		product.Quantity = parseIntField(&errs, models.FieldQuantity, pf.quantityEntry.Text)
+		product.ReservedQuantity = parseIntField(&errs, models.FieldReserved, pf.reservedEntry.Text)
+	}
+	if pf.canEditPurchasePrice() {
+		product.PurchasePrice = parseMoneyField(&errs, models.FieldPurchasePrice, pf.purchaseEntry.Text)
+		product.PurchaseCurrency = models.Currency(strings.ToUpper(strings.TrimSpace(pf.currencyEntry.Text)))
+	}
+	if database.Can(models.PermEditPrices) {
+		product.SellingPrice = parseMoneyField(&errs, models.FieldSellingPrice, pf.sellingEntry.Text)
 	}
 
-	product.Location = pf.locationEntry.Text
+	// Пустой минимальный уровень оставляет прежнее значение (для нового товара - по умолчанию),
+	// введенный ноль сохраняется
+	switch {
+	case strings.TrimSpace(pf.minStockEntry.Text) != "":
+		product.MinStockLevel = parseIntField(&errs, models.FieldMinStockLevel, pf.minStockEntry.Text)
+	case pf.product == nil:
+		product.MinStockLevel = models.DefaultMinStockLevel
+	}
 
-	weight, _ := strconv.ParseFloat(pf.weightEntry.Text, 64)
-	product.Weight = weight
+	product.ReorderPoint = parseIntField(&errs, models.FieldReorderPoint, pf.reorderPointEntry.Text)
+	product.ReorderQuantity = parseIntField(&errs, models.FieldReorderQuantity, pf.reorderQtyEntry.Text)
+	product.MaxStockLevel = parseIntField(&errs, models.FieldMaxStockLevel, pf.maxStockEntry.Text)
+	product.LeadTimeDays = parseIntField(&errs, models.FieldLeadTimeDays, pf.leadTimeEntry.Text)
 
+	product.Location = pf.locationEntry.Text
+	product.Weight = parseDecimalField(&errs, models.FieldWeight, pf.weightEntry.Text)
 	product.Dimensions = pf.dimensionsEntry.Text
 	product.Material = pf.materialEntry.Text
 	product.MarketplaceID = pf.marketplaceEntry.Text
 	product.IsActive = pf.activeCheck.Checked
 
-	// Вызываем колбэк сохранения
-	pf.onSave(product)
+	errs = append(errs, database.ValidateProduct(product)...)
+	return product, errs
+}
+
+// parseIntField разбирает целое число из поля формы; пустое поле - ноль
+func parseIntField(errs *models.ValidationErrors, field, text string) int {
+	if strings.TrimSpace(text) == "" {
+		return 0
+	}
+	value, err := models.ParseInteger(text)
+	if err != nil {
+		errs.Add(field, "Введите целое число")
+	}
+	return value
+}
+
+// parseDecimalField разбирает число с точкой или запятой из поля формы; пустое поле - ноль
+func parseDecimalField(errs *models.ValidationErrors, field, text string) float64 {
+	if strings.TrimSpace(text) == "" {
+		return 0
+	}
+	value, err := models.ParseDecimal(text)
+	if err != nil {
+		errs.Add(field, "Введите число, например 1250,50")
+	}
+	return value
+}
+
+// parseMoneyField разбирает сумму из поля формы; пустое поле - ноль
+func parseMoneyField(errs *models.ValidationErrors, field, text string) models.Money {
+	if strings.TrimSpace(text) == "" {
+		return 0
+	}
+	value, err := models.ParseMoney(text)
+	if err != nil {
+		errs.Add(field, "Введите число, например 1250,50")
+	}
+	return value
 }
 
-// ИСПРАВЛЕНО: Добавлен метод для создания error
-func (pf *ProductForm) validationError(message string) error {
-	return &validationError{message}
+func (pf *ProductForm) saveProduct() {
+	product, errs := pf.collect()
+	if errs.HasErrors() {
+		// Показываем все ошибки, в том числе в полях, которые еще не редактировались
+		for field := range pf.messages {
+			pf.touched[field] = true
+		}
+		pf.validate()
+		return
+	}
+
+	supplierID, err := database.FindOrCreateSupplier(pf.supplierEntry.Text)
+	if err != nil {
+		showError(err, pf.window)
+		return
+	}
+	product.SupplierID = supplierID
+	product.Supplier = nil
+
+	// Новые категория и бренд добавляются в справочники
+	if err := database.ResolveCatalog(product); err != nil {
+		showError(err, pf.window)
+		return
+	}
+
+	pf.save(product)
 }
 
-// ИСПРАВЛЕНО: Структура для ошибки валидации
-type validationError struct {
-	msg string
+// save сохраняет товар и закрывает форму. Если товар изменили после открытия формы,
+// предлагает объединить изменения или перезаписать их.
+func (pf *ProductForm) save(product *models.Product) {
+	err := pf.onSave(product)
+	if errors.Is(err, database.ErrVersionConflict) {
+		pf.resolveConflict(product)
+		return
+	}
+	if err != nil {
+		showError(err, pf.window)
+		return
+	}
+
+	// Переносим изменения в редактируемый товар только после успешного сохранения
+	if pf.product != nil {
+		*pf.product = *product
+	}
+	pf.dialog.Hide()
 }
 
-func (e *validationError) Error() string {
-	return e.msg
+// conflictHeaders - заголовки таблицы конфликта версий; переводятся при создании таблицы
+var conflictHeaders = []string{"Поле", "При открытии", "Ваша версия", "Текущая версия"}
+
+// resolveConflict показывает различия формы и текущей версии товара и сохраняет
+// выбранный вариант: объединение изменений или форму целиком
+func (pf *ProductForm) resolveConflict(mine *models.Product) {
+	conflict, err := database.LoadProductConflict(pf.original, *mine)
+	if err != nil {
+		showError(err, pf.window)
+		return
+	}
+
+	retry := func(resolve func() (*models.Product, error)) {
+		product, err := resolve()
+		if err != nil {
+			showError(err, pf.window)
+			return
+		}
+		if errs := database.ValidateProduct(product); errs.HasErrors() {
+			showError(errs, pf.window)
+			return
+		}
+		// Следующий конфликт, если он случится, сравнивается уже с этой версией
+		pf.original = conflict.Theirs
+		pf.save(product)
+	}
+
+	// Другой пользователь изменил только служебные поля или сделал то же, что и форма
+	if len(conflict.Fields) == 0 {
+		retry(conflict.Merged)
+		return
+	}
+
+	showPurchase := database.Can(models.PermViewPurchasePrices)
+	rows := make([][]string, 0, len(conflict.Fields))
+	for _, f := range conflict.Fields {
+		title := auditFieldTitle(f.Field)
+		if f.Conflict {
+			title = "! " + title
+		}
+		values := []string{auditValueText(f.Field, f.Base), auditValueText(f.Field, f.Mine), auditValueText(f.Field, f.Theirs)}
+		if (f.Field == "purchase_price" || f.Field == "purchase_currency") && !showPurchase {
+			hidden := lang.L("скрыто")
+			values = []string{hidden, hidden, hidden}
+		}
+		rows = append(rows, append([]string{title}, values...))
+	}
+
+	message := widget.NewLabel(fmt.Sprintf(lang.L("Товар %s изменили после открытия формы. "+
+		"Поля, измененные и вами, и другим пользователем, отмечены «!».\n"+
+		"Объединить - сохранить ваши изменения, не затрагивая остальные изменения другого пользователя.\n"+
+		"Перезаписать - сохранить форму целиком, как она заполнена."), conflict.Theirs.SKU))
+	message.Wrapping = fyne.TextWrapWord
+	table := newTextTable(translateAll(conflictHeaders), []float32{200, 170, 170, 170}, &rows)
+	content := container.NewBorder(message, nil, nil, nil, table)
+
+	var d *dialog.CustomDialog
+	mergeBtn := widget.NewButton(lang.L("Объединить"), func() {
+		d.Hide()
+		retry(conflict.Merged)
+	})
+	mergeBtn.Importance = widget.HighImportance
+	overwriteBtn := widget.NewButton(lang.L("Перезаписать"), func() {
+		d.Hide()
+		retry(conflict.Overwritten)
+	})
+	cancelBtn := widget.NewButton(lang.L("Отмена"), func() { d.Hide() })
+
+	d = dialog.NewCustomWithoutButtons(lang.L("Товар изменен другим пользователем"), content, pf.window)
+	d.SetButtons([]fyne.CanvasObject{cancelBtn, overwriteBtn, mergeBtn})
+	d.Resize(fyne.NewSize(760, 460))
+	d.Show()
 }
@@ -7,38 +7,169 @@ import (
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 
+	"gorm.io/gorm"
+
 	"SanWarehouse/database"
 	"SanWarehouse/models"
 )
 
+// productColumn описывает колонку таблицы товаров
+type productColumn struct {
+	title string
+	width float32
+	// orderBy - SQL-выражение для сортировки по колонке
+	orderBy string
+	value   func(p *models.Product) string
+}
+
+var productColumns = []productColumn{
+	{"ID", 50, "id", func(p *models.Product) string { return fmt.Sprintf("%d", p.ID) }},
+	{"SKU", 100, "sku", func(p *models.Product) string { return p.SKU }},
+	{"Название", 200, "name", func(p *models.Product) string { return truncate(p.Name, 20) }},
+	{"Категория", 160, "category", func(p *models.Product) string { return truncate(p.Category, 24) }},
+	{"Бренд", 100, "brand", func(p *models.Product) string { return p.Brand }},
+	{"Кол-во", 70, "quantity", func(p *models.Product) string { return formatInt(int64(p.Quantity)) }},
+	{"Доступно", 80, "quantity - reserved_quantity", func(p *models.Product) string { return formatInt(int64(p.AvailableQuantity())) }},
+	{"Цена", 80, "selling_price", func(p *models.Product) string { return formatNumber(p.SellingPrice.Float(), 0) }},
+	{"Статус", 100, "status", func(p *models.Product) string { return lang.L(p.Status.Title()) }},
+	{"Расположение", 110, "location", func(p *models.Product) string { return p.Location }},
+	{"Класс", 60, "abc_class || xyz_class", func(p *models.Product) string { return p.ABCClass + p.XYZClass }},
+}
+
+// Значение фильтра "без ограничений"; показывается переведенным
+const filterAll = "Все"
+
+// sortRelevance - сортировка результатов поиска по релевантности вместо колонки
+const sortRelevance = -1
+
+// Колонка миниатюр основного изображения, включается флажком на панели фильтров.
+// Заголовки колонок переводятся при отображении.
+const (
+	thumbnailColumnTitle = "Фото"
+	thumbnailColumnWidth = 60
+	thumbnailRowHeight   = 48
+)
+
+// productPageSize - сколько товаров подгружается за раз при прокрутке списка
+const productPageSize = 200
+
+// ProductFilter - фильтры по колонкам списка товаров
+type ProductFilter struct {
+	Category string
+	Brand    string
+	Status   string
+	Location string
+	// Active: "" - все, "true" - только активные, "false" - только неактивные
+	Active string
+
+	// Классы ABC/XYZ, пустая строка - без фильтра
+	ABCClass string
+	XYZClass string
+
+	// Search - строка поиска с квалификаторами, см. database.ParseSearchQuery
+	Search string
+}
+
+// Apply добавляет условия фильтра к запросу
+func (f ProductFilter) Apply(query *gorm.DB) *gorm.DB {
+	if f.Category != "" {
+		// Родительская категория включает товары подкатегорий
+		query = database.InCategory(query, f.Category)
+	}
+	if f.Brand != "" {
+		query = query.Where("brand = ?", f.Brand)
+	}
+	if f.Status != "" {
+		query = query.Where("status = ?", f.Status)
+	}
+	if f.Location != "" {
+		query = query.Where("location = ?", f.Location)
+	}
+	if f.Active != "" {
+		query = query.Where("is_active = ?", f.Active == "true")
+	}
+	if f.ABCClass != "" {
+		query = query.Where("abc_class = ?", f.ABCClass)
+	}
+	if f.XYZClass != "" {
+		query = query.Where("xyz_class = ?", f.XYZClass)
+	}
+	if f.Search != "" {
+		query = database.SearchProducts(query, f.Search)
+	}
+	return query
+}
+
 type ProductList struct {
 	widget.Table
 	mainWindow *MainWindow
-	products   []models.Product
+	// products - загруженные строки; остальные подгружаются по мере прокрутки
+	products []models.Product
+	// total - число товаров, подходящих под фильтры
+	total int64
+	// next - позиция следующей страницы, nil если загружено все
+	next    *database.PageCursor
+	loading bool
+
+	filter   ProductFilter
+	sortCol  int
+	sortDesc bool
+
+	// Виджеты фильтров по колонкам
+	categorySelect *widget.Select
+	brandSelect    *widget.Select
+	statusSelect   *widget.Select
+	locationSelect *widget.Select
+	activeSelect   *widget.Select
+	searchBar      *SearchBar
+	thumbsCheck    *widget.Check
+
+	// showThumbs - показывать колонку миниатюр; thumbnails - пути к миниатюрам загруженных товаров
+	showThumbs bool
+	thumbnails map[uint]string
+
+	// Выделенные товары (по ID) для массовых действий
+	selected map[uint]bool
+	// Текущая строка и строка, от которой строится выделение с Shift
+	cursorRow int
+	anchorRow int
+	// Выделение вызвано правым кликом
+	secondaryTap bool
 }
 
 func NewProductList(mw *MainWindow) *ProductList {
 	list := &ProductList{
 		mainWindow: mw,
 		products:   []models.Product{},
+		selected:   map[uint]bool{},
+		thumbnails: map[uint]string{},
+		cursorRow:  -1,
 	}
 
-	// Загружаем данные
-	database.DB.Find(&list.products)
-
-	// ИСПРАВЛЕНО: функция Length должна возвращать (rows, cols)
 	list.Length = func() (int, int) {
-		return len(list.products), 10 // 10 колонок
+		if list.showThumbs {
+			return len(list.products), len(productColumns) + 1
+		}
+		return len(list.products), len(productColumns)
 	}
 
 	list.CreateCell = func() fyne.CanvasObject {
-		// Создаем ячейку с контейнером для фона и текста
+		// Создаем ячейку с контейнером для фона, текста и миниатюры.
+		// Таблица берет высоту строк из шаблона ячейки, поэтому с миниатюрами строки выше.
 		bg := canvas.NewRectangle(color.Transparent)
 		text := widget.NewLabel("Template")
 		text.Alignment = fyne.TextAlignCenter
-		return container.NewStack(bg, text)
+		img := canvas.NewImageFromResource(nil)
+		img.FillMode = canvas.ImageFillContain
+		if list.showThumbs {
+			img.SetMinSize(fyne.NewSize(thumbnailRowHeight, thumbnailRowHeight))
+		}
+		return container.NewStack(bg, text, img)
 	}
 
 	list.UpdateCell = func(id widget.TableCellID, obj fyne.CanvasObject) {
@@ -49,99 +180,501 @@ func NewProductList(mw *MainWindow) *ProductList {
 		container := obj.(*fyne.Container)
 		bg := container.Objects[0].(*canvas.Rectangle)
 		label := container.Objects[1].(*widget.Label)
+		img := container.Objects[2].(*canvas.Image)
 
-		product := list.products[id.Row]
+		// Подгружаем следующую страницу, когда прокрутка приближается к концу загруженных строк
+		if id.Row >= len(list.products)-productPageSize/4 {
+			list.scheduleLoadMore()
+		}
+
+		product := &list.products[id.Row]
 
-		// Устанавливаем цвет фона в зависимости от статуса
-		switch product.Status {
-		case models.StatusLowStock:
+		// Устанавливаем цвет фона в зависимости от выделения и статуса
+		switch {
+		case list.selected[product.ID]:
+			bg.FillColor = theme.Color(theme.ColorNameSelection)
+		case product.Status == models.StatusLowStock:
 			bg.FillColor = &color.NRGBA{R: 255, G: 255, B: 0, A: 50} // Желтый
-		case models.StatusOutOfStock:
+		case product.Status == models.StatusOutOfStock:
 			bg.FillColor = &color.NRGBA{R: 255, G: 0, B: 0, A: 50} // Красный
 		default:
 			bg.FillColor = color.Transparent
 		}
 		bg.Refresh()
 
-		// Устанавливаем текст в зависимости от колонки
-		switch id.Col {
-		case 0:
-			label.SetText(fmt.Sprintf("%d", product.ID))
-		case 1:
-			label.SetText(product.SKU)
-		case 2:
-			label.SetText(truncate(product.Name, 20))
-		case 3:
-			label.SetText(product.Category)
-		case 4:
-			label.SetText(product.Brand)
-		case 5:
-			label.SetText(fmt.Sprintf("%d", product.Quantity))
-		case 6:
-			label.SetText(fmt.Sprintf("%d", product.AvailableQuantity()))
-		case 7:
-			label.SetText(fmt.Sprintf("%.0f", product.SellingPrice))
-		case 8:
-			label.SetText(string(product.Status))
-		case 9:
-			label.SetText(product.Location)
-		}
-	}
-
-	// ИСПРАВЛЕНО: используем SetColumnWidth вместо прямого доступа
-	list.SetColumnWidth(0, 50)  // ID
-	list.SetColumnWidth(1, 100) // SKU
-	list.SetColumnWidth(2, 200) // Name
-	list.SetColumnWidth(3, 100) // Category
-	list.SetColumnWidth(4, 100) // Brand
-	list.SetColumnWidth(5, 70)  // Quantity
-	list.SetColumnWidth(6, 70)  // Available
-	list.SetColumnWidth(7, 80)  // Price
-	list.SetColumnWidth(8, 100) // Status
-	list.SetColumnWidth(9, 80)  // Location
+		col := list.dataColumn(id.Col)
+		if col < 0 {
+			label.Hide()
+			img.File = list.thumbnails[product.ID]
+			img.Show()
+			img.Refresh()
+			return
+		}
+		img.Hide()
+		label.Show()
+		label.SetText(productColumns[col].value(product))
+	}
+
+	list.OnSelected = list.onCellSelected
+
+	// Заголовки колонок: нажатие сортирует по колонке
+	list.ShowHeaderRow = true
+	list.CreateHeader = func() fyne.CanvasObject {
+		btn := widget.NewButton("Template", nil)
+		btn.Importance = widget.LowImportance
+		return btn
+	}
+	list.UpdateHeader = func(id widget.TableCellID, obj fyne.CanvasObject) {
+		btn := obj.(*widget.Button)
+		col := list.dataColumn(id.Col)
+		if col < 0 || col >= len(productColumns) {
+			btn.SetText("")
+			if list.showThumbs && id.Col == 0 {
+				btn.SetText(lang.L(thumbnailColumnTitle))
+			}
+			btn.OnTapped = nil
+			return
+		}
+
+		// "ID" - зарезервированное слово каталогов переводов, его нельзя сделать ключом
+		text := productColumns[col].title
+		if text != "ID" {
+			text = lang.L(text)
+		}
+		if col == list.sortCol {
+			if list.sortDesc {
+				text += " ▼"
+			} else {
+				text += " ▲"
+			}
+		}
+		btn.SetText(text)
+
+		btn.OnTapped = func() {
+			list.SortBy(col)
+		}
+	}
+
+	list.applyColumnWidths()
+	list.createFilterWidgets()
 
 	list.ExtendBaseWidget(list)
 	return list
 }
 
+// createFilterWidgets создает выпадающие списки фильтров по колонкам
+func (pl *ProductList) createFilterWidgets() {
+	all := lang.L(filterAll)
+	onChange := func(target *string) func(string) {
+		return func(s string) {
+			if s == all {
+				s = ""
+			}
+			if *target == s {
+				return
+			}
+			*target = s
+			pl.RefreshList()
+		}
+	}
+
+	pl.categorySelect = widget.NewSelect(nil, onChange(&pl.filter.Category))
+	pl.brandSelect = widget.NewSelect(nil, onChange(&pl.filter.Brand))
+	pl.locationSelect = widget.NewSelect(nil, onChange(&pl.filter.Location))
+
+	// Статусы показываются названиями, в фильтр попадает значение
+	statuses := []string{all}
+	statusValues := map[string]string{all: ""}
+	for _, status := range models.ProductStatuses {
+		title := lang.L(status.Title())
+		statuses = append(statuses, title)
+		statusValues[title] = string(status)
+	}
+	pl.statusSelect = widget.NewSelect(statuses, func(s string) {
+		if pl.filter.Status == statusValues[s] {
+			return
+		}
+		pl.filter.Status = statusValues[s]
+		pl.RefreshList()
+	})
+
+	active, inactive := lang.L("Активные"), lang.L("Неактивные")
+	activeValues := map[string]string{all: "", active: "true", inactive: "false"}
+	pl.activeSelect = widget.NewSelect([]string{all, active, inactive}, func(s string) {
+		if pl.filter.Active == activeValues[s] {
+			return
+		}
+		pl.filter.Active = activeValues[s]
+		pl.RefreshList()
+	})
+
+	for _, s := range []*widget.Select{pl.categorySelect, pl.brandSelect, pl.statusSelect, pl.locationSelect, pl.activeSelect} {
+		s.PlaceHolder = all
+	}
+
+	pl.thumbsCheck = widget.NewCheck(lang.L(thumbnailColumnTitle), pl.ShowThumbnails)
+
+	pl.searchBar = NewSearchBar(func(query string) {
+		pl.Search(query)
+		pl.mainWindow.statusBar.SetText(fmt.Sprintf(lang.L("Найдено товаров: %d"), pl.total))
+	})
+}
+
+// SearchBar возвращает строку поиска списка
+func (pl *ProductList) SearchBar() *SearchBar {
+	return pl.searchBar
+}
+
+// FilterBar возвращает панель фильтров по колонкам
+func (pl *ProductList) FilterBar() fyne.CanvasObject {
+	return container.NewHBox(
+		widget.NewLabel(lang.L("Категория:")), pl.categorySelect,
+		widget.NewLabel(lang.L("Бренд:")), pl.brandSelect,
+		widget.NewLabel(lang.L("Статус:")), pl.statusSelect,
+		widget.NewLabel(lang.L("Место:")), pl.locationSelect,
+		widget.NewLabel(lang.L("Активность:")), pl.activeSelect,
+		pl.thumbsCheck,
+	)
+}
+
+// dataColumn переводит номер колонки таблицы в индекс productColumns; -1 - колонка миниатюр
+func (pl *ProductList) dataColumn(col int) int {
+	if pl.showThumbs {
+		return col - 1
+	}
+	return col
+}
+
+// applyColumnWidths задает ширину колонок с учетом колонки миниатюр
+func (pl *ProductList) applyColumnWidths() {
+	offset := 0
+	if pl.showThumbs {
+		pl.SetColumnWidth(0, thumbnailColumnWidth)
+		offset = 1
+	}
+	for i, c := range productColumns {
+		pl.SetColumnWidth(i+offset, c.width)
+	}
+}
+
+// ShowThumbnails включает или выключает колонку миниатюр основных изображений
+func (pl *ProductList) ShowThumbnails(show bool) {
+	if pl.showThumbs == show {
+		return
+	}
+	pl.showThumbs = show
+	pl.applyColumnWidths()
+	pl.RefreshThumbnails()
+}
+
+// RefreshThumbnails перечитывает миниатюры загруженных товаров, например после изменения изображений
+func (pl *ProductList) RefreshThumbnails() {
+	pl.thumbnails = map[uint]string{}
+	if pl.showThumbs {
+		pl.loadThumbnails(pl.products)
+	}
+	pl.Refresh()
+}
+
+// loadThumbnails загружает пути к миниатюрам для товаров products
+func (pl *ProductList) loadThumbnails(products []models.Product) {
+	ids := make([]uint, len(products))
+	for i, p := range products {
+		ids[i] = p.ID
+	}
+	for id, path := range database.PrimaryThumbnails(ids) {
+		pl.thumbnails[id] = path
+	}
+}
+
+// updateFilterOptions обновляет списки значений фильтров по данным из БД
+func (pl *ProductList) updateFilterOptions() {
+	distinct := func(column string) []string {
+		var values []string
+		database.DB.Model(&models.Product{}).
+			Where(column+" <> ''").
+			Distinct(column).
+			Order(column).
+			Pluck(column, &values)
+		return append([]string{lang.L(filterAll)}, values...)
+	}
+
+	pl.categorySelect.SetOptions(append([]string{lang.L(filterAll)}, database.CategoryPaths()...))
+	pl.brandSelect.SetOptions(append([]string{lang.L(filterAll)}, database.BrandNames()...))
+	pl.locationSelect.SetOptions(distinct("location"))
+}
+
+// query строит запрос списка товаров с учетом фильтров
+func (pl *ProductList) query() *gorm.DB {
+	return pl.filter.Apply(database.DB.Model(&models.Product{}))
+}
+
+// orderExpr возвращает выражение и направление сортировки списка
+func (pl *ProductList) orderExpr() (string, bool) {
+	if pl.sortCol == sortRelevance {
+		if expr := database.RelevanceExpr(pl.filter.Search); expr != "" {
+			return expr, false
+		}
+		return productColumns[0].orderBy, false
+	}
+	return productColumns[pl.sortCol].orderBy, pl.sortDesc
+}
+
+// RefreshList перечитывает список с первой страницы. Загружается не меньше строк,
+// чем было, чтобы после редактирования список не "отпрыгивал" к началу.
 func (pl *ProductList) RefreshList() {
-	database.DB.Find(&pl.products)
+	limit := productPageSize
+	if len(pl.products) > limit {
+		limit = len(pl.products)
+	}
+	pl.products = pl.products[:0]
+	pl.thumbnails = map[uint]string{}
+	pl.next = nil
+	pl.loadPage(limit)
+	pl.query().Count(&pl.total)
+
+	// Убираем из выделения товары, которых больше нет в списке
+	visible := make(map[uint]bool, len(pl.products))
+	for _, p := range pl.products {
+		visible[p.ID] = true
+	}
+	for id := range pl.selected {
+		if !visible[id] {
+			delete(pl.selected, id)
+		}
+	}
+	if pl.cursorRow >= len(pl.products) {
+		pl.cursorRow = len(pl.products) - 1
+	}
+
+	pl.updateFilterOptions()
 	pl.Refresh()
 }
 
+// loadPage загружает следующие limit товаров после уже загруженных
+func (pl *ProductList) loadPage(limit int) {
+	expr, desc := pl.orderExpr()
+	page, err := database.ProductsPage(pl.query(), expr, desc, pl.next, limit)
+	if err != nil {
+		pl.mainWindow.statusBar.SetText(fmt.Sprintf(lang.L("Ошибка загрузки товаров: %v"), errorText(err)))
+		return
+	}
+	pl.products = append(pl.products, page.Products...)
+	pl.next = page.Next
+	if pl.showThumbs {
+		pl.loadThumbnails(page.Products)
+	}
+}
+
+// scheduleLoadMore подгружает следующую страницу после завершения текущей отрисовки таблицы
+func (pl *ProductList) scheduleLoadMore() {
+	if pl.next == nil || pl.loading {
+		return
+	}
+	pl.loading = true
+	go fyne.Do(func() {
+		pl.loading = false
+		if pl.next == nil {
+			return
+		}
+		pl.loadPage(productPageSize)
+		pl.Refresh()
+	})
+}
+
+// Total возвращает число товаров, подходящих под фильтры (в том числе еще не загруженных)
+func (pl *ProductList) Total() int64 {
+	return pl.total
+}
+
+// SortBy сортирует список по колонке; повторное нажатие меняет направление
+func (pl *ProductList) SortBy(col int) {
+	if pl.sortCol == col {
+		pl.sortDesc = !pl.sortDesc
+	} else {
+		pl.sortCol = col
+		pl.sortDesc = false
+	}
+	pl.RefreshList()
+}
+
+// Search фильтрует список по строке поиска
 func (pl *ProductList) Search(query string) {
-	database.DB.Where("sku LIKE ? OR name LIKE ? OR category LIKE ?",
-		"%"+query+"%", "%"+query+"%", "%"+query+"%").Find(&pl.products)
-	pl.Refresh()
+	if pl.filter.Search == query {
+		return
+	}
+	pl.filter.Search = query
+
+	// Результаты поиска по словам показываются по релевантности,
+	// пока пользователь не выберет сортировку по колонке
+	hasText := len(database.ParseSearchQuery(query).FreeText()) > 0
+	if hasText && database.FullTextEnabled() && pl.sortCol == 0 && !pl.sortDesc {
+		pl.sortCol = sortRelevance
+	} else if !hasText && pl.sortCol == sortRelevance {
+		pl.sortCol = 0
+	}
+	pl.RefreshList()
+}
+
+// FilterByClass оставляет в списке только товары указанных классов ABC/XYZ
+func (pl *ProductList) FilterByClass(abc, xyz string) {
+	pl.filter.ABCClass = abc
+	pl.filter.XYZClass = xyz
+	pl.RefreshList()
+}
+
+// ClearFilters сбрасывает все фильтры и поиск
+func (pl *ProductList) ClearFilters() {
+	pl.filter = ProductFilter{}
+	if pl.sortCol == sortRelevance {
+		pl.sortCol = 0
+	}
+	for _, s := range []*widget.Select{pl.categorySelect, pl.brandSelect, pl.statusSelect, pl.locationSelect, pl.activeSelect} {
+		s.ClearSelected()
+	}
+	pl.searchBar.Reset()
+	pl.RefreshList()
 }
 
 func truncate(s string, n int) string {
-	if len(s) <= n {
+	// Считаем символы, а не байты, чтобы не разрезать кириллицу посередине
+	runes := []rune(s)
+	if len(runes) <= n {
 		return s
 	}
-	return s[:n] + "..."
+	return string(runes[:n]) + "..."
+}
+
+// onCellSelected обновляет выделение строк с учетом Ctrl и Shift
+func (pl *ProductList) onCellSelected(id widget.TableCellID) {
+	if id.Row < 0 || id.Row >= len(pl.products) {
+		return
+	}
+
+	var modifiers fyne.KeyModifier
+	if drv, ok := fyne.CurrentApp().Driver().(desktop.Driver); ok {
+		modifiers = drv.CurrentKeyModifiers()
+	}
+
+	productID := pl.products[id.Row].ID
+	switch {
+	case pl.secondaryTap && pl.selected[productID]:
+		// Правый клик по уже выделенной строке не сбрасывает выделение
+	case modifiers&fyne.KeyModifierShortcutDefault != 0:
+		if pl.selected[productID] {
+			delete(pl.selected, productID)
+		} else {
+			pl.selected[productID] = true
+		}
+		pl.anchorRow = id.Row
+	case modifiers&fyne.KeyModifierShift != 0 && pl.cursorRow >= 0:
+		from, to := pl.anchorRow, id.Row
+		if from > to {
+			from, to = to, from
+		}
+		pl.selected = map[uint]bool{}
+		for i := from; i <= to && i < len(pl.products); i++ {
+			pl.selected[pl.products[i].ID] = true
+		}
+	default:
+		pl.selected = map[uint]bool{productID: true}
+		pl.anchorRow = id.Row
+	}
+	pl.cursorRow = id.Row
+
+	// Снимаем выделение ячейки таблицы, чтобы повторный клик по ней снова вызывал OnSelected
+	pl.UnselectAll()
+	pl.Refresh()
+	pl.mainWindow.onSelectionChanged()
+}
+
+// CurrentProduct возвращает товар в текущей строке
+func (pl *ProductList) CurrentProduct() *models.Product {
+	if pl.cursorRow < 0 || pl.cursorRow >= len(pl.products) {
+		return nil
+	}
+	p := pl.products[pl.cursorRow]
+	return &p
+}
+
+// SelectedIDs возвращает ID выделенных товаров в порядке их отображения
+func (pl *ProductList) SelectedIDs() []uint {
+	var ids []uint
+	for _, p := range pl.products {
+		if pl.selected[p.ID] {
+			ids = append(ids, p.ID)
+		}
+	}
+	return ids
+}
+
+// ListedIDs возвращает ID всех товаров, подходящих под фильтры, включая еще не загруженные
+func (pl *ProductList) ListedIDs() []uint {
+	var ids []uint
+	pl.query().Pluck("products.id", &ids)
+	return ids
+}
+
+// ClearSelection снимает выделение со всех строк
+func (pl *ProductList) ClearSelection() {
+	pl.selected = map[uint]bool{}
+	pl.cursorRow = -1
+	pl.Refresh()
+	pl.mainWindow.onSelectionChanged()
+}
+
+// DoubleTapped открывает карточку товара в текущей строке
+func (pl *ProductList) DoubleTapped(_ *fyne.PointEvent) {
+	if p := pl.CurrentProduct(); p != nil {
+		pl.mainWindow.showProductDetail(p.ID)
+	}
 }
 
-// Добавляем метод для обработки заголовков
-func (pl *ProductList) CreateHeader() fyne.CanvasObject {
-	headers := []string{"ID", "SKU", "Название", "Категория", "Бренд", "Кол-во", "Доступно", "Цена", "Статус", "Расположение"}
+// TappedSecondary выделяет строку под курсором и показывает контекстное меню
+func (pl *ProductList) TappedSecondary(ev *fyne.PointEvent) {
+	pl.secondaryTap = true
+	pl.Tapped(ev)
+	pl.secondaryTap = false
 
-	headerContainer := container.NewWithoutLayout()
-	for _, h := range headers {
-		label := widget.NewLabelWithStyle(h, fyne.TextAlignCenter, fyne.TextStyle{Bold: true})
-		headerContainer.Add(label)
+	if pl.CurrentProduct() == nil {
+		return
 	}
-	return headerContainer
+
+	menu := pl.mainWindow.productContextMenu()
+	c := fyne.CurrentApp().Driver().CanvasForObject(pl)
+	widget.ShowPopUpMenuAtPosition(menu, c, ev.AbsolutePosition)
 }
 
-// Добавляем метод для кнопок действий
-func (pl *ProductList) DoubleTapped(ev *fyne.PointEvent) {
-	// Находим строку по координатам
-	for i, product := range pl.products {
-		// Простая логика: если координаты в пределах строки
-		if ev.Position.Y > float32((i-1)*40+40) && ev.Position.Y < float32((i)*40+40) {
-			pl.mainWindow.showProductForm(&product)
-			break
+// TypedKey добавляет к навигации таблицы редактирование по Enter и удаление по Delete
+func (pl *ProductList) TypedKey(ev *fyne.KeyEvent) {
+	switch ev.Name {
+	case fyne.KeyReturn, fyne.KeyEnter:
+		if p := pl.CurrentProduct(); p != nil {
+			pl.mainWindow.editProduct(p.ID)
+		}
+		return
+	case fyne.KeyDelete:
+		if ids := pl.SelectedIDs(); len(ids) > 0 {
+			pl.mainWindow.deleteProducts(ids)
+		}
+		return
+	case fyne.KeyDown:
+		if pl.cursorRow < len(pl.products)-1 {
+			pl.moveCursor(pl.cursorRow + 1)
+		}
+	case fyne.KeyUp:
+		if pl.cursorRow > 0 {
+			pl.moveCursor(pl.cursorRow - 1)
 		}
 	}
+	pl.Table.TypedKey(ev)
+}
+
+// moveCursor переводит выделение на строку row при навигации с клавиатуры
+func (pl *ProductList) moveCursor(row int) {
+	pl.cursorRow = row
+	pl.anchorRow = row
+	pl.selected = map[uint]bool{pl.products[row].ID: true}
+	pl.Refresh()
+	pl.mainWindow.onSelectionChanged()
 }
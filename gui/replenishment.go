@@ -0,0 +1,382 @@
+package gui
+
+import (
+	"encoding/csv"
+	"fmt"
+	"image/color"
+	"sort"
+	"strconv"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"gorm.io/gorm"
+
+	"SanWarehouse/database"
+	"SanWarehouse/models"
+)
+
+// replenishmentRow - рекомендация по пополнению одного товара
+type replenishmentRow struct {
+	product      models.Product
+	onOrder      int
+	dailyUsage   float64
+	reorderPoint int
+	quantity     int
+}
+
+// Replenishment - окно рекомендаций по пополнению запасов
+type Replenishment struct {
+	mainWindow *MainWindow
+	window     fyne.Window
+	table      *widget.Table
+	summary    *widget.Label
+
+	periodDays int
+	rows       []replenishmentRow
+}
+
+func NewReplenishment(mw *MainWindow) *Replenishment {
+	return &Replenishment{
+		mainWindow: mw,
+		periodDays: 90,
+	}
+}
+
+// Show открывает окно пополнения запасов
+func (r *Replenishment) Show() {
+	r.window = r.mainWindow.app.NewWindow(lang.L("Пополнение запасов"))
+	r.window.Resize(fyne.NewSize(1000, 600))
+
+	headers := translateAll([]string{"SKU", "Наименование", "Доступно", "В заказах", "Расход/день", "Точка заказа", "К заказу", "Поставщик"})
+
+	r.table = widget.NewTable(
+		func() (int, int) {
+			return len(r.rows) + 1, len(headers)
+		},
+		func() fyne.CanvasObject {
+			return container.NewStack(
+				canvas.NewRectangle(color.Transparent),
+				widget.NewLabel("Template"),
+			)
+		},
+		func(id widget.TableCellID, obj fyne.CanvasObject) {
+			container := obj.(*fyne.Container)
+			bg := container.Objects[0].(*canvas.Rectangle)
+			label := container.Objects[1].(*widget.Label)
+
+			if id.Row == 0 {
+				label.TextStyle.Bold = true
+				label.SetText(headers[id.Col])
+				bg.FillColor = &color.NRGBA{R: 200, G: 200, B: 200, A: 255}
+				bg.Refresh()
+				return
+			}
+			label.TextStyle.Bold = false
+			bg.FillColor = color.Transparent
+			bg.Refresh()
+
+			row := r.rows[id.Row-1]
+			label.SetText(r.cellText(row, id.Col))
+		})
+
+	widths := []float32{100, 250, 80, 80, 90, 100, 80, 150}
+	for i, w := range widths {
+		r.table.SetColumnWidth(i, w)
+	}
+
+	period := widget.NewSelect([]string{"30", "90", "180", "365"}, func(s string) {
+		r.periodDays, _ = strconv.Atoi(s)
+		r.load()
+	})
+	// Значение задается без вызова OnChanged: рекомендации загружаются ниже, когда создана строка итогов
+	period.Selected = strconv.Itoa(r.periodDays)
+
+	toolbar := container.NewHBox(
+		widget.NewLabel(lang.L("Период расхода (дней):")),
+		period,
+		widget.NewButtonWithIcon(lang.L("Обновить"), theme.ViewRefreshIcon(), r.load),
+		widget.NewButtonWithIcon(lang.L("Создать черновик заказа"), theme.DocumentCreateIcon(), r.createDraftOrders),
+		widget.NewButtonWithIcon(lang.L("Экспорт в CSV"), theme.DownloadIcon(), r.exportToCSV),
+		widget.NewButtonWithIcon(lang.L("Открытые заказы"), theme.ListIcon(), r.showOpenOrders),
+	)
+
+	r.summary = widget.NewLabel("")
+
+	content := container.NewBorder(toolbar, r.summary, nil, nil, r.table)
+	r.window.SetContent(content)
+
+	r.load()
+	r.window.Show()
+}
+
+func (r *Replenishment) cellText(row replenishmentRow, col int) string {
+	switch col {
+	case 0:
+		return row.product.SKU
+	case 1:
+		return truncate(row.product.Name, 30)
+	case 2:
+		return formatInt(int64(row.product.AvailableQuantity()))
+	case 3:
+		return formatInt(int64(row.onOrder))
+	case 4:
+		return formatNumber(row.dailyUsage, 2)
+	case 5:
+		return formatInt(int64(row.reorderPoint))
+	case 6:
+		return formatInt(int64(row.quantity))
+	case 7:
+		if row.product.Supplier != nil {
+			return row.product.Supplier.Name
+		}
+		return "—"
+	}
+	return ""
+}
+
+// load пересчитывает рекомендации по текущим остаткам, заказам и расходу
+func (r *Replenishment) load() {
+	var products []models.Product
+	database.DB.Preload("Supplier").Where("is_active = ?", true).Find(&products)
+
+	onOrder := database.OpenOrderQuantities()
+	shipped := database.ShipmentTotals(time.Now().AddDate(0, 0, -r.periodDays))
+
+	r.rows = r.rows[:0]
+	for _, p := range products {
+		usage := float64(shipped[p.ID]) / float64(r.periodDays)
+		quantity := p.SuggestOrderQuantity(onOrder[p.ID], usage)
+		if quantity <= 0 {
+			continue
+		}
+		r.rows = append(r.rows, replenishmentRow{
+			product:      p,
+			onOrder:      onOrder[p.ID],
+			dailyUsage:   usage,
+			reorderPoint: p.EffectiveReorderPoint(usage),
+			quantity:     quantity,
+		})
+	}
+
+	// Группируем по поставщику, чтобы заказ было удобно собирать
+	sort.Slice(r.rows, func(i, j int) bool {
+		si, sj := r.supplierName(r.rows[i]), r.supplierName(r.rows[j])
+		if si != sj {
+			return si < sj
+		}
+		return r.rows[i].product.SKU < r.rows[j].product.SKU
+	})
+
+	// Сумма закупки - в валюте учета по текущим курсам
+	rates := database.CurrentExchangeRates()
+	var total models.Money
+	missing := map[models.Currency]bool{}
+	for _, row := range r.rows {
+		cost, ok := rates.Convert(row.product.PurchasePrice.Times(row.quantity), row.product.PurchaseCurrency)
+		if !ok {
+			missing[row.product.PurchaseCurrency] = true
+		}
+		total += cost
+	}
+	summary := fmt.Sprintf(lang.L("Позиций к заказу: %d | Сумма закупки: %s"), len(r.rows), formatMoney(total))
+	if len(missing) > 0 {
+		currencies := make([]models.Currency, 0, len(missing))
+		for c := range missing {
+			currencies = append(currencies, c)
+		}
+		sort.Slice(currencies, func(i, j int) bool { return currencies[i] < currencies[j] })
+		summary += " " + fmt.Sprintf(lang.L("(без товаров в %s: нет курса)"), currencyList(currencies))
+	}
+	r.summary.SetText(summary)
+	r.table.Refresh()
+}
+
+func (r *Replenishment) supplierName(row replenishmentRow) string {
+	if row.product.Supplier != nil {
+		return row.product.Supplier.Name
+	}
+	return ""
+}
+
+// draftOrderKey - черновик заказа собирается по поставщику и валюте закупки:
+// заказ оплачивается в одной валюте
+type draftOrderKey struct {
+	supplierID uint
+	currency   models.Currency
+}
+
+// createDraftOrders создает черновики заказов, по одному на каждого поставщика и валюту
+func (r *Replenishment) createDraftOrders() {
+	if len(r.rows) == 0 {
+		dialog.ShowInformation(lang.L("Пополнение"), lang.L("Нет товаров для заказа"), r.window)
+		return
+	}
+
+	var numbers []string
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		orders := map[draftOrderKey]*models.PurchaseOrder{}
+		var keys []draftOrderKey
+
+		for _, row := range r.rows {
+			key := draftOrderKey{currency: row.product.PurchaseCurrency}
+			if row.product.SupplierID != nil {
+				key.supplierID = *row.product.SupplierID
+			}
+			order, ok := orders[key]
+			if !ok {
+				order = &models.PurchaseOrder{
+					SupplierID: row.product.SupplierID,
+					Status:     models.OrderDraft,
+					Currency:   row.product.PurchaseCurrency,
+				}
+				if row.product.LeadTimeDays > 0 {
+					expected := time.Now().AddDate(0, 0, row.product.LeadTimeDays)
+					order.ExpectedAt = &expected
+				}
+				orders[key] = order
+				keys = append(keys, key)
+			}
+			order.Lines = append(order.Lines, models.PurchaseOrderLine{
+				ProductID: row.product.ID,
+				Quantity:  row.quantity,
+				UnitPrice: row.product.PurchasePrice,
+			})
+		}
+
+		for _, key := range keys {
+			order := orders[key]
+			order.Number = database.NextOrderNumber(tx)
+			if err := tx.Create(order).Error; err != nil {
+				return err
+			}
+			numbers = append(numbers, order.Number)
+		}
+		return nil
+	})
+	if err != nil {
+		showError(err, r.window)
+		return
+	}
+
+	dialog.ShowInformation(lang.L("Пополнение"),
+		fmt.Sprintf(lang.L("Создано черновиков заказов: %d\n%v"), len(numbers), numbers), r.window)
+	r.load()
+}
+
+// exportToCSV выгружает рекомендации как черновик заказа поставщику
+func (r *Replenishment) exportToCSV() {
+	dialog.ShowFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil || writer == nil {
+			return
+		}
+		defer writer.Close()
+
+		w := csv.NewWriter(writer)
+		w.Write(translateAll([]string{"Поставщик", "SKU", "Наименование", "Доступно", "В заказах", "Точка заказа", "К заказу", "Цена закупки", "Сумма", "Валюта"}))
+		for _, row := range r.rows {
+			w.Write([]string{
+				r.supplierName(row),
+				row.product.SKU,
+				row.product.Name,
+				strconv.Itoa(row.product.AvailableQuantity()),
+				strconv.Itoa(row.onOrder),
+				strconv.Itoa(row.reorderPoint),
+				strconv.Itoa(row.quantity),
+				row.product.PurchasePrice.String(),
+				row.product.PurchasePrice.Times(row.quantity).String(),
+				string(row.product.PurchaseCurrency),
+			})
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			showError(err, r.window)
+			return
+		}
+
+		dialog.ShowInformation(lang.L("Экспорт завершен"), lang.L("Черновик заказа выгружен в CSV"), r.window)
+	}, r.window)
+}
+
+// showOpenOrders показывает открытые заказы поставщикам
+func (r *Replenishment) showOpenOrders() {
+	var orders []models.PurchaseOrder
+	database.DB.Preload("Supplier").Preload("Lines").Preload("Lines.Product").
+		Where("status IN ?", models.OpenOrderStatuses).
+		Order("created_at").
+		Find(&orders)
+
+	content := container.NewVBox()
+	var d dialog.Dialog
+
+	for i := range orders {
+		order := orders[i]
+
+		supplier := lang.L("без поставщика")
+		if order.Supplier != nil {
+			supplier = order.Supplier.Name
+		}
+
+		lines := container.NewVBox()
+		for _, l := range order.Lines {
+			name := fmt.Sprintf(lang.L("товар #%d"), l.ProductID)
+			if l.Product != nil {
+				name = l.Product.SKU + " " + truncate(l.Product.Name, 30)
+			}
+			lines.Add(widget.NewLabel(fmt.Sprintf(lang.L("%s — %d шт. × %s"), name, l.Quantity, formatPrice(l.UnitPrice, order.Currency))))
+		}
+
+		actions := container.NewHBox()
+		if order.Status == models.OrderDraft {
+			actions.Add(widget.NewButton(lang.L("Разместить"), func() {
+				// Заказ могли изменить в другом окне: тогда Update вернет database.ErrVersionConflict
+				if err := database.DB.Model(&order).Update("status", models.OrderPlaced).Error; err != nil {
+					showError(err, r.window)
+					return
+				}
+				d.Hide()
+				r.showOpenOrders()
+			}))
+		}
+		actions.Add(widget.NewButton(lang.L("Оприходовать"), func() {
+			if err := database.ReceivePurchaseOrder(&order); err != nil {
+				showError(err, r.window)
+				return
+			}
+			d.Hide()
+			r.mainWindow.productList.RefreshList()
+			r.mainWindow.alerts.Refresh()
+			r.load()
+			r.showOpenOrders()
+		}))
+		actions.Add(widget.NewButton(lang.L("Отменить"), func() {
+			if err := database.DB.Model(&order).Update("status", models.OrderCancelled).Error; err != nil {
+				showError(err, r.window)
+				return
+			}
+			d.Hide()
+			r.load()
+			r.showOpenOrders()
+		}))
+
+		title := fmt.Sprintf("%s (%s)", order.Number, lang.L(orderStatusTitles[order.Status]))
+		subtitle := fmt.Sprintf(lang.L("%s | Сумма: %s"), supplier, formatPrice(order.Total(), order.Currency))
+		content.Add(widget.NewCard(title, subtitle, container.NewVBox(lines, actions)))
+	}
+
+	if len(orders) == 0 {
+		content.Add(widget.NewLabel(lang.L("Открытых заказов нет")))
+	}
+
+	scroll := container.NewScroll(content)
+	scroll.SetMinSize(fyne.NewSize(600, 450))
+
+	d = dialog.NewCustom(lang.L("Открытые заказы поставщикам"), lang.L("Закрыть"), scroll, r.window)
+	d.Show()
+}
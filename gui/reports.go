@@ -1,18 +1,22 @@
 package gui
 
 import (
+	"encoding/csv"
 	"fmt"
 	"image/color"
-	//"strconv"
+	"strconv"
 	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/lang"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 
+	"gorm.io/gorm"
+
 	"SanWarehouse/database"
 	"SanWarehouse/models"
 )
@@ -30,55 +34,100 @@ func NewReports(mw *MainWindow) *Reports {
 
 // ShowReportsMenu показывает меню отчетов
 func (r *Reports) ShowReportsMenu() {
-	r.window = r.mainWindow.app.NewWindow("Отчеты")
+	r.window = r.mainWindow.app.NewWindow(lang.L("Отчеты"))
 	r.window.Resize(fyne.NewSize(800, 600))
 
 	// Заголовок
-	title := canvas.NewText("Отчеты и аналитика", color.White)
+	title := canvas.NewText(lang.L("Отчеты и аналитика"), color.White)
 	title.TextSize = 24
 	title.Alignment = fyne.TextAlignCenter
 
 	headerBg := canvas.NewRectangle(&color.NRGBA{R: 70, G: 70, B: 70, A: 255})
 	header := container.NewStack(headerBg, container.NewPadded(title))
 
+	// Отчеты с закупочными ценами доступны только ролям, которым эти цены видны
+	canViewPurchase := database.Can(models.PermViewPurchasePrices)
+	financialBtn := widget.NewButtonWithIcon(lang.L("Финансовый анализ"), theme.ConfirmIcon(), r.showFinancialReport)
+	chartsBtn := widget.NewButtonWithIcon(lang.L("Показать графики"), theme.ViewFullScreenIcon(), r.showChartsReport)
+	abcBtn := widget.NewButtonWithIcon(lang.L("ABC/XYZ анализ"), theme.GridIcon(), r.showABCReport)
+	ratesBtn := widget.NewButtonWithIcon(lang.L("Курсы валют"), theme.ListIcon(), func() {
+		NewExchangeRateManager(r.mainWindow).Show()
+	})
+	setEnabled(ratesBtn, canViewPurchase)
+	setEnabled(financialBtn, canViewPurchase)
+	setEnabled(chartsBtn, canViewPurchase)
+	setEnabled(abcBtn, canViewPurchase)
+
 	// Кнопки отчетов
 	reportsList := container.NewVBox(
-		widget.NewCard("", "Общий отчет по складу",
+		widget.NewCard("", lang.L("Общий отчет по складу"),
+			container.NewVBox(
+				widget.NewLabel(lang.L("Полная статистика по всем товарам")),
+				widget.NewButtonWithIcon(lang.L("Сформировать отчет"), theme.DocumentIcon(), r.showGeneralReport),
+			),
+		),
+		widget.NewSeparator(),
+
+		widget.NewCard("", lang.L("Товары с истекающим сроком"),
+			container.NewVBox(
+				widget.NewLabel(lang.L("Товары, которые давно не продаются")),
+				widget.NewButtonWithIcon(lang.L("Анализ оборачиваемости"), theme.HistoryIcon(), r.showTurnoverReport),
+			),
+		),
+		widget.NewSeparator(),
+
+		widget.NewCard("", lang.L("Прогноз спроса"),
+			container.NewVBox(
+				widget.NewLabel(lang.L("Прогноз продаж, запас в днях и дата исчерпания по истории отгрузок")),
+				widget.NewButtonWithIcon(lang.L("Прогноз спроса"), theme.SearchIcon(), r.showForecastReport),
+			),
+		),
+		widget.NewSeparator(),
+
+		widget.NewCard("", lang.L("Финансовый отчет"),
+			container.NewVBox(
+				widget.NewLabel(lang.L("Прибыль, себестоимость, маржинальность")),
+				financialBtn,
+			),
+		),
+		widget.NewSeparator(),
+
+		widget.NewCard("", lang.L("Курсы валют"),
 			container.NewVBox(
-				widget.NewLabel("Полная статистика по всем товарам"),
-				widget.NewButtonWithIcon("Сформировать отчет", theme.DocumentIcon(), r.showGeneralReport),
+				widget.NewLabel(lang.L("Курсы для пересчета закупочных цен в рубли: ввод вручную и загрузка из CSV")),
+				ratesBtn,
 			),
 		),
 		widget.NewSeparator(),
 
-		widget.NewCard("", "Товары с истекающим сроком",
+		widget.NewCard("", lang.L("Отчет по категориям"),
 			container.NewVBox(
-				widget.NewLabel("Товары, которые давно не продаются"),
-				widget.NewButtonWithIcon("Анализ оборачиваемости", theme.HistoryIcon(), r.showTurnoverReport),
+				widget.NewLabel(lang.L("Статистика по категориям товаров")),
+				widget.NewButtonWithIcon(lang.L("Анализ категорий"), theme.ViewRestoreIcon(), r.showCategoryReport),
 			),
 		),
 		widget.NewSeparator(),
 
-		widget.NewCard("", "Финансовый отчет",
+		widget.NewCard("", lang.L("Графики"),
 			container.NewVBox(
-				widget.NewLabel("Прибыль, себестоимость, маржинальность"),
-				widget.NewButtonWithIcon("Финансовый анализ", theme.ConfirmIcon(), r.showFinancialReport),
+				widget.NewLabel(lang.L("Стоимость по категориям, маржа по брендам, остатки во времени")),
+				chartsBtn,
 			),
 		),
 		widget.NewSeparator(),
 
-		widget.NewCard("", "Отчет по категориям",
+		widget.NewCard("", lang.L("ABC/XYZ анализ"),
 			container.NewVBox(
-				widget.NewLabel("Статистика по категориям товаров"),
-				widget.NewButtonWithIcon("Анализ категорий", theme.ViewRestoreIcon(), r.showCategoryReport),
+				widget.NewLabel(lang.L("Классификация по стоимости запаса или выручке и стабильности спроса")),
+				abcBtn,
 			),
 		),
 		widget.NewSeparator(),
 
-		widget.NewCard("", "Экспорт данных",
+		widget.NewCard("", lang.L("Экспорт данных"),
 			container.NewVBox(
-				widget.NewLabel("Выгрузить данные в CSV"),
-				widget.NewButtonWithIcon("Экспорт в CSV", theme.DownloadIcon(), r.exportToCSV),
+				widget.NewLabel(lang.L("Выгрузить данные в CSV")),
+				widget.NewButtonWithIcon(lang.L("Экспорт в CSV"), theme.DownloadIcon(), r.exportToCSV),
 			),
 		),
 	)
@@ -95,12 +144,9 @@ func (r *Reports) ShowReportsMenu() {
 
 // showGeneralReport - общий отчет по складу
 func (r *Reports) showGeneralReport() {
-	var products []models.Product
-	database.DB.Find(&products)
-
 	var totalProducts int64
-	var totalValue float64
-	var totalPurchaseValue float64
+	var totalValue models.Money
+	var totalPurchaseValue models.Money
 	var totalItems int
 	var outOfStock int64
 	var lowStock int64
@@ -110,25 +156,41 @@ func (r *Reports) showGeneralReport() {
 	database.DB.Model(&models.Product{}).Count(&totalProducts)
 	database.DB.Model(&models.Product{}).Where("is_active = ?", true).Count(&activeProducts)
 	database.DB.Model(&models.Product{}).Select("sum(quantity * selling_price)").Scan(&totalValue)
-	database.DB.Model(&models.Product{}).Select("sum(quantity * purchase_price)").Scan(&totalPurchaseValue)
+	// Себестоимость - в валюте учета по текущим курсам
+	rates := database.CurrentExchangeRates()
+	database.DB.Model(&models.Product{}).Select("sum(quantity * " + database.PurchaseCostSQL(rates) + ")").Scan(&totalPurchaseValue)
 	database.DB.Model(&models.Product{}).Select("sum(quantity)").Scan(&totalItems)
 	database.DB.Model(&models.Product{}).Where("quantity - reserved_quantity <= 0").Count(&outOfStock)
 	database.DB.Model(&models.Product{}).Where("quantity - reserved_quantity < min_stock_level AND quantity - reserved_quantity > 0").Count(&lowStock)
 
+	margin := 0.0
+	if totalValue > 0 {
+		margin = float64(totalValue-totalPurchaseValue) / float64(totalValue) * 100
+	}
+
 	// Создаем таблицу с товарами
 	data := [][]string{
-		{"Показатель", "Значение"},
-		{"Всего наименований", fmt.Sprintf("%d", totalProducts)},
-		{"Активных товаров", fmt.Sprintf("%d", activeProducts)},
-		{"Всего единиц товара", fmt.Sprintf("%d", totalItems)},
-		{"Общая стоимость продажи", fmt.Sprintf("%.2f руб.", totalValue)},
-		{"Общая себестоимость", fmt.Sprintf("%.2f руб.", totalPurchaseValue)},
-		{"Потенциальная прибыль", fmt.Sprintf("%.2f руб.", totalValue-totalPurchaseValue)},
-		{"Маржинальность", fmt.Sprintf("%.1f%%", (totalValue-totalPurchaseValue)/totalValue*100)},
-		{"Товаров в наличии", fmt.Sprintf("%d", totalProducts-outOfStock)},
-		{"Нет в наличии", fmt.Sprintf("%d", outOfStock)},
-		{"Низкий запас", fmt.Sprintf("%d", lowStock)},
+		{lang.L("Показатель"), lang.L("Значение")},
+		{lang.L("Всего наименований"), formatInt(totalProducts)},
+		{lang.L("Активных товаров"), formatInt(activeProducts)},
+		{lang.L("Всего единиц товара"), formatInt(int64(totalItems))},
+		{lang.L("Общая стоимость продажи"), formatMoney(totalValue)},
 	}
+	if database.Can(models.PermViewPurchasePrices) {
+		data = append(data,
+			[]string{lang.L("Общая себестоимость"), formatMoney(totalPurchaseValue)},
+			[]string{lang.L("Потенциальная прибыль"), formatMoney(totalValue - totalPurchaseValue)},
+			[]string{lang.L("Маржинальность"), formatNumber(margin, 1) + "%"},
+		)
+		if missing := missingRates(rates); len(missing) > 0 {
+			data = append(data, []string{lang.L("Нет курса валют"), currencyList(missing)})
+		}
+	}
+	data = append(data,
+		[]string{lang.L("Товаров в наличии"), formatInt(totalProducts - outOfStock)},
+		[]string{lang.L("Нет в наличии"), formatInt(outOfStock)},
+		[]string{lang.L("Низкий запас"), formatInt(lowStock)},
+	)
 
 	list := widget.NewTable(
 		func() (int, int) {
@@ -158,49 +220,69 @@ func (r *Reports) showGeneralReport() {
 	list.SetColumnWidth(0, 250)
 	list.SetColumnWidth(1, 200)
 
-	dialog.ShowCustom("Общий отчет по складу", "Закрыть", list, r.mainWindow.window)
+	dialog.ShowCustom(lang.L("Общий отчет по складу"), lang.L("Закрыть"), list, r.mainWindow.window)
 }
 
 // showFinancialReport - финансовый отчет
 func (r *Reports) showFinancialReport() {
+	content := container.NewVBox()
+	levelSelect := newCategoryLevelSelect(func(level int) {
+		r.fillFinancialReport(content, level)
+	})
+	r.fillFinancialReport(content, 0)
+
+	scroll := container.NewScroll(content)
+	scroll.SetMinSize(fyne.NewSize(600, 500))
+
+	top := container.NewHBox(widget.NewLabel(lang.L("Категории:")), levelSelect)
+	dialog.ShowCustom(lang.L("Финансовый отчет"), lang.L("Закрыть"), container.NewBorder(top, nil, nil, nil, scroll), r.mainWindow.window)
+}
+
+// fillFinancialReport заполняет финансовый отчет по категориям, свернутым до уровня level
+func (r *Reports) fillFinancialReport(content *fyne.Container, level int) {
 	type CategoryFinance struct {
-		Category    string
 		Items       int
-		PurchaseSum float64
-		SellingSum  float64
-		Profit      float64
+		PurchaseSum models.Money
+		SellingSum  models.Money
 	}
 
-	var results []CategoryFinance
-
-	database.DB.Model(&models.Product{}).
-		Select("category, sum(quantity) as items, sum(quantity * purchase_price) as purchase_sum, sum(quantity * selling_price) as selling_sum").
-		Group("category").
-		Scan(&results)
+	content.RemoveAll()
 
 	// Заголовок
-	content := container.NewVBox(
-		widget.NewLabelWithStyle("Финансовый анализ по категориям", fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
-		widget.NewSeparator(),
-	)
+	content.Add(widget.NewLabelWithStyle(lang.L("Финансовый анализ по категориям"), fyne.TextAlignCenter, fyne.TextStyle{Bold: true}))
+
+	// Закупочные цены пересчитываются в валюту учета по текущим курсам
+	rates := database.CurrentExchangeRates()
+	if missing := missingRates(rates); len(missing) > 0 {
+		warning := widget.NewLabel(fmt.Sprintf(lang.L("Нет курса %s: закупка товаров в этих валютах не учтена. "+
+			"Курсы задаются в окне «Курсы валют»."), currencyList(missing)))
+		warning.Importance = widget.WarningImportance
+		warning.Wrapping = fyne.TextWrapWord
+		content.Add(warning)
+	}
+	content.Add(widget.NewSeparator())
 
-	totalPurchase := 0.0
-	totalSelling := 0.0
-	totalProfit := 0.0
+	var totalPurchase, totalSelling, totalProfit models.Money
+	purchaseSum := "coalesce(sum(quantity * " + database.PurchaseCostSQL(rates) + "), 0) as purchase_sum"
+
+	for _, g := range database.CategoryGroups(level) {
+		var r CategoryFinance
+		g.Apply(database.DB.Model(&models.Product{})).
+			Select("coalesce(sum(quantity), 0) as items, " + purchaseSum + ", coalesce(sum(quantity * selling_price), 0) as selling_sum").
+			Scan(&r)
 
-	for _, r := range results {
 		profit := r.SellingSum - r.PurchaseSum
 		margin := 0.0
 		if r.SellingSum > 0 {
-			margin = profit / r.SellingSum * 100
+			margin = float64(profit) / float64(r.SellingSum) * 100
 		}
 
-		card := widget.NewCard(r.Category, fmt.Sprintf("Единиц: %d", r.Items),
+		card := widget.NewCard(categoryTitle(g), fmt.Sprintf(lang.L("Единиц: %s"), formatInt(int64(r.Items))),
 			container.NewVBox(
-				widget.NewLabel(fmt.Sprintf("Закупка: %.2f руб.", r.PurchaseSum)),
-				widget.NewLabel(fmt.Sprintf("Продажа: %.2f руб.", r.SellingSum)),
-				widget.NewLabelWithStyle(fmt.Sprintf("Прибыль: %.2f руб.", profit), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
-				widget.NewLabel(fmt.Sprintf("Маржа: %.1f%%", margin)),
+				widget.NewLabel(fmt.Sprintf(lang.L("Закупка: %s"), formatMoney(r.PurchaseSum))),
+				widget.NewLabel(fmt.Sprintf(lang.L("Продажа: %s"), formatMoney(r.SellingSum))),
+				widget.NewLabelWithStyle(fmt.Sprintf(lang.L("Прибыль: %s"), formatMoney(profit)), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+				widget.NewLabel(fmt.Sprintf(lang.L("Маржа: %s%%"), formatNumber(margin, 1))),
 			),
 		)
 
@@ -215,126 +297,127 @@ func (r *Reports) showFinancialReport() {
 	// Итоги
 	totalMargin := 0.0
 	if totalSelling > 0 {
-		totalMargin = totalProfit / totalSelling * 100
+		totalMargin = float64(totalProfit) / float64(totalSelling) * 100
 	}
 
-	summary := widget.NewCard("ИТОГО", "Общие показатели",
+	summary := widget.NewCard(lang.L("ИТОГО"), lang.L("Общие показатели"),
 		container.NewVBox(
-			widget.NewLabel(fmt.Sprintf("Общая себестоимость: %.2f руб.", totalPurchase)),
-			widget.NewLabel(fmt.Sprintf("Общая выручка: %.2f руб.", totalSelling)),
-			widget.NewLabelWithStyle(fmt.Sprintf("Общая прибыль: %.2f руб.", totalProfit), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
-			widget.NewLabel(fmt.Sprintf("Общая маржинальность: %.1f%%", totalMargin)),
+			widget.NewLabel(fmt.Sprintf(lang.L("Общая себестоимость: %s"), formatMoney(totalPurchase))),
+			widget.NewLabel(fmt.Sprintf(lang.L("Общая выручка: %s"), formatMoney(totalSelling))),
+			widget.NewLabelWithStyle(fmt.Sprintf(lang.L("Общая прибыль: %s"), formatMoney(totalProfit)), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabel(fmt.Sprintf(lang.L("Общая маржинальность: %s%%"), formatNumber(totalMargin, 1))),
 		),
 	)
 
 	content.Add(summary)
+	content.Refresh()
+}
+
+// showCategoryReport - отчет по категориям
+func (r *Reports) showCategoryReport() {
+	// Гистограмма стоимости запаса по категориям
+	chart := NewChart(BarChart, lang.L("Стоимость запаса по категориям"), stockValueByCategory(0))
+	chart.Format = func(v float64) string {
+		return formatNumber(v, 0) + " " + lang.L("руб.")
+	}
+	export := widget.NewButtonWithIcon(lang.L("Сохранить PNG"), theme.DocumentSaveIcon(), func() {
+		ExportChartPNG(chart, r.mainWindow.window)
+	})
+
+	stats := container.NewVBox()
+	levelSelect := newCategoryLevelSelect(func(level int) {
+		chart.SetPoints(stockValueByCategory(level))
+		fillCategoryStats(stats, level)
+	})
+	fillCategoryStats(stats, 0)
+
+	content := container.NewVBox(chart, container.NewHBox(export, widget.NewLabel(lang.L("Категории:")), levelSelect), widget.NewSeparator(), stats)
+	// Стоимость запаса считается по закупочным ценам
+	if !database.Can(models.PermViewPurchasePrices) {
+		chart.Hide()
+		export.Hide()
+	}
 
 	scroll := container.NewScroll(content)
-	scroll.SetMinSize(fyne.NewSize(600, 500))
+	scroll.SetMinSize(fyne.NewSize(700, 500))
 
-	dialog.ShowCustom("Финансовый отчет", "Закрыть", scroll, r.mainWindow.window)
+	dialog.ShowCustom(lang.L("Отчет по категориям"), lang.L("Закрыть"), scroll, r.mainWindow.window)
 }
 
-// showCategoryReport - отчет по категориям
-func (r *Reports) showCategoryReport() {
+// fillCategoryStats заполняет статистику по категориям, свернутым до уровня level
+func fillCategoryStats(content *fyne.Container, level int) {
 	type CategoryStat struct {
-		Category    string
 		Count       int
 		TotalItems  int
-		AvgPrice    float64
+		AvgPrice    models.Money
 		BrandsCount int
 	}
 
-	var stats []CategoryStat
+	content.RemoveAll()
+	for _, g := range database.CategoryGroups(level) {
+		// Средняя цена и число брендов не складываются, поэтому считаются по группе целиком
+		var s CategoryStat
+		g.Apply(database.DB.Model(&models.Product{})).
+			Select("count(*) as count, coalesce(sum(quantity), 0) as total_items, coalesce(avg(selling_price), 0) as avg_price, count(distinct brand) as brands_count").
+			Scan(&s)
 
-	// Получаем статистику по категориям
-	database.DB.Model(&models.Product{}).
-		Select("category, count(*) as count, sum(quantity) as total_items, avg(selling_price) as avg_price").
-		Group("category").
-		Scan(&stats)
+		statText := fmt.Sprintf(lang.L("%s:\n  Товаров: %d | Единиц: %s | Брендов: %d | Средняя цена: %s"),
+			categoryTitle(g), s.Count, formatInt(int64(s.TotalItems)), s.BrandsCount, formatMoney(s.AvgPrice))
 
-	// Создаем гистограмму (упрощенную)
-	content := container.NewVBox()
-
-	for _, s := range stats {
-		// Считаем количество брендов в категории
-		var brands []string
-		database.DB.Model(&models.Product{}).Where("category = ?", s.Category).Distinct("brand").Pluck("brand", &brands)
-
-		// Создаем визуализацию
-		bg := canvas.NewRectangle(&color.NRGBA{R: 100, G: 150, B: 255, A: 100})
-
-		statText := fmt.Sprintf("%s:\n  Товаров: %d | Единиц: %d | Брендов: %d | Средняя цена: %.2f руб.",
-			s.Category, s.Count, s.TotalItems, len(brands), s.AvgPrice)
-
-		label := widget.NewLabel(statText)
-
-		item := container.NewStack(bg, container.NewPadded(label))
-		content.Add(item)
+		content.Add(widget.NewLabel(statText))
 		content.Add(widget.NewSeparator())
 	}
-
-	scroll := container.NewScroll(content)
-	scroll.SetMinSize(fyne.NewSize(600, 500))
-
-	dialog.ShowCustom("Отчет по категориям", "Закрыть", scroll, r.mainWindow.window)
+	content.Refresh()
 }
 
 // showTurnoverReport - отчет по оборачиваемости
 func (r *Reports) showTurnoverReport() {
+	// Товары, которые давно не обновлялись: 1 месяц назад
+	threshold := time.Now().AddDate(0, -1, 0)
+	query := database.DB.Model(&models.Product{}).Where("quantity > 0 AND updated_at < ?", threshold)
+
+	var total int64
+	query.Count(&total)
+
+	// Показываем самые давние, остальные только учитываем в итоге
+	const limit = 200
 	var products []models.Product
-	database.DB.Where("quantity > 0").Find(&products)
+	query.Order("updated_at").Limit(limit).Find(&products)
 
 	content := container.NewVBox(
-		widget.NewLabelWithStyle("Анализ оборачиваемости товаров", fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle(lang.L("Анализ оборачиваемости товаров"), fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
 		widget.NewSeparator(),
 	)
-
-	// Анализируем товары, которые давно не обновлялись
-	threshold := time.Now().AddDate(0, -1, 0) // 1 месяц назад
+	if total > limit {
+		content.Add(widget.NewLabel(fmt.Sprintf(lang.L("Показаны %d самых давних из %d товаров"), limit, total)))
+	}
 
 	for _, p := range products {
-		if p.UpdatedAt.Before(threshold) {
-			days := int(time.Since(p.UpdatedAt).Hours() / 24)
+		days := int(time.Since(p.UpdatedAt).Hours() / 24)
 
-			warning := canvas.NewRectangle(&color.NRGBA{R: 255, G: 200, B: 0, A: 100})
+		warning := canvas.NewRectangle(&color.NRGBA{R: 255, G: 200, B: 0, A: 100})
 
-			text := fmt.Sprintf("%s (%s)\n  Не обновлялся %d дней\n  В наличии: %d шт.\n  SKU: %s",
-				p.Name, p.Category, days, p.Quantity, p.SKU)
+		text := fmt.Sprintf(lang.L("%s (%s)\n  Не обновлялся %d дней\n  В наличии: %d шт.\n  SKU: %s"),
+			p.Name, p.Category, days, p.Quantity, p.SKU)
 
-			label := widget.NewLabel(text)
+		label := widget.NewLabel(text)
 
-			content.Add(container.NewStack(warning, container.NewPadded(label)))
-			content.Add(widget.NewSeparator())
-		}
+		content.Add(container.NewStack(warning, container.NewPadded(label)))
+		content.Add(widget.NewSeparator())
 	}
 
 	if len(content.Objects) <= 2 {
-		content.Add(widget.NewLabel("Товаров, требующих внимания, не найдено"))
+		content.Add(widget.NewLabel(lang.L("Товаров, требующих внимания, не найдено")))
 	}
 
 	scroll := container.NewScroll(content)
 	scroll.SetMinSize(fyne.NewSize(600, 500))
 
-	dialog.ShowCustom("Оборачиваемость товаров", "Закрыть", scroll, r.mainWindow.window)
+	dialog.ShowCustom(lang.L("Оборачиваемость товаров"), lang.L("Закрыть"), scroll, r.mainWindow.window)
 }
 
 // exportToCSV - экспорт данных в CSV
 func (r *Reports) exportToCSV() {
-	var products []models.Product
-	database.DB.Find(&products)
-
-	// Создаем CSV контент
-	csv := "ID,SKU,Название,Категория,Бренд,Количество,Доступно,Цена закупки,Цена продажи,Статус,Расположение\n"
-
-	for _, p := range products {
-		csv += fmt.Sprintf("%d,%s,%s,%s,%s,%d,%d,%.2f,%.2f,%s,%s\n",
-			p.ID, p.SKU, p.Name, p.Category, p.Brand,
-			p.Quantity, p.AvailableQuantity(),
-			p.PurchasePrice, p.SellingPrice,
-			p.Status, p.Location)
-	}
-
 	// Показываем диалог сохранения
 	dialog.ShowFileSave(func(writer fyne.URIWriteCloser, err error) {
 		if err != nil || writer == nil {
@@ -342,10 +425,48 @@ func (r *Reports) exportToCSV() {
 		}
 		defer writer.Close()
 
-		writer.Write([]byte(csv))
+		// Товары читаются и записываются пачками, чтобы не держать весь каталог в памяти
+		// Без доступа к закупочным ценам колонки цены и валюты закупки не выгружаются
+		withPurchase := database.Can(models.PermViewPurchasePrices)
+
+		w := csv.NewWriter(writer)
+		header := append([]string{"ID"}, translateAll([]string{"SKU", "Название", "Категория", "Бренд", "Количество", "Доступно", "Цена закупки", "Валюта закупки", "Цена продажи", "Статус", "Расположение"})...)
+		if !withPurchase {
+			header = append(header[:7], header[9:]...)
+		}
+		w.Write(header)
+
+		var batch []models.Product
+		result := database.DB.Order("id").FindInBatches(&batch, 1000, func(tx *gorm.DB, _ int) error {
+			for _, p := range batch {
+				record := []string{
+					strconv.FormatUint(uint64(p.ID), 10), p.SKU, p.Name, p.Category, p.Brand,
+					strconv.Itoa(p.Quantity), strconv.Itoa(p.AvailableQuantity()),
+				}
+				if withPurchase {
+					record = append(record, p.PurchasePrice.String(), string(p.PurchaseCurrency))
+				}
+				record = append(record, p.SellingPrice.String(), string(p.Status), p.Location)
+				w.Write(record)
+			}
+			return w.Error()
+		})
+		w.Flush()
+		if err = result.Error; err == nil {
+			err = w.Error()
+		}
+		if err != nil {
+			showError(err, r.mainWindow.window)
+			return
+		}
 
-		dialog.ShowInformation("Экспорт завершен",
-			"Данные успешно экспортированы в CSV",
+		dialog.ShowInformation(lang.L("Экспорт завершен"),
+			fmt.Sprintf(lang.L("Экспортировано товаров: %d"), result.RowsAffected),
 			r.mainWindow.window)
 	}, r.mainWindow.window)
 }
+
+// missingRates возвращает валюты закупочных цен товаров в наличии, для которых нет курса
+func missingRates(rates models.ExchangeRates) []models.Currency {
+	return database.CurrenciesWithoutRate(database.DB.Model(&models.Product{}).Where("quantity > 0"), rates)
+}
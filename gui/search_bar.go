@@ -0,0 +1,90 @@
+package gui
+
+import (
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Задержка перед поиском после последнего нажатия клавиши
+const searchDebounce = 300 * time.Millisecond
+
+// SearchBar - строка поиска, фильтрующая список по мере ввода
+type SearchBar struct {
+	entry    *widget.Entry
+	onSearch func(query string)
+
+	mu    sync.Mutex
+	timer *time.Timer
+}
+
+func NewSearchBar(onSearch func(query string)) *SearchBar {
+	sb := &SearchBar{onSearch: onSearch}
+
+	sb.entry = widget.NewEntry()
+	sb.entry.SetPlaceHolder(lang.L(`Поиск: слова, brand:Grohe, qty<5, price>=1000, status:low, loc:A1, "точная фраза"`))
+	sb.entry.ActionItem = widget.NewButtonWithIcon("", theme.ContentClearIcon(), sb.Clear)
+	sb.entry.OnChanged = func(string) { sb.schedule() }
+	// Enter выполняет поиск сразу, не дожидаясь задержки
+	sb.entry.OnSubmitted = func(text string) {
+		sb.stop()
+		sb.onSearch(text)
+	}
+	return sb
+}
+
+// Widget возвращает поле ввода для размещения в окне
+func (sb *SearchBar) Widget() fyne.CanvasObject {
+	return sb.entry
+}
+
+// Focus переводит фокус ввода в строку поиска
+func (sb *SearchBar) Focus() {
+	if c := fyne.CurrentApp().Driver().CanvasForObject(sb.entry); c != nil {
+		c.Focus(sb.entry)
+	}
+}
+
+// Clear очищает строку поиска и показывает полный список
+func (sb *SearchBar) Clear() {
+	sb.Reset()
+	sb.onSearch("")
+}
+
+// Reset очищает строку поиска без запуска поиска
+func (sb *SearchBar) Reset() {
+	onChanged := sb.entry.OnChanged
+	sb.entry.OnChanged = nil
+	sb.entry.SetText("")
+	sb.entry.OnChanged = onChanged
+	sb.stop()
+}
+
+// schedule откладывает поиск до паузы во вводе
+func (sb *SearchBar) schedule() {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+
+	if sb.timer != nil {
+		sb.timer.Stop()
+	}
+	sb.timer = time.AfterFunc(searchDebounce, func() {
+		fyne.Do(func() {
+			sb.onSearch(sb.entry.Text)
+		})
+	})
+}
+
+func (sb *SearchBar) stop() {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+
+	if sb.timer != nil {
+		sb.timer.Stop()
+		sb.timer = nil
+	}
+}
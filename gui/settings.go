@@ -0,0 +1,46 @@
+package gui
+
+import (
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// showSettings показывает настройки пользователя: язык интерфейса и смену пароля.
+// Язык сохраняется в файле настроек и меняется после перезапуска программы.
+func (mw *MainWindow) showSettings() {
+	titles := make([]string, len(languageOptions))
+	for i, o := range languageOptions {
+		titles[i] = lang.L(o.title)
+	}
+	language := widget.NewSelect(titles, nil)
+	for i, o := range languageOptions {
+		if o.code == mw.config.Language {
+			language.SetSelectedIndex(i)
+		}
+	}
+
+	password := widget.NewButtonWithIcon(lang.L("Сменить пароль..."), theme.AccountIcon(), func() {
+		showChangePasswordDialog(mw.window)
+	})
+
+	items := []*widget.FormItem{
+		widget.NewFormItem(lang.L("Язык интерфейса"), language),
+		widget.NewFormItem(lang.L("Пароль"), password),
+	}
+	dialog.ShowForm(lang.L("Настройки"), lang.L("Сохранить"), lang.L("Отмена"), items, func(ok bool) {
+		i := language.SelectedIndex()
+		if !ok || i < 0 || languageOptions[i].code == mw.config.Language {
+			return
+		}
+		previous := mw.config.Language
+		mw.config.Language = languageOptions[i].code
+		if err := mw.config.Save(); err != nil {
+			mw.config.Language = previous
+			showError(err, mw.window)
+			return
+		}
+		dialog.ShowInformation(lang.L("Настройки"), lang.L("Язык интерфейса изменится после перезапуска программы"), mw.window)
+	}, mw.window)
+}
@@ -0,0 +1,169 @@
+package gui
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/widget"
+
+	"SanWarehouse/database"
+	"SanWarehouse/models"
+)
+
+// undoLimit - сколько последних действий можно отменить
+const undoLimit = 100
+
+// command - обратимое действие пользователя над товарами
+type command struct {
+	// title описывает действие в строке состояния: "Изменение товара MIX-001"
+	title string
+	do    func() error
+	undo  func() error
+}
+
+// undoStack - выполненные и отмененные действия
+type undoStack struct {
+	done   []*command
+	undone []*command
+}
+
+// push запоминает выполненное действие; отмененные действия после этого повторить нельзя
+func (s *undoStack) push(c *command) {
+	s.done = append(s.done, c)
+	if len(s.done) > undoLimit {
+		s.done = s.done[len(s.done)-undoLimit:]
+	}
+	s.undone = nil
+}
+
+func (s *undoStack) clear() {
+	s.done, s.undone = nil, nil
+}
+
+func last(commands []*command) *command {
+	if len(commands) == 0 {
+		return nil
+	}
+	return commands[len(commands)-1]
+}
+
+// createProductCommand - добавление товара; отмена помечает товар удаленным
+func createProductCommand(p *models.Product) *command {
+	id := []uint{p.ID}
+	return &command{
+		title: fmt.Sprintf(lang.L("Добавление товара %s"), p.SKU),
+		do:    func() error { return database.RestoreProducts(id) },
+		undo:  func() error { return database.DeleteProducts(id) },
+	}
+}
+
+// editProductCommand - изменение товара в форме
+func editProductCommand(before, after *models.Product) *command {
+	revision := database.ProductRevision{Before: *before, After: *after}
+	return &command{
+		title: fmt.Sprintf(lang.L("Изменение товара %s"), after.SKU),
+		do:    func() error { return database.ApplyProductRevisions([]database.ProductRevision{revision}) },
+		undo: func() error {
+			return database.ApplyProductRevisions([]database.ProductRevision{revision.Reversed()})
+		},
+	}
+}
+
+// deleteProductsCommand - удаление товаров; отмена восстанавливает их
+func deleteProductsCommand(ids []uint, title string) *command {
+	return &command{
+		title: title,
+		do:    func() error { return database.DeleteProducts(ids) },
+		undo:  func() error { return database.RestoreProducts(ids) },
+	}
+}
+
+// bulkEditCommand - массовое изменение товаров
+func bulkEditCommand(revisions []database.ProductRevision) *command {
+	reversed := make([]database.ProductRevision, len(revisions))
+	for i, r := range revisions {
+		reversed[len(revisions)-1-i] = r.Reversed()
+	}
+	return &command{
+		title: fmt.Sprintf(lang.L("Массовое изменение товаров (%d)"), len(revisions)),
+		do:    func() error { return database.ApplyProductRevisions(revisions) },
+		undo:  func() error { return database.ApplyProductRevisions(reversed) },
+	}
+}
+
+// execute выполняет действие и запоминает его для отмены
+func (mw *MainWindow) execute(c *command) error {
+	if err := c.do(); err != nil {
+		return err
+	}
+	mw.record(c)
+	return nil
+}
+
+// record запоминает уже выполненное действие для отмены
+func (mw *MainWindow) record(c *command) {
+	mw.history.push(c)
+	mw.updateUndoActions()
+}
+
+// undo отменяет последнее действие (Ctrl+Z)
+func (mw *MainWindow) undo() {
+	c := last(mw.history.done)
+	if c == nil {
+		mw.statusBar.SetText(lang.L("Нет действий для отмены"))
+		return
+	}
+	mw.history.done = mw.history.done[:len(mw.history.done)-1]
+
+	if err := c.undo(); err != nil {
+		// Действие, которое не удалось отменить, убирается из истории, чтобы не блокировать предыдущие
+		mw.updateUndoActions()
+		showError(models.Errorf("не удалось отменить «%s»: %w", c.title, err), mw.window)
+		return
+	}
+	mw.history.undone = append(mw.history.undone, c)
+	mw.afterHistoryChange(fmt.Sprintf(lang.L("Отменено: %s"), c.title))
+}
+
+// redo повторяет последнее отмененное действие (Ctrl+Shift+Z)
+func (mw *MainWindow) redo() {
+	c := last(mw.history.undone)
+	if c == nil {
+		mw.statusBar.SetText(lang.L("Нет действий для повтора"))
+		return
+	}
+	mw.history.undone = mw.history.undone[:len(mw.history.undone)-1]
+
+	if err := c.do(); err != nil {
+		mw.updateUndoActions()
+		showError(models.Errorf("не удалось повторить «%s»: %w", c.title, err), mw.window)
+		return
+	}
+	mw.history.done = append(mw.history.done, c)
+	mw.afterHistoryChange(fmt.Sprintf(lang.L("Повторено: %s"), c.title))
+}
+
+// afterHistoryChange обновляет окно после отмены или повтора
+func (mw *MainWindow) afterHistoryChange(status string) {
+	mw.productList.RefreshList()
+	mw.productList.RefreshThumbnails()
+	mw.alerts.Refresh()
+	mw.updateUndoActions()
+	mw.statusBar.SetText(status)
+}
+
+// updateUndoActions включает кнопки отмены и повтора, если есть что отменять и повторять
+func (mw *MainWindow) updateUndoActions() {
+	toggle := func(action *widget.ToolbarAction, enabled bool) {
+		if action == nil {
+			return
+		}
+		if enabled {
+			action.Enable()
+		} else {
+			action.Disable()
+		}
+	}
+	toggle(mw.undoAction, len(mw.history.done) > 0)
+	toggle(mw.redoAction, len(mw.history.undone) > 0)
+}
@@ -0,0 +1,137 @@
+package gui
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"SanWarehouse/database"
+	"SanWarehouse/models"
+)
+
+// UserManager - окно управления учетными записями (только для администратора)
+type UserManager struct {
+	mainWindow *MainWindow
+	window     fyne.Window
+
+	users    []models.User
+	rows     [][]string
+	table    *widget.Table
+	selected int
+}
+
+func NewUserManager(mw *MainWindow) *UserManager {
+	return &UserManager{mainWindow: mw, selected: -1}
+}
+
+// Show открывает окно пользователей
+func (m *UserManager) Show() {
+	m.window = m.mainWindow.app.NewWindow(lang.L("Пользователи"))
+	m.window.Resize(fyne.NewSize(750, 450))
+
+	headers := translateAll([]string{"Пользователь", "Имя", "Роль", "Состояние", "Последний вход"})
+	m.table = newTextTable(headers, []float32{130, 200, 130, 110, 140}, &m.rows)
+	m.table.OnSelected = func(id widget.TableCellID) {
+		m.selected = id.Row - 1
+	}
+	m.reload()
+
+	addBtn := widget.NewButtonWithIcon(lang.L("Добавить"), theme.ContentAddIcon(), func() {
+		m.showUserDialog(&models.User{Role: models.RoleStorekeeper})
+	})
+	editBtn := widget.NewButtonWithIcon(lang.L("Изменить"), theme.DocumentCreateIcon(), func() {
+		if m.selected < 0 || m.selected >= len(m.users) {
+			dialog.ShowInformation(lang.L("Пользователи"), lang.L("Выберите пользователя"), m.window)
+			return
+		}
+		user := m.users[m.selected]
+		m.showUserDialog(&user)
+	})
+
+	m.window.SetContent(container.NewBorder(nil, container.NewHBox(addBtn, editBtn), nil, nil, m.table))
+	m.window.Show()
+}
+
+func (m *UserManager) reload() {
+	m.users = database.Users()
+	m.rows = m.rows[:0]
+	for _, u := range m.users {
+		state := lang.L("Активен")
+		if u.Disabled {
+			state = lang.L("Заблокирован")
+		}
+		lastLogin := "—"
+		if u.LastLoginAt != nil {
+			lastLogin = formatDateTime(*u.LastLoginAt)
+		}
+		m.rows = append(m.rows, []string{u.Username, u.FullName, lang.L(u.Role.Title()), state, lastLogin})
+	}
+	m.table.UnselectAll()
+	m.selected = -1
+	m.table.Refresh()
+}
+
+// showUserDialog создает (ID == 0) или изменяет учетную запись
+func (m *UserManager) showUserDialog(user *models.User) {
+	isNew := user.ID == 0
+
+	username := widget.NewEntry()
+	username.SetText(user.Username)
+	fullName := widget.NewEntry()
+	fullName.SetText(user.FullName)
+
+	roleTitles := make([]string, len(models.Roles))
+	for i, r := range models.Roles {
+		roleTitles[i] = lang.L(r.Title())
+	}
+	role := widget.NewSelect(roleTitles, nil)
+	role.SetSelected(lang.L(user.Role.Title()))
+
+	disabled := widget.NewCheck(lang.L("Заблокирован"), nil)
+	disabled.SetChecked(user.Disabled)
+
+	password := widget.NewPasswordEntry()
+	if !isNew {
+		password.SetPlaceHolder(lang.L("Оставьте пустым, чтобы не менять"))
+	}
+
+	items := []*widget.FormItem{
+		widget.NewFormItem(lang.L("Пользователь"), username),
+		widget.NewFormItem(lang.L("Имя"), fullName),
+		widget.NewFormItem(lang.L("Роль"), role),
+		widget.NewFormItem(lang.L("Пароль"), password),
+		widget.NewFormItem("", disabled),
+	}
+
+	title := lang.L("Новый пользователь")
+	if !isNew {
+		title = fmt.Sprintf(lang.L("Пользователь %s"), user.Username)
+	}
+	dialog.ShowForm(title, lang.L("Сохранить"), lang.L("Отмена"), items, func(ok bool) {
+		if !ok {
+			return
+		}
+		user.Username = username.Text
+		user.FullName = fullName.Text
+		user.Role = models.Roles[role.SelectedIndex()]
+		user.Disabled = disabled.Checked
+
+		var err error
+		if isNew {
+			err = database.CreateUser(user, password.Text)
+		} else {
+			err = database.UpdateUser(user, password.Text)
+		}
+		if err != nil {
+			showError(err, m.window)
+			return
+		}
+		m.reload()
+		m.mainWindow.statusBar.SetText(fmt.Sprintf(lang.L("Пользователь %s сохранен"), user.Username))
+	}, m.window)
+}
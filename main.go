@@ -1,21 +1,53 @@
 package main
 
 import (
+	"flag"
 	"log"
+	"os"
+	"time"
 
+	"SanWarehouse/config"
 	db "SanWarehouse/database"
 	gui "SanWarehouse/gui"
 	//"SanWarehouse/models"
 )
 
 func main() {
+	// Подкоманды командной строки, например: SanWarehouse generate -n 100000
+	if runCommand(os.Args[1:]) {
+		return
+	}
+
+	demo := flag.Bool("demo", false, "заполнить пустую базу демонстрационными данными")
+	demoProducts := flag.Int("demo-products", db.DemoProducts, "сколько товаров сгенерировать для демонстрации")
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal("Ошибка чтения настроек:", err)
+	}
+
 	// Инициализируем базу данных
-	if err := db.InitDB(); err != nil {
+	if err := db.InitDB(cfg.Database); err != nil {
 		log.Fatal("Ошибка инициализации БД:", err)
 	}
 	defer db.CloseDB()
 
+	// Демонстрационные данные загружаются только по запросу и только в пустую базу;
+	// при первом запуске их также предлагает экран создания администратора
+	if *demo {
+		if db.WarehouseEmpty() {
+			n, err := db.LoadDemoData(*demoProducts, time.Now().UnixNano())
+			if err != nil {
+				log.Fatal("Ошибка загрузки демонстрационных данных:", err)
+			}
+			log.Printf("Загружено демонстрационных товаров: %d", n)
+		} else {
+			log.Println("База не пуста, демонстрационные данные не загружены")
+		}
+	}
+
 	// Запускаем GUI
-	app := gui.NewMainWindow()
+	app := gui.NewMainWindow(cfg)
 	app.Run()
 }
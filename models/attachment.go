@@ -0,0 +1,47 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+type AttachmentKind string
+
+const (
+	AttachmentImage    AttachmentKind = "image"
+	AttachmentDocument AttachmentKind = "document"
+)
+
+// Attachment - изображение или документ товара (паспорт, сертификат).
+// Сам файл хранится в каталоге данных, в БД - только сведения о нем.
+type Attachment struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	ProductID uint           `gorm:"index;not null" json:"product_id"`
+	Kind      AttachmentKind `gorm:"size:20;index" json:"kind"`
+	Title     string         `gorm:"size:200" json:"title"`
+	// FileName - исходное имя файла, StoredName - путь относительно каталога вложений
+	FileName   string `gorm:"size:255" json:"file_name"`
+	StoredName string `gorm:"size:255" json:"stored_name"`
+	// ThumbnailName - миниатюра изображения, пусто для документов
+	ThumbnailName string `gorm:"size:255" json:"thumbnail_name"`
+	MimeType      string `gorm:"size:100" json:"mime_type"`
+	Size          int64  `json:"size"`
+	// IsPrimary - основное изображение товара, показывается в списке
+	IsPrimary bool `gorm:"default:false" json:"is_primary"`
+	SortOrder int  `json:"sort_order"`
+}
+
+// IsImage сообщает, является ли вложение изображением
+func (a *Attachment) IsImage() bool {
+	return a.Kind == AttachmentImage
+}
+
+// DisplayName возвращает подпись вложения: название или имя файла
+func (a *Attachment) DisplayName() string {
+	if strings.TrimSpace(a.Title) != "" {
+		return a.Title
+	}
+	return a.FileName
+}
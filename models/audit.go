@@ -0,0 +1,119 @@
+package models
+
+import (
+	"time"
+)
+
+type AuditAction string
+
+const (
+	AuditCreate AuditAction = "create"
+	AuditUpdate AuditAction = "update"
+	AuditDelete AuditAction = "delete"
+	// AuditRestore - восстановление записи, помеченной удаленной
+	AuditRestore AuditAction = "restore"
+)
+
+// AuditActions - действия журнала в порядке отображения
+var AuditActions = []AuditAction{AuditCreate, AuditUpdate, AuditDelete, AuditRestore}
+
+// Title возвращает название действия для интерфейса
+func (a AuditAction) Title() string {
+	switch a {
+	case AuditCreate:
+		return "Создание"
+	case AuditUpdate:
+		return "Изменение"
+	case AuditDelete:
+		return "Удаление"
+	case AuditRestore:
+		return "Восстановление"
+	}
+	return string(a)
+}
+
+// Auditable - сущность, изменения которой записываются в журнал аудита.
+// AuditLabel возвращает понятное человеку обозначение записи (артикул, номер, имя).
+//
+// Поля с тегом audit:"-" в журнал не попадают, значения полей с тегом
+// audit:"secret" (например, хеш пароля) заменяются на маску.
+type Auditable interface {
+	AuditLabel() string
+}
+
+// AuditSecretMask заменяет значения секретных полей в журнале
+const AuditSecretMask = "***"
+
+// AuditEntry - запись журнала аудита: одно создание, изменение или удаление сущности
+type AuditEntry struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+
+	// Пользователь, выполнивший действие; nil - команды командной строки и служебные операции
+	UserID   *uint  `gorm:"index" json:"user_id"`
+	Username string `gorm:"size:50;index" json:"username"`
+
+	Action      AuditAction `gorm:"size:10;index" json:"action"`
+	Entity      string      `gorm:"size:50;index:idx_audit_entity" json:"entity"`
+	EntityID    uint        `gorm:"index:idx_audit_entity" json:"entity_id"`
+	EntityLabel string      `gorm:"size:500;index" json:"entity_label"`
+
+	Changes []AuditChange `json:"changes"`
+}
+
+// AuditChange - старое и новое значение одного поля
+type AuditChange struct {
+	ID           uint   `gorm:"primarykey" json:"id"`
+	AuditEntryID uint   `gorm:"index;not null" json:"audit_entry_id"`
+	Field        string `gorm:"size:50;index" json:"field"`
+	OldValue     string `gorm:"type:text" json:"old_value"`
+	NewValue     string `gorm:"type:text" json:"new_value"`
+}
+
+// AuditLabel реализует Auditable
+func (p *Product) AuditLabel() string {
+	return p.SKU
+}
+
+// AuditLabel реализует Auditable
+func (s *Supplier) AuditLabel() string {
+	return s.Name
+}
+
+// AuditLabel реализует Auditable
+func (c *Category) AuditLabel() string {
+	return c.Path
+}
+
+// AuditLabel реализует Auditable
+func (b *Brand) AuditLabel() string {
+	return b.Name
+}
+
+// AuditLabel реализует Auditable
+func (o *PurchaseOrder) AuditLabel() string {
+	return o.Number
+}
+
+// AuditLabel реализует Auditable
+func (r *Reservation) AuditLabel() string {
+	if r.Reference != "" {
+		return r.Reference
+	}
+	return r.Customer
+}
+
+// AuditLabel реализует Auditable
+func (a *Attachment) AuditLabel() string {
+	return a.DisplayName()
+}
+
+// AuditLabel реализует Auditable
+func (u *User) AuditLabel() string {
+	return u.Username
+}
+
+// AuditLabel реализует Auditable
+func (r *ExchangeRate) AuditLabel() string {
+	return string(r.Currency) + " " + r.Date.Format("2006-01-02")
+}
@@ -0,0 +1,143 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+)
+
+// BulkField - поле товара, доступное для массового изменения
+type BulkField string
+
+const (
+	BulkCategory      BulkField = "category"
+	BulkBrand         BulkField = "brand"
+	BulkLocation      BulkField = "location"
+	BulkMaterial      BulkField = "material"
+	BulkMinStock      BulkField = "min_stock_level"
+	BulkActive        BulkField = "is_active"
+	BulkPurchasePrice BulkField = "purchase_price"
+	BulkSellingPrice  BulkField = "selling_price"
+)
+
+// BulkOp - вид изменения поля
+type BulkOp string
+
+const (
+	BulkSet     BulkOp = "set"
+	BulkClear   BulkOp = "clear"
+	BulkPercent BulkOp = "percent"
+)
+
+// maxBulkPercent ограничивает наценку при массовом изменении цен: большее значение -
+// скорее ошибка ввода, а цена за пределами копеек int64 переполнилась бы
+const maxBulkPercent = 10000
+
+// BulkChange - изменение одного поля у группы товаров
+type BulkChange struct {
+	Field BulkField
+	Op    BulkOp
+	// Value - новое значение (для BulkSet) или процент (для BulkPercent)
+	Value string
+}
+
+// Apply применяет изменение к товару
+func (c BulkChange) Apply(p *Product) error {
+	switch c.Field {
+	case BulkCategory, BulkBrand, BulkLocation, BulkMaterial:
+		field := c.stringField(p)
+		switch c.Op {
+		case BulkSet:
+			value := strings.TrimSpace(c.Value)
+			if value == "" && c.Field == BulkCategory {
+				return Errorf("категория не может быть пустой")
+			}
+			*field = value
+		case BulkClear:
+			if c.Field == BulkCategory {
+				return Errorf("категория не может быть пустой")
+			}
+			*field = ""
+		default:
+			return c.unsupported()
+		}
+
+	case BulkMinStock:
+		switch c.Op {
+		case BulkSet:
+			value, err := strconv.Atoi(strings.TrimSpace(c.Value))
+			if err != nil || value < 0 {
+				return Errorf("некорректный минимальный запас: %s", c.Value)
+			}
+			p.MinStockLevel = value
+		case BulkClear:
+			p.MinStockLevel = 0
+		default:
+			return c.unsupported()
+		}
+
+	case BulkActive:
+		if c.Op != BulkSet {
+			return c.unsupported()
+		}
+		value, err := strconv.ParseBool(c.Value)
+		if err != nil {
+			return Errorf("некорректное значение активности: %s", c.Value)
+		}
+		p.IsActive = value
+
+	case BulkPurchasePrice, BulkSellingPrice:
+		price := &p.PurchasePrice
+		if c.Field == BulkSellingPrice {
+			price = &p.SellingPrice
+		}
+		switch c.Op {
+		case BulkSet:
+			value, err := ParseMoney(c.Value)
+			if err != nil || value < 0 {
+				return Errorf("некорректная цена: %s", c.Value)
+			}
+			*price = value
+		case BulkPercent:
+			percent, err := ParseDecimal(c.Value)
+			if err != nil || percent <= -100 || percent > maxBulkPercent {
+				return Errorf("некорректный процент: %s", c.Value)
+			}
+			*price = price.AddPercent(percent)
+		case BulkClear:
+			*price = 0
+		default:
+			return c.unsupported()
+		}
+
+	default:
+		return Errorf("поле %s нельзя изменять массово", c.Field)
+	}
+	return nil
+}
+
+func (c BulkChange) stringField(p *Product) *string {
+	switch c.Field {
+	case BulkCategory:
+		return &p.Category
+	case BulkBrand:
+		return &p.Brand
+	case BulkLocation:
+		return &p.Location
+	default:
+		return &p.Material
+	}
+}
+
+func (c BulkChange) unsupported() error {
+	return Errorf("операция %s не поддерживается для поля %s", c.Op, c.Field)
+}
+
+// ApplyBulkChanges применяет набор изменений к товару
+func ApplyBulkChanges(p *Product, changes []BulkChange) error {
+	for _, c := range changes {
+		if err := c.Apply(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// CategoryPathSeparator разделяет уровни в полном пути категории
+const CategoryPathSeparator = " > "
+
+// Category - категория товаров. Категории образуют дерево:
+// "Сантехника > Смесители > Для раковины".
+type Category struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Name     string `gorm:"size:100;not null" json:"name"`
+	ParentID *uint  `gorm:"index" json:"parent_id"`
+	// Path - полный путь от корня, хранится для фильтров и отчетов
+	Path string `gorm:"size:500;not null" json:"path"`
+	// LookupKey - путь в нижнем регистре: "Смесители" и "смесители " - одна категория
+	LookupKey string `gorm:"size:500;uniqueIndex;not null" json:"-" audit:"-"`
+}
+
+// Level возвращает глубину категории: 1 - корневая
+func (c *Category) Level() int {
+	return len(SplitCategoryPath(c.Path))
+}
+
+// Brand - бренд (производитель) товаров
+type Brand struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Name string `gorm:"size:100;not null" json:"name"`
+	// LookupKey - название в нижнем регистре для поиска дубликатов
+	LookupKey string `gorm:"size:100;uniqueIndex;not null" json:"-" audit:"-"`
+}
+
+// NormalizeName убирает лишние пробелы в названии
+func NormalizeName(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// LookupKey возвращает ключ сравнения названий без учета регистра и пробелов
+func LookupKey(s string) string {
+	return strings.ToLower(NormalizeName(s))
+}
+
+// SplitCategoryPath разбивает путь категории на названия уровней, пропуская пустые
+func SplitCategoryPath(path string) []string {
+	var parts []string
+	for _, part := range strings.Split(path, ">") {
+		if part = NormalizeName(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+// JoinCategoryPath собирает путь категории из названий уровней
+func JoinCategoryPath(parts []string) string {
+	return strings.Join(parts, CategoryPathSeparator)
+}
+
+// CategoryAncestor возвращает путь предка категории на уровне level
+// или сам путь, если категория не глубже этого уровня
+func CategoryAncestor(path string, level int) string {
+	parts := SplitCategoryPath(path)
+	if level > 0 && len(parts) > level {
+		parts = parts[:level]
+	}
+	return JoinCategoryPath(parts)
+}
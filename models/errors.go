@@ -0,0 +1,75 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// UserError - ошибка, текст которой показывается пользователю. Format - шаблон по-русски,
+// он же ключ каталога перевода интерфейса: аргументы подставляются уже в переведенный шаблон.
+type UserError struct {
+	Format string
+	Args   []interface{}
+}
+
+// Errorf создает UserError. Как и в fmt.Errorf, %w оборачивает ошибку; аргументы
+// с названием (права, роли, статусы) выводятся названием.
+func Errorf(format string, args ...interface{}) error {
+	return &UserError{Format: format, Args: args}
+}
+
+func (e *UserError) Error() string {
+	return e.Localize(func(s string) string { return s })
+}
+
+// Unwrap возвращает ошибки, обернутые через %w
+func (e *UserError) Unwrap() []error {
+	var wrapped []error
+	for _, a := range e.Args {
+		if err, ok := a.(error); ok {
+			wrapped = append(wrapped, err)
+		}
+	}
+	return wrapped
+}
+
+// Localize формирует текст ошибки, переводя шаблон и названия функцией translate
+func (e *UserError) Localize(translate func(string) string) string {
+	return localize(translate, e.Format, e.Args)
+}
+
+// titled - значение с названием для пользователя
+type titled interface {
+	Title() string
+}
+
+// LocalizeError возвращает текст ошибки, переведенный функцией translate. Переводятся
+// UserError и ValidationErrors, в том числе обернутые в UserError; текст прочих ошибок
+// (драйвера базы, файловой системы) не изменяется.
+func LocalizeError(err error, translate func(string) string) string {
+	switch e := err.(type) {
+	case nil:
+		return ""
+	case *UserError:
+		return e.Localize(translate)
+	case ValidationErrors:
+		return e.Localize(translate)
+	}
+	return err.Error()
+}
+
+// localize подставляет аргументы в переведенный шаблон
+func localize(translate func(string) string, format string, args []interface{}) string {
+	values := make([]interface{}, len(args))
+	for i, a := range args {
+		switch v := a.(type) {
+		case error:
+			values[i] = LocalizeError(v, translate)
+		case titled:
+			values[i] = translate(v.Title())
+		default:
+			values[i] = a
+		}
+	}
+	return fmt.Sprintf(strings.ReplaceAll(translate(format), "%w", "%v"), values...)
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+)
+
+// ExchangeRate - курс валюты к BaseCurrency, действующий с даты Date до следующего курса
+// этой валюты: сколько единиц базовой валюты стоит одна единица Currency
+type ExchangeRate struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Currency Currency  `gorm:"size:3;not null;uniqueIndex:idx_exchange_rates_currency_date" json:"currency"`
+	Date     time.Time `gorm:"not null;uniqueIndex:idx_exchange_rates_currency_date" json:"date"`
+	Rate     float64   `gorm:"not null" json:"rate"`
+}
+
+// RateDay приводит время к началу дня: курсы задаются на день, без времени
+func RateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// ExchangeRates - действующие курсы по валютам
+type ExchangeRates map[Currency]float64
+
+// Convert пересчитывает сумму в валюте c в базовую валюту. Возвращает false,
+// если курса валюты нет.
+func (r ExchangeRates) Convert(m Money, c Currency) (Money, bool) {
+	if c == BaseCurrency || c == "" {
+		return m, true
+	}
+	rate, ok := r[c]
+	if !ok {
+		return 0, false
+	}
+	return m.Convert(rate), true
+}
@@ -0,0 +1,184 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Money - денежная сумма в сотых долях валюты (копейках, центах). Суммы складываются
+// и умножаются на количество точно, без ошибок округления float64. В базе хранится
+// целым числом копеек, в JSON - числом с двумя знаками после точки.
+type Money int64
+
+// moneyScale - сколько сотых в единице валюты
+const moneyScale = 100
+
+// NewMoney округляет сумму до сотых
+func NewMoney(v float64) Money {
+	return Money(math.Round(v * moneyScale))
+}
+
+// ParseMoney разбирает сумму, введенную пользователем или прочитанную из файла: "1 250,50",
+// "1250.5". Дробная часть длиннее двух знаков округляется.
+func ParseMoney(s string) (Money, error) {
+	text := normalizeNumber(s)
+	text, negative := strings.CutPrefix(text, "-")
+	if !negative {
+		text, _ = strings.CutPrefix(text, "+")
+	}
+
+	whole, fraction, _ := strings.Cut(text, ".")
+	if whole+fraction == "" || !allDigits(whole) || !allDigits(fraction) {
+		return 0, Errorf("некорректная сумма: %s", s)
+	}
+	if whole == "" {
+		whole = "0"
+	}
+	units, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || units > math.MaxInt64/moneyScale-1 {
+		return 0, Errorf("некорректная сумма: %s", s)
+	}
+
+	fraction += "000"
+	cents, _ := strconv.ParseInt(fraction[:2], 10, 64)
+	if fraction[2] >= '5' {
+		cents++
+	}
+	m := Money(units*moneyScale + cents)
+	if negative {
+		m = -m
+	}
+	return m, nil
+}
+
+func allDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Float возвращает сумму числом, например для графиков и процентов
+func (m Money) Float() float64 {
+	return float64(m) / moneyScale
+}
+
+// String возвращает сумму с двумя знаками после точки: "1250.50". Так сумма пишется
+// в журнал и файлы выгрузки; для показа пользователю сумма форматируется по языку.
+func (m Money) String() string {
+	sign := ""
+	if m < 0 {
+		sign = "-"
+		m = -m
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, m/moneyScale, m%moneyScale)
+}
+
+// Times возвращает стоимость n единиц по цене m
+func (m Money) Times(n int) Money {
+	return m * Money(n)
+}
+
+// AddPercent изменяет сумму на percent процентов с округлением до сотых
+func (m Money) AddPercent(percent float64) Money {
+	return Money(math.Round(float64(m) * (100 + percent) / 100))
+}
+
+// Convert пересчитывает сумму по курсу rate с округлением до сотых
+func (m Money) Convert(rate float64) Money {
+	return Money(math.Round(float64(m) * rate))
+}
+
+// GormDataType - в базе сумма хранится целым числом копеек: sum() по ней остается
+// точным, а сравнение в поиске и сортировка идут по числу
+func (Money) GormDataType() string {
+	return "bigint"
+}
+
+// Value записывает сумму в базу числом копеек
+func (m Money) Value() (driver.Value, error) {
+	return int64(m), nil
+}
+
+// Scan читает сумму в копейках из базы. Колонка возвращает целое число, а выражения
+// отчетов - дробное (сумма, пересчитанная по курсу, avg) или строку numeric в PostgreSQL;
+// дробные копейки округляются.
+func (m *Money) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*m = 0
+	case int64:
+		*m = Money(v)
+	case float64:
+		*m = Money(math.Round(v))
+	case []byte:
+		return m.Scan(string(v))
+	case string:
+		cents, err := strconv.ParseFloat(v, 64)
+		if err != nil || math.IsNaN(cents) || math.IsInf(cents, 0) {
+			return fmt.Errorf("сумма: некорректное значение %q", v)
+		}
+		*m = Money(math.Round(cents))
+	default:
+		return fmt.Errorf("сумма: неподдерживаемый тип %T", src)
+	}
+	return nil
+}
+
+// MarshalJSON записывает сумму числом с двумя знаками после точки
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON читает сумму из числа или строки
+func (m *Money) UnmarshalJSON(data []byte) error {
+	text := strings.Trim(string(data), `"`)
+	if text == "null" || text == "" {
+		*m = 0
+		return nil
+	}
+	parsed, err := ParseMoney(text)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Currency - код валюты ISO 4217
+type Currency string
+
+const (
+	CurrencyRUB Currency = "RUB"
+	CurrencyEUR Currency = "EUR"
+	CurrencyUSD Currency = "USD"
+)
+
+// BaseCurrency - валюта учета: в ней задаются продажные цены и считаются итоги отчетов.
+// Закупочные цены могут быть в любой валюте и пересчитываются по курсам, см. ExchangeRate.
+const BaseCurrency = CurrencyRUB
+
+// Currencies - валюты, которые предлагаются при вводе цен
+var Currencies = []Currency{CurrencyRUB, CurrencyEUR, CurrencyUSD}
+
+var currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// ParseCurrency приводит код валюты к виду ISO 4217: "eur" - EUR
+func ParseCurrency(s string) (Currency, error) {
+	c := Currency(strings.ToUpper(strings.TrimSpace(s)))
+	if !c.Valid() {
+		return "", Errorf("некорректный код валюты: %s", s)
+	}
+	return c, nil
+}
+
+// Valid сообщает, похож ли код на код валюты ISO 4217: три латинские буквы
+func (c Currency) Valid() bool {
+	return currencyPattern.MatchString(string(c))
+}
@@ -0,0 +1,33 @@
+package models
+
+import "testing"
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Money
+		ok    bool
+	}{
+		{"1250.5", 125050, true},
+		{"1 250,50", 125050, true},
+		{"0,005", 1, true},
+		{".5", 50, true},
+		{"+5", 500, true},
+		{"-5", -500, true},
+		{"-0.01", -1, true},
+		{"", 0, false},
+		{"-", 0, false},
+		{"--5", 0, false},
+		{"+-5", 0, false},
+		{"-+5", 0, false},
+		{"5-", 0, false},
+		{"1.2.3", 0, false},
+		{"abc", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := ParseMoney(tt.input)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("ParseMoney(%q) = %v, %v, want %v, ok %v", tt.input, got, err, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+)
+
+// PriceChange фиксирует изменение закупочной или продажной цены товара
+type PriceChange struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+
+	ProductID           uint     `gorm:"index;not null" json:"product_id"`
+	OldPurchasePrice    Money    `json:"old_purchase_price"`
+	OldPurchaseCurrency Currency `gorm:"size:3;not null;default:'RUB'" json:"old_purchase_currency"`
+	NewPurchasePrice    Money    `json:"new_purchase_price"`
+	NewPurchaseCurrency Currency `gorm:"size:3;not null;default:'RUB'" json:"new_purchase_currency"`
+	OldSellingPrice     Money    `json:"old_selling_price"`
+	NewSellingPrice     Money    `json:"new_selling_price"`
+}
+
+// SellingChangePercent возвращает изменение продажной цены в процентах
+func (c *PriceChange) SellingChangePercent() float64 {
+	if c.OldSellingPrice == 0 {
+		return 0
+	}
+	return float64(c.NewSellingPrice-c.OldSellingPrice) / float64(c.OldSellingPrice) * 100
+}
@@ -14,27 +14,68 @@ const (
 	StatusOnOrder ProductStatus = "On order"
 )
 
+// DefaultMinStockLevel - минимальный уровень нового товара, совпадает с default в теге MinStockLevel
+const DefaultMinStockLevel = 5
+
+// ProductStatuses - статусы товара в порядке отображения
+var ProductStatuses = []ProductStatus{StatusInStock, StatusLowStock, StatusOutOfStock, StatusOnOrder}
+
+// Title возвращает название статуса для интерфейса
+func (s ProductStatus) Title() string {
+	switch s {
+	case StatusInStock:
+		return "В наличии"
+	case StatusLowStock:
+		return "Мало на складе"
+	case StatusOutOfStock:
+		return "Нет в наличии"
+	case StatusOnOrder:
+		return "Под заказ"
+	}
+	return string(s)
+}
+
 type Product struct {
     ID              uint           `gorm:"primarykey" json:"id"`
     CreatedAt       time.Time      `json:"created_at"`
     UpdatedAt       time.Time      `json:"updated_at"`
     DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
+    // Номер версии растет при каждом изменении; сохранение устаревшей версии
+    // отклоняется, см. database.ErrVersionConflict
+    Version         uint           `gorm:"not null;default:0" json:"version" audit:"-"`
 
     SKU             string         `gorm:"uniqueIndex;size:50" json:"sku"`
-    Name            string         `gorm:"size:200;not null" json:"name"`
-    Category        string         `gorm:"size:100" json:"category"`
-    Brand           string         `gorm:"size:100" json:"brand"`
+    Name            string         `gorm:"size:200;not null;index" json:"name"`
+    // Category и Brand - названия из справочников, см. database.ResolveCatalog
+    Category        string         `gorm:"size:500;index" json:"category"`
+    CategoryID      *uint          `gorm:"index" json:"category_id"`
+    Brand           string         `gorm:"size:100;index" json:"brand"`
+    BrandID         *uint          `gorm:"index" json:"brand_id"`
     Description     string         `gorm:"type:text" json:"description"`
     
-    Quantity        int            `gorm:"not null;default:0" json:"quantity"`
+    Quantity        int            `gorm:"not null;default:0;index" json:"quantity"`
     ReservedQuantity int           `gorm:"default:0" json:"reserved_quantity"`
     
-    PurchasePrice   float64        `json:"purchase_price"`
-    SellingPrice    float64        `json:"selling_price"`
+    // Закупочная цена - в валюте поставщика, продажная - в BaseCurrency
+    PurchasePrice   Money          `json:"purchase_price"`
+    PurchaseCurrency Currency      `gorm:"size:3;not null;default:'RUB'" json:"purchase_currency"`
+    SellingPrice    Money          `gorm:"index" json:"selling_price"`
     MinStockLevel   int            `gorm:"default:5" json:"min_stock_level"`
+
+    // Политика пополнения
+    ReorderPoint    int            `gorm:"default:0" json:"reorder_point"`
+    ReorderQuantity int            `gorm:"default:0" json:"reorder_quantity"`
+    MaxStockLevel   int            `gorm:"default:0" json:"max_stock_level"`
+    LeadTimeDays    int            `gorm:"default:0" json:"lead_time_days"`
+    SupplierID      *uint          `gorm:"index" json:"supplier_id"`
+    Supplier        *Supplier      `json:"-"`
+
+    // Классы ABC/XYZ по результатам последнего анализа; в журнал аудита не записываются
+    ABCClass        string         `gorm:"size:1;index" json:"abc_class" audit:"-"`
+    XYZClass        string         `gorm:"size:1;index" json:"xyz_class" audit:"-"`
     
-    Location        string         `gorm:"size:50" json:"location"`
-    Status          ProductStatus  `gorm:"size:20;default:'В наличии'" json:"status"`
+    Location        string         `gorm:"size:50;index" json:"location"`
+    Status          ProductStatus  `gorm:"size:20;default:'В наличии';index" json:"status"`
     
     Weight          float64        `json:"weight"`
     Dimensions      string         `gorm:"size:50" json:"dimensions"`
@@ -42,6 +83,11 @@ type Product struct {
     
     MarketplaceID   string         `gorm:"size:100" json:"marketplace_id"`
     IsActive        bool           `gorm:"default:true" json:"is_active"`
+
+    // Статус до пересчета в BeforeSave, нужен для фиксации переходов
+    previousStatus  ProductStatus
+    // Цены до сохранения, загружаются в BeforeSave для истории цен
+    previousPrices  *PriceChange
 }
 
 func (p *Product) AvailableQuantity() int {
@@ -61,10 +107,63 @@ func (p *Product) UpdateStatus() {
 }
 
 func (p *Product) BeforeSave(tx *gorm.DB) error {
+    p.previousStatus = p.Status
     p.UpdateStatus()
+    if p.PurchaseCurrency == "" {
+        p.PurchaseCurrency = BaseCurrency
+    }
+
+    // Запоминаем сохраненные цены, чтобы записать их изменение в AfterSave
+    p.previousPrices = nil
+    if p.ID != 0 {
+        var old PriceChange
+        err := tx.Session(&gorm.Session{NewDB: true}).Model(&Product{}).
+            Select("purchase_price AS old_purchase_price, purchase_currency AS old_purchase_currency, selling_price AS old_selling_price").
+            Where("id = ?", p.ID).
+            Take(&old).Error
+        if err == nil {
+            p.previousPrices = &old
+        }
+    }
     return nil
 }
 
+// AfterSave записывает изменение цен и событие, если статус товара изменился
+func (p *Product) AfterSave(tx *gorm.DB) error {
+    if old := p.previousPrices; old != nil && (old.OldPurchasePrice != p.PurchasePrice ||
+        old.OldPurchaseCurrency != p.PurchaseCurrency || old.OldSellingPrice != p.SellingPrice) {
+        change := PriceChange{
+            ProductID:           p.ID,
+            OldPurchasePrice:    old.OldPurchasePrice,
+            OldPurchaseCurrency: old.OldPurchaseCurrency,
+            NewPurchasePrice:    p.PurchasePrice,
+            NewPurchaseCurrency: p.PurchaseCurrency,
+            OldSellingPrice:     old.OldSellingPrice,
+            NewSellingPrice:     p.SellingPrice,
+        }
+        p.previousPrices = nil
+        if err := tx.Session(&gorm.Session{NewDB: true}).Create(&change).Error; err != nil {
+            return err
+        }
+    }
+
+    if p.previousStatus == "" || p.previousStatus == p.Status {
+        return nil
+    }
+
+    event := StatusEvent{
+        ProductID:   p.ID,
+        SKU:         p.SKU,
+        ProductName: p.Name,
+        OldStatus:   p.previousStatus,
+        NewStatus:   p.Status,
+        Available:   p.AvailableQuantity(),
+    }
+    p.previousStatus = p.Status
+
+    return tx.Session(&gorm.Session{NewDB: true}).Create(&event).Error
+}
+
 type ProductDisplay struct {
     ID          uint
     SKU         string
@@ -73,7 +172,7 @@ type ProductDisplay struct {
     Brand       string
     Quantity    int
     Available   int
-    Price       float64
+    Price       Money
     Status      ProductStatus
     Location    string
 }
\ No newline at end of file
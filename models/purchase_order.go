@@ -0,0 +1,70 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type OrderStatus string
+
+const (
+	OrderDraft     OrderStatus = "draft"
+	OrderPlaced    OrderStatus = "placed"
+	OrderReceived  OrderStatus = "received"
+	OrderCancelled OrderStatus = "cancelled"
+)
+
+// OpenOrderStatuses - статусы заказов, товар по которым еще ожидается на складе
+var OpenOrderStatuses = []OrderStatus{OrderDraft, OrderPlaced}
+
+// PurchaseOrder - заказ поставщику
+type PurchaseOrder struct {
+	ID        uint           `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	// Номер версии для оптимистической блокировки, см. Product.Version
+	Version uint `gorm:"not null;default:0" json:"version" audit:"-"`
+
+	Number     string      `gorm:"uniqueIndex;size:50" json:"number"`
+	SupplierID *uint       `gorm:"index" json:"supplier_id"`
+	Supplier   *Supplier   `json:"-"`
+	Status     OrderStatus `gorm:"size:20;index;default:'draft'" json:"status"`
+	ExpectedAt *time.Time  `json:"expected_at"`
+	Comment    string      `gorm:"size:255" json:"comment"`
+	// Currency - валюта цен заказа: заказ оплачивается в одной валюте
+	Currency Currency `gorm:"size:3;not null;default:'RUB'" json:"currency"`
+
+	Lines []PurchaseOrderLine `json:"lines"`
+}
+
+// PurchaseOrderLine - строка заказа поставщику
+type PurchaseOrderLine struct {
+	ID              uint `gorm:"primarykey" json:"id"`
+	PurchaseOrderID uint `gorm:"index;not null" json:"purchase_order_id"`
+
+	ProductID uint     `gorm:"index;not null" json:"product_id"`
+	Product   *Product `json:"-"`
+	Quantity  int      `json:"quantity"`
+	UnitPrice Money    `json:"unit_price"`
+}
+
+// IsOpen сообщает, ожидается ли еще поступление по заказу
+func (o *PurchaseOrder) IsOpen() bool {
+	for _, s := range OpenOrderStatuses {
+		if o.Status == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Total возвращает сумму заказа в его валюте
+func (o *PurchaseOrder) Total() Money {
+	var total Money
+	for _, l := range o.Lines {
+		total += l.UnitPrice.Times(l.Quantity)
+	}
+	return total
+}
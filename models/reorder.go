@@ -0,0 +1,49 @@
+package models
+
+import (
+	"math"
+)
+
+// EffectiveReorderPoint возвращает точку заказа товара.
+// Если она не задана явно, считаем ее как спрос за время поставки,
+// но не ниже минимального уровня запаса.
+func (p *Product) EffectiveReorderPoint(dailyUsage float64) int {
+	if p.ReorderPoint > 0 {
+		return p.ReorderPoint
+	}
+	leadDemand := int(math.Ceil(dailyUsage * float64(p.LeadTimeDays)))
+	if leadDemand > p.MinStockLevel {
+		return leadDemand
+	}
+	return p.MinStockLevel
+}
+
+// SuggestOrderQuantity рассчитывает рекомендуемое количество к заказу.
+// onOrder - количество в открытых заказах, dailyUsage - средний расход в день.
+// Возвращает 0, если запас с учетом заказов выше точки заказа.
+func (p *Product) SuggestOrderQuantity(onOrder int, dailyUsage float64) int {
+	position := p.AvailableQuantity() + onOrder
+	reorderPoint := p.EffectiveReorderPoint(dailyUsage)
+	if position > reorderPoint {
+		return 0
+	}
+
+	// Пополняем до максимального уровня, если он задан
+	if p.MaxStockLevel > reorderPoint {
+		return p.MaxStockLevel - position
+	}
+
+	// Иначе заказываем кратно размеру партии, пока не превысим точку заказа
+	if p.ReorderQuantity > 0 {
+		batches := (reorderPoint-position)/p.ReorderQuantity + 1
+		return batches * p.ReorderQuantity
+	}
+
+	// Без настроек восполняем точку заказа плюс спрос за время поставки
+	leadDemand := int(math.Ceil(dailyUsage * float64(p.LeadTimeDays)))
+	quantity := reorderPoint - position + leadDemand
+	if quantity < 1 {
+		quantity = 1
+	}
+	return quantity
+}
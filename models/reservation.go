@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+)
+
+type ReservationStatus string
+
+const (
+	ReservationActive    ReservationStatus = "active"
+	ReservationReleased  ReservationStatus = "released"
+	ReservationFulfilled ReservationStatus = "fulfilled"
+)
+
+// Reservation - резерв товара под заказ клиента. Сумма активных резервов
+// отражается в Product.ReservedQuantity.
+type Reservation struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// Номер версии для оптимистической блокировки, см. Product.Version
+	Version uint `gorm:"not null;default:0" json:"version" audit:"-"`
+
+	ProductID uint              `gorm:"index;not null" json:"product_id"`
+	Quantity  int               `gorm:"not null" json:"quantity"`
+	Status    ReservationStatus `gorm:"size:20;index;default:'active'" json:"status"`
+	Customer  string            `gorm:"size:200" json:"customer"`
+	Reference string            `gorm:"size:100" json:"reference"`
+	ExpiresAt *time.Time        `json:"expires_at"`
+}
+
+// IsActive сообщает, удерживает ли резерв товар
+func (r *Reservation) IsActive() bool {
+	return r.Status == ReservationActive
+}
+
+// IsExpired сообщает, истек ли срок активного резерва
+func (r *Reservation) IsExpired(now time.Time) bool {
+	return r.IsActive() && r.ExpiresAt != nil && now.After(*r.ExpiresAt)
+}
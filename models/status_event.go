@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+)
+
+// StatusEvent фиксирует переход товара из одного статуса в другой
+type StatusEvent struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+
+	ProductID   uint          `gorm:"index;not null" json:"product_id"`
+	SKU         string        `gorm:"size:50" json:"sku"`
+	ProductName string        `gorm:"size:200" json:"product_name"`
+	OldStatus   ProductStatus `gorm:"size:20" json:"old_status"`
+	NewStatus   ProductStatus `gorm:"size:20" json:"new_status"`
+	Available   int           `json:"available"`
+
+	Acknowledged   bool       `gorm:"index;default:false" json:"acknowledged"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at"`
+	SnoozedUntil   *time.Time `json:"snoozed_until"`
+}
+
+// IsAlert сообщает, требует ли переход внимания (товар заканчивается или закончился)
+func (e *StatusEvent) IsAlert() bool {
+	return e.NewStatus == StatusLowStock || e.NewStatus == StatusOutOfStock
+}
+
+// IsActive сообщает, нужно ли показывать событие в центре уведомлений
+func (e *StatusEvent) IsActive(now time.Time) bool {
+	if e.Acknowledged {
+		return false
+	}
+	return e.SnoozedUntil == nil || now.After(*e.SnoozedUntil)
+}
+
+// Acknowledge отмечает событие как прочитанное
+func (e *StatusEvent) Acknowledge(now time.Time) {
+	e.Acknowledged = true
+	e.AcknowledgedAt = &now
+}
+
+// Snooze откладывает событие на указанное время
+func (e *StatusEvent) Snooze(now time.Time, d time.Duration) {
+	until := now.Add(d)
+	e.SnoozedUntil = &until
+}
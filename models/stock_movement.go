@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+)
+
+type MovementType string
+
+const (
+	MovementReceipt    MovementType = "receipt"
+	MovementShipment   MovementType = "shipment"
+	MovementAdjustment MovementType = "adjustment"
+)
+
+// StockMovement - движение товара: поступление, отгрузка или корректировка остатка
+type StockMovement struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+
+	ProductID uint         `gorm:"index;not null" json:"product_id"`
+	Type      MovementType `gorm:"size:20;index" json:"type"`
+	// Quantity положительно для прихода и отрицательно для расхода
+	Quantity  int    `json:"quantity"`
+	Location  string `gorm:"size:50" json:"location"`
+	Reference string `gorm:"size:100" json:"reference"`
+	Comment   string `gorm:"size:255" json:"comment"`
+}
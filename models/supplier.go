@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Supplier - поставщик товаров
+type Supplier struct {
+	ID        uint           `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	Name          string `gorm:"uniqueIndex;size:200;not null" json:"name"`
+	ContactPerson string `gorm:"size:200" json:"contact_person"`
+	Phone         string `gorm:"size:50" json:"phone"`
+	Email         string `gorm:"size:100" json:"email"`
+}
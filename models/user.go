@@ -0,0 +1,181 @@
+package models
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Role string
+
+const (
+	RoleAdmin       Role = "admin"
+	RoleManager     Role = "manager"
+	RoleStorekeeper Role = "storekeeper"
+	RoleViewer      Role = "viewer"
+)
+
+// Roles - роли в порядке убывания прав
+var Roles = []Role{RoleAdmin, RoleManager, RoleStorekeeper, RoleViewer}
+
+var roleTitles = map[Role]string{
+	RoleAdmin:       "Администратор",
+	RoleManager:     "Менеджер",
+	RoleStorekeeper: "Кладовщик",
+	RoleViewer:      "Наблюдатель",
+}
+
+// Title возвращает название роли для интерфейса
+func (r Role) Title() string {
+	if title, ok := roleTitles[r]; ok {
+		return title
+	}
+	return string(r)
+}
+
+// Permission - действие, доступ к которому зависит от роли
+type Permission string
+
+const (
+	PermEditProducts       Permission = "edit_products"
+	PermEditPrices         Permission = "edit_prices"
+	PermAdjustStock        Permission = "adjust_stock"
+	PermDeleteProducts     Permission = "delete_products"
+	PermViewPurchasePrices Permission = "view_purchase_prices"
+	PermManageOrders       Permission = "manage_orders"
+	PermManageCatalog      Permission = "manage_catalog"
+	PermManageUsers        Permission = "manage_users"
+	PermViewAuditLog       Permission = "view_audit_log"
+	PermManageBackups      Permission = "manage_backups"
+)
+
+var permissionTitles = map[Permission]string{
+	PermEditProducts:       "изменение товаров",
+	PermEditPrices:         "изменение цен",
+	PermAdjustStock:        "изменение остатков",
+	PermDeleteProducts:     "удаление товаров",
+	PermViewPurchasePrices: "просмотр закупочных цен",
+	PermManageOrders:       "заказы поставщикам",
+	PermManageCatalog:      "справочники",
+	PermManageUsers:        "управление пользователями",
+	PermViewAuditLog:       "журнал изменений",
+	PermManageBackups:      "резервные копии",
+}
+
+// Title возвращает название права для сообщений
+func (p Permission) Title() string {
+	return permissionTitles[p]
+}
+
+// rolePermissions - права ролей; администратору разрешено все
+var rolePermissions = map[Role][]Permission{
+	RoleManager: {
+		PermEditProducts, PermEditPrices, PermAdjustStock, PermDeleteProducts,
+		PermViewPurchasePrices, PermManageOrders, PermManageCatalog, PermViewAuditLog,
+	},
+	RoleStorekeeper: {PermEditProducts, PermAdjustStock},
+	RoleViewer:      {},
+}
+
+// Can сообщает, разрешено ли роли действие
+func (r Role) Can(p Permission) bool {
+	if r == RoleAdmin {
+		return true
+	}
+	for _, allowed := range rolePermissions[r] {
+		if allowed == p {
+			return true
+		}
+	}
+	return false
+}
+
+// User - учетная запись пользователя склада
+type User struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Username string `gorm:"size:50;uniqueIndex;not null" json:"username"`
+	FullName string `gorm:"size:200" json:"full_name"`
+	// PasswordHash - хеш PBKDF2, см. SetPassword
+	PasswordHash string `gorm:"size:200;not null" json:"-" audit:"secret"`
+	Role         Role   `gorm:"size:20;not null" json:"role"`
+	// Disabled - учетная запись заблокирована
+	Disabled    bool       `json:"disabled"`
+	LastLoginAt *time.Time `json:"last_login_at" audit:"-"`
+}
+
+// Can сообщает, разрешено ли пользователю действие
+func (u *User) Can(p Permission) bool {
+	return !u.Disabled && u.Role.Can(p)
+}
+
+// DisplayName возвращает имя пользователя для интерфейса
+func (u *User) DisplayName() string {
+	if u.FullName != "" {
+		return u.FullName
+	}
+	return u.Username
+}
+
+// Параметры хеширования паролей: PBKDF2-HMAC-SHA256, рекомендация OWASP на 2023 год
+const (
+	passwordIterations = 600000
+	passwordSaltSize   = 16
+	passwordKeySize    = 32
+	// MinPasswordLength - минимальная длина пароля
+	MinPasswordLength = 8
+)
+
+// SetPassword сохраняет хеш пароля в виде "pbkdf2-sha256$<итерации>$<соль>$<хеш>"
+func (u *User) SetPassword(password string) error {
+	if len([]rune(password)) < MinPasswordLength {
+		return Errorf("пароль должен быть не короче %d символов", MinPasswordLength)
+	}
+
+	salt := make([]byte, passwordSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, passwordIterations, passwordKeySize)
+	if err != nil {
+		return err
+	}
+
+	u.PasswordHash = fmt.Sprintf("pbkdf2-sha256$%d$%s$%s", passwordIterations,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+	return nil
+}
+
+// CheckPassword сравнивает пароль с сохраненным хешем за постоянное время
+func (u *User) CheckPassword(password string) bool {
+	parts := strings.Split(u.PasswordHash, "$")
+	if len(parts) != 4 || parts[0] != "pbkdf2-sha256" {
+		return false
+	}
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations <= 0 {
+		return false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	expected, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return false
+	}
+
+	key, err := pbkdf2.Key(sha256.New, password, salt, iterations, len(expected))
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(key, expected) == 1
+}
@@ -0,0 +1,200 @@
+package models
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Имена полей товара в ошибках проверки, совпадают с колонками БД
+const (
+	FieldSKU              = "sku"
+	FieldName             = "name"
+	FieldQuantity         = "quantity"
+	FieldReserved         = "reserved_quantity"
+	FieldPurchasePrice    = "purchase_price"
+	FieldPurchaseCurrency = "purchase_currency"
+	FieldSellingPrice     = "selling_price"
+	FieldMinStockLevel    = "min_stock_level"
+	FieldReorderPoint     = "reorder_point"
+	FieldReorderQuantity  = "reorder_quantity"
+	FieldMaxStockLevel    = "max_stock_level"
+	FieldLeadTimeDays     = "lead_time_days"
+	FieldWeight           = "weight"
+)
+
+// skuPattern - допустимый артикул: латинские буквы, цифры и разделители . _ / -
+var skuPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._/-]*$`)
+
+// ValidationIssue - замечание к полю. Предупреждение не мешает сохранению.
+// Message - шаблон по-русски и ключ перевода, как у UserError.
+type ValidationIssue struct {
+	Field   string
+	Message string
+	Args    []interface{}
+	Warning bool
+}
+
+// Localize возвращает текст замечания, переведенный функцией translate
+func (i ValidationIssue) Localize(translate func(string) string) string {
+	return localize(translate, i.Message, i.Args)
+}
+
+// ValidationErrors - результат проверки товара
+type ValidationErrors []ValidationIssue
+
+// Add добавляет ошибку поля
+func (v *ValidationErrors) Add(field, message string, args ...interface{}) {
+	*v = append(*v, ValidationIssue{Field: field, Message: message, Args: args})
+}
+
+// Warn добавляет предупреждение к полю
+func (v *ValidationErrors) Warn(field, message string, args ...interface{}) {
+	*v = append(*v, ValidationIssue{Field: field, Message: message, Args: args, Warning: true})
+}
+
+// HasErrors сообщает, есть ли замечания, запрещающие сохранение
+func (v ValidationErrors) HasErrors() bool {
+	for _, issue := range v {
+		if !issue.Warning {
+			return true
+		}
+	}
+	return false
+}
+
+// ForField возвращает первое замечание к полю: ошибки важнее предупреждений
+func (v ValidationErrors) ForField(field string) (ValidationIssue, bool) {
+	var found *ValidationIssue
+	for i := range v {
+		if v[i].Field != field {
+			continue
+		}
+		if !v[i].Warning {
+			return v[i], true
+		}
+		if found == nil {
+			found = &v[i]
+		}
+	}
+	if found == nil {
+		return ValidationIssue{}, false
+	}
+	return *found, true
+}
+
+func (v ValidationErrors) Error() string {
+	return v.Localize(func(s string) string { return s })
+}
+
+// Localize возвращает ошибки через "; ", переведенные функцией translate
+func (v ValidationErrors) Localize(translate func(string) string) string {
+	var messages []string
+	for _, issue := range v {
+		if !issue.Warning {
+			messages = append(messages, issue.Localize(translate))
+		}
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Validate проверяет заполнение полей товара. Уникальность артикула
+// проверяется отдельно, см. database.ValidateProduct.
+func (p *Product) Validate() ValidationErrors {
+	var errs ValidationErrors
+
+	sku := strings.TrimSpace(p.SKU)
+	switch {
+	case sku == "":
+		errs.Add(FieldSKU, "Укажите артикул")
+	case utf8.RuneCountInString(sku) > 50:
+		errs.Add(FieldSKU, "Артикул длиннее 50 символов")
+	case !skuPattern.MatchString(sku):
+		errs.Add(FieldSKU, "Артикул может содержать только латинские буквы, цифры и символы . _ / -")
+	}
+
+	name := strings.TrimSpace(p.Name)
+	switch {
+	case name == "":
+		errs.Add(FieldName, "Укажите наименование")
+	case utf8.RuneCountInString(name) > 200:
+		errs.Add(FieldName, "Наименование длиннее 200 символов")
+	}
+
+	nonNegative := map[string]float64{
+		FieldQuantity:        float64(p.Quantity),
+		FieldReserved:        float64(p.ReservedQuantity),
+		FieldPurchasePrice:   p.PurchasePrice.Float(),
+		FieldSellingPrice:    p.SellingPrice.Float(),
+		FieldMinStockLevel:   float64(p.MinStockLevel),
+		FieldReorderPoint:    float64(p.ReorderPoint),
+		FieldReorderQuantity: float64(p.ReorderQuantity),
+		FieldMaxStockLevel:   float64(p.MaxStockLevel),
+		FieldLeadTimeDays:    float64(p.LeadTimeDays),
+		FieldWeight:          p.Weight,
+	}
+	for field, value := range nonNegative {
+		if value < 0 {
+			errs.Add(field, "Значение не может быть отрицательным")
+		}
+	}
+
+	if p.Quantity >= 0 && p.ReservedQuantity > p.Quantity {
+		errs.Add(FieldReserved, "Зарезервировано больше, чем есть на складе (%d)", p.Quantity)
+	}
+	if p.MaxStockLevel > 0 && p.MaxStockLevel < p.MinStockLevel {
+		errs.Add(FieldMaxStockLevel, "Макс. уровень меньше минимального (%d)", p.MinStockLevel)
+	}
+	if p.PurchaseCurrency != "" && !p.PurchaseCurrency.Valid() {
+		errs.Add(FieldPurchaseCurrency, "Укажите код валюты из трех латинских букв")
+	}
+	// Цены в разных валютах без курса не сравнить, такая проверка - в карточке товара
+	if p.SellingPrice >= 0 && p.SellingPrice < p.PurchasePrice &&
+		(p.PurchaseCurrency == "" || p.PurchaseCurrency == BaseCurrency) {
+		errs.Warn(FieldSellingPrice, "Цена продажи ниже закупочной")
+	}
+
+	return errs
+}
+
+// normalizeNumber убирает пробелы-разделители разрядов и приводит десятичный разделитель к точке.
+// Если в строке есть и точка, и запятая, десятичным считается последний из них: "1.234,5" и "1,234.5".
+func normalizeNumber(s string) string {
+	s = strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\u00a0', '\u202f', '\'':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(s))
+
+	comma, dot := strings.LastIndex(s, ","), strings.LastIndex(s, ".")
+	switch {
+	case comma >= 0 && dot >= 0 && comma > dot:
+		s = strings.ReplaceAll(s, ".", "")
+	case comma >= 0 && dot >= 0:
+		s = strings.ReplaceAll(s, ",", "")
+	}
+	return strings.ReplaceAll(s, ",", ".")
+}
+
+// ParseDecimal разбирает дробное число, введенное пользователем: "10,5", "1 234.50"
+func ParseDecimal(s string) (float64, error) {
+	value, err := strconv.ParseFloat(normalizeNumber(s), 64)
+	// ParseFloat понимает и "NaN", "Inf": такие значения не сохранить и не пересчитать
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, Errorf("некорректное число: %s", s)
+	}
+	return value, nil
+}
+
+// ParseInteger разбирает целое число, допуская пробелы между разрядами: "1 000"
+func ParseInteger(s string) (int, error) {
+	value, err := strconv.Atoi(normalizeNumber(s))
+	if err != nil {
+		return 0, Errorf("некорректное целое число: %s", s)
+	}
+	return value, nil
+}